@@ -0,0 +1,41 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	client "github.com/600apples/go-vast-client/pkg/vast_client"
+)
+
+func main() {
+	ctx := context.Background()
+	config := &client.VMSConfig{
+		Host:     "10.27.40.1",
+		Username: "admin",
+		Password: "123456",
+	}
+	rest := client.NewVMSRest(config)
+
+	// Acknowledge all critical alarms which are not acknowledged yet
+	alarms, err := rest.Alarms.ListBySeverity(ctx, client.AlarmSeverityCritical, nil)
+	if err != nil {
+		panic(err)
+	}
+	for _, alarm := range alarms {
+		if acknowledged, _ := alarm.GetBool("acknowledged"); acknowledged {
+			continue
+		}
+		id, err := alarm.ID()
+		if err != nil {
+			panic(err)
+		}
+		if _, err = rest.Alarms.Acknowledge(ctx, id); err != nil {
+			panic(err)
+		}
+		fmt.Printf("acknowledged alarm %d: %v\n", id, alarm["alarm_message"])
+	}
+
+	// Clear informational alarms
+	if _, err = rest.Alarms.ClearAll(ctx, client.Params{"severity": client.AlarmSeverityInfo}); err != nil {
+		panic(err)
+	}
+}
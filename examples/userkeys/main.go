@@ -19,7 +19,10 @@ func main() {
 	if err != nil {
 		panic(err)
 	}
-	accessKey := result["access_key"].(string)
+	accessKey, err := result.GetString("access_key")
+	if err != nil {
+		panic(err)
+	}
 
 	fmt.Printf("access key: %s\n", accessKey)
 
@@ -7,4 +7,9 @@ require (
 	github.com/hashicorp/go-version v1.7.0
 )
 
-require github.com/stretchr/testify v1.10.0 // indirect
+require (
+	github.com/davecgh/go-spew v1.1.1 // indirect
+	github.com/pmezard/go-difflib v1.0.0 // indirect
+	github.com/stretchr/testify v1.10.0
+	gopkg.in/yaml.v3 v3.0.1 // indirect
+)
@@ -0,0 +1,124 @@
+package vast_client
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"strings"
+)
+
+// Field statuses reported in AdoptFieldReport
+const (
+	AdoptFieldMatch     = "match"
+	AdoptFieldTolerated = "tolerated"
+	AdoptFieldMismatch  = "mismatch"
+)
+
+// defaultCriticalFields are fields that must match exactly for adoption (see AdoptOptions).
+var defaultCriticalFields = []string{"path", "tenant_id"}
+
+// AdoptOptions controls how existing object is compared with desired spec in Adopt.
+type AdoptOptions struct {
+	Tolerances       map[string]float64 // Relative tolerance for numeric fields (e.g. {"hard_limit": 0.05} tolerates 5% drift).
+	CriticalFields   []string           // Fields that must match exactly. Default is path and tenant_id.
+	RefuseOnCritical bool               // Refuse adoption if any critical field mismatches.
+}
+
+// AdoptFieldReport describes comparison of single desired field.
+type AdoptFieldReport struct {
+	Field    string `json:"field"`
+	Desired  any    `json:"desired"`
+	Actual   any    `json:"actual"`
+	Status   string `json:"status"`
+	Critical bool   `json:"critical"`
+}
+
+// AdoptReport describes how existing object matches desired spec. Report can be serialized to JSON for review.
+type AdoptReport struct {
+	Resource  string             `json:"resource"`
+	Id        int64              `json:"id"`
+	Adoptable bool               `json:"adoptable"`
+	Fields    []AdoptFieldReport `json:"fields"`
+}
+
+// Mismatches returns names of fields that don't match desired spec (tolerated drift excluded).
+func (r AdoptReport) Mismatches() []string {
+	var fields []string
+	for _, f := range r.Fields {
+		if f.Status == AdoptFieldMismatch {
+			fields = append(fields, f.Field)
+		}
+	}
+	return fields
+}
+
+// AdoptionRefusedError is returned by Adopt when critical fields mismatch and RefuseOnCritical is set.
+type AdoptionRefusedError struct {
+	Resource string
+	Id       int64
+	Fields   []string
+}
+
+func (e *AdoptionRefusedError) Error() string {
+	return fmt.Sprintf("adoption of resource '%s' with id %d refused: critical fields mismatch: %s", e.Resource, e.Id, strings.Join(e.Fields, ", "))
+}
+
+// Adopt finds object created outside the client (single match semantics of Get) and compares it with desired spec.
+// Returns found Record and report with per-field comparison. Adopt never modifies anything on cluster.
+// NOTE: Only fields present in desired are compared.
+func (e *VastResourceEntry) Adopt(ctx context.Context, searchParams Params, desired Params, opts AdoptOptions) (Record, AdoptReport, error) {
+	report := AdoptReport{Resource: e.resourcePath}
+	record, err := e.Get(withFeature(ctx, "adopt"), searchParams)
+	if err != nil {
+		return nil, report, err
+	}
+	if report.Id, err = toInt(record["id"]); err != nil {
+		return nil, report, err
+	}
+	critical := opts.CriticalFields
+	if critical == nil {
+		critical = defaultCriticalFields
+	}
+	var criticalMismatches []string
+	for _, field := range sortedKeys(desired) {
+		fieldReport := AdoptFieldReport{
+			Field:    field,
+			Desired:  desired[field],
+			Actual:   record[field],
+			Critical: containsFold(critical, field),
+		}
+		switch {
+		case valuesEqual(fieldReport.Actual, fieldReport.Desired, isSetLikeField(field)):
+			fieldReport.Status = AdoptFieldMatch
+		case !fieldReport.Critical && withinTolerance(fieldReport.Actual, fieldReport.Desired, opts.Tolerances[field]):
+			fieldReport.Status = AdoptFieldTolerated
+		default:
+			fieldReport.Status = AdoptFieldMismatch
+			if fieldReport.Critical {
+				criticalMismatches = append(criticalMismatches, field)
+			}
+		}
+		report.Fields = append(report.Fields, fieldReport)
+	}
+	report.Adoptable = len(criticalMismatches) == 0
+	if !report.Adoptable && opts.RefuseOnCritical {
+		return record, report, &AdoptionRefusedError{Resource: e.resourcePath, Id: report.Id, Fields: criticalMismatches}
+	}
+	return record, report, nil
+}
+
+// withinTolerance reports whether numeric actual differs from desired no more than by tolerance (relative).
+func withinTolerance(actual, desired any, tolerance float64) bool {
+	if tolerance <= 0 {
+		return false
+	}
+	a, aOk := toFloat(actual)
+	d, dOk := toFloat(desired)
+	if !aOk || !dOk {
+		return false
+	}
+	if d == 0 {
+		return a == 0
+	}
+	return math.Abs(a-d)/math.Abs(d) <= tolerance
+}
@@ -0,0 +1,108 @@
+package vast_client
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAdopt(t *testing.T) {
+	existing := map[string]any{"id": 7, "name": "q", "path": "/data", "tenant_id": 1, "hard_limit": 1000, "soft_limit": 800}
+	tests := []struct {
+		name          string
+		desired       Params
+		opts          AdoptOptions
+		wantStatuses  map[string]string
+		wantAdoptable bool
+		wantRefused   []string
+	}{
+		{
+			name:          "match",
+			desired:       Params{"path": "/data", "tenant_id": 1, "hard_limit": 1000},
+			wantStatuses:  map[string]string{"path": AdoptFieldMatch, "tenant_id": AdoptFieldMatch, "hard_limit": AdoptFieldMatch},
+			wantAdoptable: true,
+		},
+		{
+			name:          "tolerable drift",
+			desired:       Params{"path": "/data", "hard_limit": 1040, "soft_limit": 700},
+			opts:          AdoptOptions{Tolerances: map[string]float64{"hard_limit": 0.05, "soft_limit": 0.05}},
+			wantStatuses:  map[string]string{"path": AdoptFieldMatch, "hard_limit": AdoptFieldTolerated, "soft_limit": AdoptFieldMismatch},
+			wantAdoptable: true,
+		},
+		{
+			name:         "critical mismatch is reported",
+			desired:      Params{"path": "/other", "tenant_id": 2},
+			wantStatuses: map[string]string{"path": AdoptFieldMismatch, "tenant_id": AdoptFieldMismatch},
+		},
+		{
+			name:         "critical mismatch refusal",
+			desired:      Params{"path": "/other", "tenant_id": 1},
+			opts:         AdoptOptions{RefuseOnCritical: true},
+			wantStatuses: map[string]string{"path": AdoptFieldMismatch, "tenant_id": AdoptFieldMatch},
+			wantRefused:  []string{"path"},
+		},
+		{
+			name:          "custom critical fields",
+			desired:       Params{"path": "/other", "hard_limit": 1040},
+			opts:          AdoptOptions{CriticalFields: []string{"hard_limit"}, Tolerances: map[string]float64{"hard_limit": 0.05}, RefuseOnCritical: true},
+			wantStatuses:  map[string]string{"path": AdoptFieldMismatch, "hard_limit": AdoptFieldMismatch},
+			wantRefused:   []string{"hard_limit"},
+			wantAdoptable: false,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			srv := newTestServer(t, recordsHandler(existing))
+			rest := newTestRest(t, srv.config())
+
+			record, report, err := rest.Quotas.Adopt(context.Background(), Params{"name": "q"}, tt.desired, tt.opts)
+
+			require.NotNil(t, record)
+			assert.Equal(t, int64(7), report.Id)
+			assert.Equal(t, tt.wantAdoptable, report.Adoptable)
+			statuses := map[string]string{}
+			for _, f := range report.Fields {
+				statuses[f.Field] = f.Status
+			}
+			assert.Equal(t, tt.wantStatuses, statuses)
+			if tt.wantRefused != nil {
+				var refused *AdoptionRefusedError
+				require.ErrorAs(t, err, &refused)
+				assert.Equal(t, tt.wantRefused, refused.Fields)
+			} else {
+				require.NoError(t, err)
+			}
+			for _, r := range srv.Requests() {
+				assert.Equal(t, http.MethodGet, r.Method, "adopt never modifies anything")
+			}
+		})
+	}
+}
+
+func TestAdoptReportIsSerializable(t *testing.T) {
+	srv := newTestServer(t, recordsHandler(map[string]any{"id": 1, "name": "v", "path": "/v"}))
+	rest := newTestRest(t, srv.config())
+
+	_, report, err := rest.Views.Adopt(context.Background(), Params{"name": "v"}, Params{"path": "/w"}, AdoptOptions{})
+	require.NoError(t, err)
+	raw, err := json.Marshal(report)
+	require.NoError(t, err)
+	assert.JSONEq(t, `{
+		"resource": "views", "id": 1, "adoptable": false,
+		"fields": [{"field": "path", "desired": "/w", "actual": "/v", "status": "mismatch", "critical": true}]
+	}`, string(raw))
+	assert.Equal(t, []string{"path"}, report.Mismatches())
+}
+
+func TestAdoptNotFound(t *testing.T) {
+	srv := newTestServer(t, recordsHandler())
+	rest := newTestRest(t, srv.config())
+
+	_, _, err := rest.Views.Adopt(context.Background(), Params{"name": "v"}, Params{"path": "/v"}, AdoptOptions{})
+	var notFound *NotFoundError
+	assert.ErrorAs(t, err, &notFound)
+}
@@ -4,8 +4,15 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"github.com/bndr/gotabulate"
 	version "github.com/hashicorp/go-version"
+	"net"
 	"net/http"
+	"net/url"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
 )
 
 //  ######################################################
@@ -17,18 +24,135 @@ type NotFoundError struct {
 	Query    string
 }
 
+// isNotFoundErr reports whether err is NotFoundError or ApiError with 404 status code.
 func isNotFoundErr(err error) bool {
 	var nfErr *NotFoundError
 	if errors.As(err, &nfErr) {
 		return true
 	}
-	return false
+	return isApiErrWithStatus(err, http.StatusNotFound)
 }
 
 func (e *NotFoundError) Error() string {
 	return fmt.Sprintf("resource '%s' not found for params '%s'", e.Resource, e.Query)
 }
 
+// maxReportedMatches limits number of matches described in AmbiguousMatchError
+const maxReportedMatches = 3
+
+// Operations reported by AmbiguousMatchError
+const (
+	opGet    = "get"
+	opUpdate = "update"
+	opDelete = "delete"
+)
+
+// AmbiguousMatchError is returned when query matches unexpected number of resources.
+// For Get (and Delete) exactly one match is expected. Use WithExpectedMatches to expect other number for Delete.
+type AmbiguousMatchError struct {
+	Resource  string
+	Query     string
+	Operation string   // Operation which looked resources up ("get", "update" or "delete")
+	Expected  int      // Number of expected matches
+	Count     int      // Number of actual matches
+	Matches   []string // Short descriptions (id/name) of first few matches
+}
+
+func newAmbiguousMatchError(operation, resource string, params Params, expected int, matches RecordSet) *AmbiguousMatchError {
+	var described []string
+	for i, r := range matches {
+		if i == maxReportedMatches {
+			break
+		}
+		desc := fmt.Sprintf("id=%v", r["id"])
+		if name, ok := r["name"]; ok {
+			desc += fmt.Sprintf(" name=%v", name)
+		}
+		described = append(described, desc)
+	}
+	return &AmbiguousMatchError{
+		Resource:  resource,
+		Query:     params.ToQuery(),
+		Operation: operation,
+		Expected:  expected,
+		Count:     len(matches),
+		Matches:   described,
+	}
+}
+
+func (e *AmbiguousMatchError) Error() string {
+	matches := strings.Join(e.Matches, ", ")
+	if e.Count > len(e.Matches) {
+		matches += ", ..."
+	}
+	msg := fmt.Sprintf("expected %d resource(s) '%s' for params '%s', found %d [%s]", e.Expected, e.Resource, e.Query, e.Count, matches)
+	if e.Operation == opDelete && e.Count > 0 {
+		return fmt.Sprintf(
+			"%s. Refine params or, to delete all of them, assert the number of matches explicitly: "+
+				"Delete(client.WithExpectedMatches(ctx, %d), params) or use DeleteAll", msg, e.Count,
+		)
+	}
+	return msg + ". Refine params to match exactly the expected number of resources"
+}
+
+// TooManyMatchesError is returned by Get (and GetStrict) when query matches more than one resource.
+// It wraps AmbiguousMatchError so both types can be checked with errors.As.
+type TooManyMatchesError struct {
+	*AmbiguousMatchError
+	Conflicts RecordSet // id/name/tenant_id of first few matched resources
+}
+
+func newTooManyMatchesError(operation, resource string, params Params, matches RecordSet) *TooManyMatchesError {
+	var conflicts RecordSet
+	for i, r := range matches {
+		if i == maxReportedMatches {
+			break
+		}
+		conflict := Record{}
+		for _, key := range []string{"id", "name", "tenant_id"} {
+			if val, ok := r[key]; ok {
+				conflict[key] = val
+			}
+		}
+		conflicts = append(conflicts, conflict)
+	}
+	return &TooManyMatchesError{
+		AmbiguousMatchError: newAmbiguousMatchError(operation, resource, params, 1, matches),
+		Conflicts:           conflicts,
+	}
+}
+
+func (e *TooManyMatchesError) Unwrap() error {
+	return e.AmbiguousMatchError
+}
+
+// Render prints error message followed by table of conflicting resources.
+func (e *TooManyMatchesError) Render() string {
+	var rows [][]any
+	for _, r := range e.Conflicts {
+		rows = append(rows, []any{fmt.Sprintf("%v", r["id"]), fmt.Sprintf("%v", r["name"]), fmt.Sprintf("%v", r["tenant_id"])})
+	}
+	if len(rows) == 0 {
+		return e.Error()
+	}
+	t := gotabulate.Create(rows)
+	t.SetHeaders([]string{"id", "name", "tenant_id"})
+	t.SetAlign("left")
+	return fmt.Sprintf("%s\n%s", e.Error(), t.Render("grid"))
+}
+
+// ValidationError is returned when params fail client-side validation before any request is sent.
+// All detected problems are reported at once.
+type ValidationError struct {
+	Resource string
+	Fields   []string // Fields at fault
+	Problems []string // Human-readable description of each problem
+}
+
+func (e *ValidationError) Error() string {
+	return fmt.Sprintf("invalid params for resource '%s': %s", e.Resource, strings.Join(e.Problems, "; "))
+}
+
 // VastResource defines the interface for standard CRUD operations on a VAST resource.
 type VastResource interface {
 	Session() RESTSession
@@ -36,8 +160,10 @@ type VastResource interface {
 	List(context.Context, Params) (RecordSet, error)
 	Create(context.Context, Params) (Record, error)
 	Update(context.Context, int64, Params) (Record, error)
+	UpdateByParams(context.Context, Params, Params) (Record, error)
 	Delete(context.Context, Params) (EmptyRecord, error)
 	Ensure(context.Context, string, Params) (Record, error)
+	EnsureByParams(context.Context, Params, Params, EnsureOptions) (Record, error)
 	DeleteById(context.Context, int64) (EmptyRecord, error)
 	Get(context.Context, Params) (Record, error)
 	GetById(context.Context, int64) (Record, error)
@@ -85,7 +211,7 @@ func checkVastResourceVersionCompat(ctx context.Context, e *VastResourceEntry) e
 	}
 	clusterVersion, _ := e.rest.Versions.GetVersion(ctx)
 	if compareOrd == -1 {
-		return fmt.Errorf("resource %q is not supported in VAST cluster version %s (supported from version %s)", e.resourceType, clusterVersion, e.availableFromVersion)
+		return &UnsupportedVersionError{What: fmt.Sprintf("resource %q", e.resourceType), ClusterVersion: clusterVersion.String(), MinVersion: e.availableFromVersion.String()}
 	}
 	return nil
 }
@@ -97,6 +223,92 @@ type VastResourceEntry struct {
 	apiVersion           string
 	availableFromVersion *version.Version
 	rest                 *VMSRest
+	requiredOnCreate     []string      // Fields which must be provided on Create (see preflightCreate)
+	idField              string        // Identifier field of the resource if it is not "id" (see SetIdField)
+	interceptors         []Interceptor // Interceptors of the resource handle (see WithInterceptors)
+	tenantFilter         string        // Query param filtering resources by tenant if it is not default (see SetTenantFilterKey)
+	retryPolicy          *RetryPolicy  // Retry policy of the resource if it is not default (see SetRetryPolicy)
+}
+
+// SetIdField overrides name of the field which identifies resource in records returned by VMS
+// (e.g. "guid"). Value of this field is used in resource path by Delete, DeleteAsync and UpdateByParams.
+func (e *VastResourceEntry) SetIdField(field string) {
+	e.idField = field
+}
+
+func (e *VastResourceEntry) getIdField() string {
+	if e.idField == "" {
+		return "id"
+	}
+	return e.idField
+}
+
+// SetTenantFilterKey overrides name of query param used to filter resources by tenant in ListForTenant
+// and in GET requests of tenant-scoped clients (see ForTenant). Default is set on resource registration
+// (see withTenantFilter) or "tenant_id".
+func (e *VastResourceEntry) SetTenantFilterKey(key string) {
+	e.tenantFilter = key
+}
+
+func (e *VastResourceEntry) getTenantFilterKey() string {
+	if e.tenantFilter != "" {
+		return e.tenantFilter
+	}
+	return "tenant_id"
+}
+
+// resourceOption customizes resource on registration (see newResource).
+type resourceOption func(e *VastResourceEntry)
+
+// withTenantFilter sets query param filtering resource by tenant for endpoints which don't accept "tenant_id".
+func withTenantFilter(key string) resourceOption {
+	return func(e *VastResourceEntry) {
+		e.tenantFilter = key
+	}
+}
+
+// resourceEntry is implemented by resources embedding VastResourceEntry.
+type resourceEntry interface {
+	entry() *VastResourceEntry
+}
+
+func (e *VastResourceEntry) entry() *VastResourceEntry {
+	return e
+}
+
+// inheritOverrides copies per-resource overrides of parent resource (see ForTenant).
+func (e *VastResourceEntry) inheritOverrides(parent *VastResourceEntry) {
+	e.tenantFilter = parent.tenantFilter
+	e.idField = parent.idField
+	e.apiVersion = parent.apiVersion
+	e.requiredOnCreate = slices.Clone(parent.requiredOnCreate)
+	e.retryPolicy = parent.retryPolicy
+}
+
+// SetRequiredOnCreate overrides list of fields which must be present in Create body.
+// Missing fields are reported with ValidationError before any request is sent.
+// Call without arguments to disable preflight check for particular resource.
+func (e *VastResourceEntry) SetRequiredOnCreate(fields ...string) {
+	e.requiredOnCreate = fields
+}
+
+// preflightCreate checks that all required fields are present in Create body.
+// Check can be disabled for all resources with VMSConfig.DisableCreatePreflight.
+func (e *VastResourceEntry) preflightCreate(body Params) error {
+	if e.Session().GetConfig().DisableCreatePreflight {
+		return nil
+	}
+	var missing, problems []string
+	for _, field := range e.requiredOnCreate {
+		if val, ok := body[field]; !ok || val == nil {
+			missing = append(missing, field)
+			problems = append(problems, fmt.Sprintf("field %q is required on create", field))
+		}
+	}
+	if len(missing) > 0 {
+		return &ValidationError{Resource: e.resourcePath, Fields: missing, Problems: problems}
+	}
+	return nil
 }
 
 // Session returns the current VMSSession associated with the resource.
@@ -109,11 +321,48 @@ func (e *VastResourceEntry) GetResourceType() string {
 }
 
 // List retrieves all resources matching the given parameters.
+// If response is paginated all pages are fetched unless "page" param is provided explicitly.
+// Limit and offset set with Params.Limit and Params.Offset are applied on client side.
 func (e *VastResourceEntry) List(ctx context.Context, params Params) (RecordSet, error) {
 	if err := checkVastResourceVersionCompat(ctx, e); err != nil {
 		return nil, err
 	}
-	return request[RecordSet](ctx, e, http.MethodGet, e.resourcePath, e.apiVersion, params, nil)
+	query, limit, offset, err := e.extractLimitOffset(params)
+	if err != nil {
+		return nil, err
+	}
+	if limit >= 0 || offset > 0 {
+		return e.listLimited(ctx, query, limit, offset)
+	}
+	if _, ok := params["page"]; ok {
+		return request[RecordSet](ctx, e, http.MethodGet, e.resourcePath, e.apiVersion, params, nil)
+	}
+	return e.listAllPages(ctx, params)
+}
+
+// listAllPages fetches pages one by one while paginated response refers to the next page.
+func (e *VastResourceEntry) listAllPages(ctx context.Context, params Params) (RecordSet, error) {
+	var all RecordSet
+	pageParams := Params{}
+	pageParams.Update(params, false)
+	for pageNum := 1; ; pageNum++ {
+		var page pageInfo
+		if pageNum > 1 {
+			pageParams["page"] = pageNum
+		}
+		result, err := request[RecordSet](withPageCapture(ctx, &page), e, http.MethodGet, e.resourcePath, e.apiVersion, pageParams, nil)
+		if err != nil {
+			return nil, err
+		}
+		if all == nil {
+			all = result
+		} else {
+			all = append(all, result...)
+		}
+		if !page.hasNext() {
+			return all, nil
+		}
+	}
 }
 
 // Create creates a new resource using the provided parameters.
@@ -121,6 +370,12 @@ func (e *VastResourceEntry) Create(ctx context.Context, body Params) (Record, er
 	if err := checkVastResourceVersionCompat(ctx, e); err != nil {
 		return nil, err
 	}
+	if err := e.preflightCreate(body); err != nil {
+		return nil, err
+	}
+	if err := e.checkFieldFeatures(ctx, body); err != nil {
+		return nil, err
+	}
 	return request[Record](ctx, e, http.MethodPost, e.resourcePath, e.apiVersion, nil, body)
 }
 
@@ -129,13 +384,35 @@ func (e *VastResourceEntry) Update(ctx context.Context, id int64, body Params) (
 	if err := checkVastResourceVersionCompat(ctx, e); err != nil {
 		return nil, err
 	}
+	if err := e.checkFieldFeatures(ctx, body); err != nil {
+		return nil, err
+	}
 	path := fmt.Sprintf("%s/%d", e.resourcePath, id)
 	return request[Record](ctx, e, http.MethodPatch, path, e.apiVersion, nil, body)
 }
 
+// Replace fully replaces an existing resource by its ID using the provided parameters (PUT request).
+// Unlike Update (PATCH) fields missing in body may be reset by VMS.
+func (e *VastResourceEntry) Replace(ctx context.Context, id int64, body Params) (Record, error) {
+	if err := checkVastResourceVersionCompat(ctx, e); err != nil {
+		return nil, err
+	}
+	path := fmt.Sprintf("%s/%d", e.resourcePath, id)
+	return request[Record](ctx, e, http.MethodPut, path, e.apiVersion, nil, body)
+}
+
 // Delete finds and deletes a resource using the provided query and body parameters.
+// If context is created with WithExpectedMatches(ctx, n) all n matched resources are deleted
+// but only when query matches exactly n resources. Otherwise AmbiguousMatchError is returned.
+// Expected number of matches is not passed to requests made by Delete.
 func (e *VastResourceEntry) Delete(ctx context.Context, params Params) (EmptyRecord, error) {
-	result, err := e.Get(ctx, params)
+	if expected, ok := expectedMatchesFromContext(ctx); ok {
+		if expected < 0 {
+			return nil, fmt.Errorf("expected matches must not be negative, got %d", expected)
+		}
+		return e.deleteExpected(withoutExpectedMatches(ctx), params, expected)
+	}
+	result, err := e.get(ctx, params, opDelete)
 	if err != nil {
 		if isNotFoundErr(err) {
 			// Resource not found. For "Delete" it is not error condition.
@@ -144,15 +421,310 @@ func (e *VastResourceEntry) Delete(ctx context.Context, params Params) (EmptyRec
 		}
 		return nil, err
 	}
-	idVal, ok := result["id"]
-	if !ok {
-		return nil, fmt.Errorf("resource '%s' does not have id field in body and thereby cannot be deleted by id")
+	ident, err := e.recordIdentifier(result, params)
+	if err != nil {
+		return nil, err
+	}
+	return e.deleteByIdentifier(ctx, ident)
+}
+
+// recordIdentifier extracts identifier of the resource (see SetIdField) from record returned by VMS.
+// Numeric identifiers are formatted without fraction part. params are used for error reporting only.
+func (e *VastResourceEntry) recordIdentifier(record Record, params Params) (string, error) {
+	idField := e.getIdField()
+	idVal, ok := record[idField]
+	if !ok || idVal == nil {
+		return "", fmt.Errorf(
+			"resource '%s' (%s) found for params '%s' does not have %q field in body and thereby cannot be addressed by id. Present keys: [%s]",
+			e.resourcePath, e.resourceType, params.ToQuery(), idField, strings.Join(sortedKeys(Params(record)), ", "),
+		)
+	}
+	if idInt, err := toInt(idVal); err == nil {
+		return strconv.FormatInt(idInt, 10), nil
+	}
+	return fmt.Sprintf("%v", idVal), nil
+}
+
+// recordId extracts numeric id of the resource from record returned by VMS.
+func (e *VastResourceEntry) recordId(record Record, params Params) (int64, error) {
+	ident, err := e.recordIdentifier(record, params)
+	if err != nil {
+		return 0, err
+	}
+	idInt, err := strconv.ParseInt(ident, 10, 64)
+	if err != nil {
+		return 0, fmt.Errorf("resource '%s' identifier %q (field %q) is not numeric", e.resourcePath, ident, e.getIdField())
+	}
+	return idInt, nil
+}
+
+// deleteByIdentifier deletes a resource using its identifier (numeric id or value of custom id field).
+func (e *VastResourceEntry) deleteByIdentifier(ctx context.Context, ident string) (EmptyRecord, error) {
+	if err := checkVastResourceVersionCompat(ctx, e); err != nil {
+		return nil, err
+	}
+	path := fmt.Sprintf("%s/%s", e.resourcePath, url.PathEscape(ident))
+	return request[EmptyRecord](ctx, e, http.MethodDelete, path, e.apiVersion, nil, nil)
+}
+
+// updateByIdentifier updates a resource using its identifier (numeric id or value of custom id field).
+func (e *VastResourceEntry) updateByIdentifier(ctx context.Context, ident string, body Params) (Record, error) {
+	if err := checkVastResourceVersionCompat(ctx, e); err != nil {
+		return nil, err
 	}
-	idInt, err := toInt(idVal)
+	path := fmt.Sprintf("%s/%s", e.resourcePath, url.PathEscape(ident))
+	return request[Record](ctx, e, http.MethodPatch, path, e.apiVersion, nil, body)
+}
+
+// deleteExpected deletes all resources matched by params. Nothing is deleted if number of matches is not equal to expected.
+func (e *VastResourceEntry) deleteExpected(ctx context.Context, params Params, expected int) (EmptyRecord, error) {
+	result, err := e.List(ctx, params)
 	if err != nil {
 		return nil, err
 	}
-	return e.DeleteById(ctx, idInt)
+	if len(result) != expected {
+		return nil, newAmbiguousMatchError(opDelete, e.resourcePath, params, expected, result)
+	}
+	for _, record := range result {
+		ident, err := e.recordIdentifier(record, params)
+		if err != nil {
+			return nil, err
+		}
+		if _, err = e.deleteByIdentifier(ctx, ident); err != nil {
+			return nil, err
+		}
+	}
+	return EmptyRecord{}, nil
+}
+
+// UpdateByParams finds a resource using the provided query params (single match semantics of Get)
+// and updates it by ID using the provided body.
+func (e *VastResourceEntry) UpdateByParams(ctx context.Context, searchParams Params, body Params) (Record, error) {
+	result, err := e.get(ctx, searchParams, opUpdate)
+	if err != nil {
+		return nil, err
+	}
+	ident, err := e.recordIdentifier(result, searchParams)
+	if err != nil {
+		return nil, err
+	}
+	return e.updateByIdentifier(ctx, ident, body)
+}
+
+// DeleteAndWait finds and deletes a resource using the provided query params
+// and then waits until resource is actually gone (see WaitForDeletion).
+// Not found resource is not an error condition.
+func (e *VastResourceEntry) DeleteAndWait(ctx context.Context, params Params, opts ...PollOptions) (EmptyRecord, error) {
+	result, err := e.get(ctx, params, opDelete)
+	if err != nil {
+		if isNotFoundErr(err) {
+			return EmptyRecord{}, nil
+		}
+		return nil, err
+	}
+	idInt, err := e.recordId(result, params)
+	if err != nil {
+		return nil, err
+	}
+	if _, err = e.DeleteById(ctx, idInt); err != nil {
+		return nil, err
+	}
+	if err = e.WaitForDeletion(ctx, idInt, firstOrDefault(opts)); err != nil {
+		return nil, err
+	}
+	return EmptyRecord{}, nil
+}
+
+// idempotentRequeryTimeout bounds lookup made by CreateIdempotent after caller context is already done.
+const idempotentRequeryTimeout = 30 * time.Second
+
+// isTimeoutErr reports whether err means that request outcome is unknown: request may have succeeded
+// on server side but response was not received (client timeout, network timeout or gateway error).
+func isTimeoutErr(err error) bool {
+	var clientTimeout *ClientTimeoutError
+	var netErr net.Error
+	switch {
+	case errors.As(err, &clientTimeout), errors.Is(err, context.DeadlineExceeded):
+		return true
+	case errors.As(err, &netErr) && netErr.Timeout():
+		return true
+	}
+	return isApiErrWithStatus(err, http.StatusBadGateway) ||
+		isApiErrWithStatus(err, http.StatusServiceUnavailable) ||
+		isApiErrWithStatus(err, http.StatusGatewayTimeout)
+}
+
+// CreateIdempotent creates resource unless resource with the same values of uniqueKeys (taken from body) already exists.
+// If Create fails with timeout-classified error (request may have succeeded on server side) resource is looked up again
+// and returned if it was created. Otherwise Create is retried with backoff according to retry policy
+// (see WithRetryPolicy, SetRetryPolicy and VMSConfig.Retry; without policy Create is not retried).
+// This is the only path where mutating requests are retried by client.
+func (e *VastResourceEntry) CreateIdempotent(ctx context.Context, uniqueKeys []string, body Params) (Record, error) {
+	if len(uniqueKeys) == 0 {
+		return nil, errors.New("at least one unique key is required")
+	}
+	searchParams := Params{}
+	for _, key := range uniqueKeys {
+		value, ok := body[key]
+		if !ok || value == nil {
+			return nil, &ValidationError{Resource: e.resourcePath, Fields: []string{key}, Problems: []string{fmt.Sprintf("unique key %q is missing in body", key)}}
+		}
+		searchParams[key] = value
+	}
+	ctx = withFeature(ctx, "create_idempotent")
+	existing, err := e.Get(ctx, searchParams)
+	if err == nil {
+		return existing, nil
+	} else if !isNotFoundErr(err) {
+		return nil, err
+	}
+	policy := resolveRetryPolicy(ctx, e, e.Session().GetConfig())
+	for attempt := 1; ; attempt++ {
+		created, err := e.Create(ctx, body)
+		if err == nil || !isTimeoutErr(err) {
+			return created, err
+		}
+		lookupCtx, cancel := ctx, context.CancelFunc(func() {})
+		if ctx.Err() != nil {
+			// Caller deadline is over but outcome of Create must still be reported correctly.
+			lookupCtx, cancel = context.WithTimeout(context.WithoutCancel(ctx), idempotentRequeryTimeout)
+		}
+		existing, lookupErr := e.Get(lookupCtx, searchParams)
+		cancel()
+		if lookupErr == nil {
+			return existing, nil
+		}
+		if attempt >= policy.MaxAttempts || ctx.Err() != nil || !isNotFoundErr(lookupErr) {
+			return nil, err
+		}
+		if !waitRetry(ctx, policy.delay(attempt)) {
+			return nil, err
+		}
+	}
+}
+
+// CreateAsync creates a new resource and, if VMS responds with asynchronous VTask instead of resource,
+// waits for the task to complete (see WaitTask) and fetches created resource.
+// If response is not a task reference it is returned as is (same as Create).
+func (e *VastResourceEntry) CreateAsync(ctx context.Context, body Params, opts ...WaitTaskOptions) (Record, error) {
+	if err := checkVastResourceVersionCompat(ctx, e); err != nil {
+		return nil, err
+	}
+	if err := e.preflightCreate(body); err != nil {
+		return nil, err
+	}
+	task, isTask, err := e.requestAndWaitTask(ctx, http.MethodPost, e.resourcePath, body, false, opts)
+	if err != nil || !isTask {
+		return task, err
+	}
+	// Fetch final resource
+	if objectId, err := toInt(task["object_id"]); err == nil {
+		return e.GetById(ctx, objectId)
+	}
+	if name, ok := body["name"]; ok {
+		return e.Get(ctx, Params{"name": name})
+	}
+	return nil, fmt.Errorf("resource '%s' was created by task %v but cannot be fetched: task has no object_id and body has no name", e.resourcePath, task["id"])
+}
+
+// DeleteAsync finds and deletes a resource using the provided query params. If VMS responds with
+// asynchronous VTask, waits for the task to complete (see WaitTask). Not found resource is not an error condition.
+func (e *VastResourceEntry) DeleteAsync(ctx context.Context, params Params, opts ...WaitTaskOptions) (EmptyRecord, error) {
+	result, err := e.get(ctx, params, opDelete)
+	if err != nil {
+		if isNotFoundErr(err) {
+			return EmptyRecord{}, nil
+		}
+		return nil, err
+	}
+	ident, err := e.recordIdentifier(result, params)
+	if err != nil {
+		return nil, err
+	}
+	path := fmt.Sprintf("%s/%s", e.resourcePath, url.PathEscape(ident))
+	if _, _, err = e.requestAndWaitTask(ctx, http.MethodDelete, path, nil, false, opts); err != nil {
+		return nil, err
+	}
+	return EmptyRecord{}, nil
+}
+
+// actionRequest sends request to action sub-endpoint of resource with given id (e.g. PATCH clusters/{id}/ssl_certificate).
+// Optional features are known features (see Feature* constants) which cluster version must support.
+func (e *VastResourceEntry) actionRequest(ctx context.Context, verb string, id int64, action string, params, body Params, features ...string) (Record, error) {
+	if err := checkVastResourceVersionCompat(ctx, e); err != nil {
+		return nil, err
+	}
+	if err := e.rest.requireFeatures(ctx, features); err != nil {
+		return nil, err
+	}
+	path := fmt.Sprintf("%s/%d/%s", e.resourcePath, id, strings.Trim(action, "/"))
+	return request[Record](ctx, e, verb, path, e.apiVersion, params, body)
+}
+
+// queryRequest sends query-style request: POST to sub-endpoint of resource (e.g. users/query) with lookup criteria
+// in body which responds with single object instead of list. Empty response is reported as NotFoundError.
+// Optional features are known features (see Feature* constants) which cluster version must support.
+func (e *VastResourceEntry) queryRequest(ctx context.Context, subPath string, query Params, features ...string) (Record, error) {
+	if err := checkVastResourceVersionCompat(ctx, e); err != nil {
+		return nil, err
+	}
+	if err := e.rest.requireFeatures(ctx, features); err != nil {
+		return nil, err
+	}
+	path := fmt.Sprintf("%s/%s", e.resourcePath, strings.Trim(subPath, "/"))
+	result, err := request[Record](ctx, e, http.MethodPost, path, e.apiVersion, nil, query)
+	if err != nil && !isApiErrWithStatus(err, http.StatusNotFound) {
+		return nil, err
+	}
+	// Empty response has only resource type key set by request
+	if _, typed := result[resourceTypeKey]; err != nil || len(result) == 0 || (typed && len(result) == 1) {
+		return nil, &NotFoundError{Resource: path, Query: query.ToQuery()}
+	}
+	return result, nil
+}
+
+// asyncTaskId returns id of VTask if record is a reference to asynchronous task.
+// See defaultResponseMutations for async_task normalization.
+func asyncTaskId(record Record) (int64, bool) {
+	if record[resourceTypeKey] != "VTask" {
+		// Response may be not normalized (e.g. custom AfterRequestFn is set)
+		raw, ok := record["async_task"].(map[string]any)
+		if !ok {
+			return 0, false
+		}
+		record = raw
+	}
+	id, err := toInt(record["id"])
+	return id, err == nil
+}
+
+// requestAndWaitTask sends request and, if response refers to asynchronous VTask, waits for task to complete.
+// If expectTask is true response is treated as VTask even if it is not recognized as async task reference.
+// Returns completed task and true or original response and false if response is not a task.
+// Synthetic response of dry-run mode (see VMSConfig.DryRun) is never treated as task.
+// Optional features are known features (see Feature* constants) which cluster version must support.
+func (e *VastResourceEntry) requestAndWaitTask(ctx context.Context, verb, path string, body Params, expectTask bool, opts []WaitTaskOptions, features ...string) (Record, bool, error) {
+	if err := e.rest.requireFeatures(ctx, features); err != nil {
+		return nil, false, err
+	}
+	response, err := request[Record](ctx, e, verb, path, e.apiVersion, nil, body)
+	if err != nil {
+		return nil, false, err
+	}
+	if dryRun, _ := response[DryRunKey].(bool); dryRun {
+		return response, false, nil
+	}
+	taskId, isTask := asyncTaskId(response)
+	if !isTask {
+		if !expectTask {
+			return response, false, nil
+		}
+		if taskId, err = toInt(response["id"]); err != nil {
+			return nil, false, err
+		}
+	}
+	task, err := e.rest.VTasks.WaitTask(ctx, taskId, opts...)
+	return task, true, err
 }
 
 // DeleteById deletes a resource using its unique ID.
@@ -164,20 +736,96 @@ func (e *VastResourceEntry) DeleteById(ctx context.Context, id int64) (EmptyReco
 	return request[EmptyRecord](ctx, e, http.MethodDelete, path, e.apiVersion, nil, nil)
 }
 
+// DeleteByIdWithBody deletes a resource using its unique ID and sends JSON body along with DELETE request.
+// Some VMS endpoints (e.g. tenant data deletion) require request body for DELETE.
+func (e *VastResourceEntry) DeleteByIdWithBody(ctx context.Context, id int64, body Params) (EmptyRecord, error) {
+	if err := checkVastResourceVersionCompat(ctx, e); err != nil {
+		return nil, err
+	}
+	path := fmt.Sprintf("%s/%d", e.resourcePath, id)
+	return request[EmptyRecord](ctx, e, http.MethodDelete, path, e.apiVersion, nil, body)
+}
+
+// EnsureOptions controls behavior of EnsureByParams for existing resources.
+type EnsureOptions struct {
+	UpdateOnDrift bool // Update existing resource with body fields that differ from actual values.
+	// OnDrift is called with existing resource and fields that differ from actual values (see Record.Diff)
+	// before update. Useful to log exactly what is changed.
+	OnDrift func(existing Record, changed Params)
+}
+
 // Ensure checks if a resource with the given name exists, and creates it if not.
 func (e *VastResourceEntry) Ensure(ctx context.Context, name string, body Params) (Record, error) {
-	result, err := e.Get(ctx, Params{"name": name})
+	return e.EnsureByParams(ctx, Params{"name": name}, body, EnsureOptions{})
+}
+
+// EnsureStatus tells what Ensure-style call did with resource.
+type EnsureStatus string
+
+const (
+	EnsureCreated   EnsureStatus = "created"
+	EnsureUpdated   EnsureStatus = "updated"
+	EnsureUnchanged EnsureStatus = "unchanged"
+)
+
+// EnsureByParams checks if a resource matching searchParams exists (single match semantics of Get), and creates it if not.
+// Create body is composed of body and searchParams. If UpdateOnDrift option is set existing resource is patched
+// with body fields that differ from actual values (only keys present in body are compared).
+func (e *VastResourceEntry) EnsureByParams(ctx context.Context, searchParams Params, body Params, opts EnsureOptions) (Record, error) {
+	result, _, err := e.ensureByParams(ctx, searchParams, body, opts)
+	return result, err
+}
+
+// ensureByParams implements EnsureByParams and additionally reports whether resource was created, updated or left unchanged.
+func (e *VastResourceEntry) ensureByParams(ctx context.Context, searchParams Params, body Params, opts EnsureOptions) (Record, EnsureStatus, error) {
+	ctx = withFeature(ctx, "ensure")
+	result, err := e.Get(ctx, searchParams)
 	if isNotFoundErr(err) {
-		body["name"] = name
-		return e.Create(ctx, body)
+		createBody := Params{}
+		createBody.Update(body, false)
+		createBody.Update(searchParams, false)
+		result, err = e.Create(ctx, createBody)
+		return result, EnsureCreated, err
 	} else if err != nil {
-		return nil, err
+		return nil, "", err
 	}
-	return result, nil
+	if !opts.UpdateOnDrift {
+		return result, EnsureUnchanged, nil
+	}
+	changed, equal := result.Diff(body)
+	if equal {
+		return result, EnsureUnchanged, nil
+	}
+	if opts.OnDrift != nil {
+		opts.OnDrift(result, changed)
+	}
+	ident, err := e.recordIdentifier(result, searchParams)
+	if err != nil {
+		return nil, "", err
+	}
+	result, err = e.updateByIdentifier(ctx, ident, changed)
+	return result, EnsureUpdated, err
+}
+
+// ListForTenant retrieves all resources of tenant matching the given parameters.
+// Tenant filter is injected with query param accepted by resource endpoint (see SetTenantFilterKey).
+// Returns error for resources which are not scoped to tenant (e.g. clusters, alarms).
+func (e *VastResourceEntry) ListForTenant(ctx context.Context, tenantId int64, params Params) (RecordSet, error) {
+	if tenantAgnosticResources[e.resourceType] {
+		return nil, fmt.Errorf("resource %q is not scoped to tenant", e.resourceType)
+	}
+	query := Params{e.getTenantFilterKey(): tenantId}
+	query.Update(params, true)
+	return e.List(ctx, query)
 }
 
 // Get retrieves a single resource based on the given parameters. Returns NotFoundError if no resource matches.
 func (e *VastResourceEntry) Get(ctx context.Context, params Params) (Record, error) {
+	return e.get(ctx, params, opGet)
+}
+
+// get implements Get. Operation is reported in TooManyMatchesError so its message suggests relevant next step.
+func (e *VastResourceEntry) get(ctx context.Context, params Params, operation string) (Record, error) {
 	if err := checkVastResourceVersionCompat(ctx, e); err != nil {
 		return nil, err
 	}
@@ -194,8 +842,89 @@ func (e *VastResourceEntry) Get(ctx context.Context, params Params) (Record, err
 	case 1:
 		return result[0], nil
 	default:
-		return nil, fmt.Errorf("more than one resource '%s' found for params '%v'", e.resourcePath, params.ToQuery())
+		return nil, newTooManyMatchesError(operation, e.resourcePath, params, result)
+	}
+}
+
+// GetSingleton retrieves the only object of singleton-style resource (e.g. dns, vms settings).
+// Returns NotFoundError if resource has no objects and error wrapping TooManyMatchesError if there are several.
+func (e *VastResourceEntry) GetSingleton(ctx context.Context) (Record, error) {
+	result, err := e.Get(ctx, nil)
+	var tooMany *TooManyMatchesError
+	if errors.As(err, &tooMany) {
+		return nil, fmt.Errorf("resource '%s' is expected to have single object, got %d: %w", e.resourcePath, tooMany.Count, err)
+	}
+	return result, err
+}
+
+// GetStrict retrieves a single resource based on the given parameters like Get but additionally verifies
+// matched resources on client side: records having params keys with different values are filtered out
+// (params which are not record fields, e.g. filter suffixes like "name__contains", are not verified).
+// This protects from filters silently ignored by VMS. Returns NotFoundError if no resource matches
+// and TooManyMatchesError if more than one resource matches.
+func (e *VastResourceEntry) GetStrict(ctx context.Context, params Params) (Record, error) {
+	if err := checkVastResourceVersionCompat(ctx, e); err != nil {
+		return nil, err
+	}
+	result, err := request[RecordSet](ctx, e, http.MethodGet, e.resourcePath, e.apiVersion, params, nil)
+	if err != nil {
+		return nil, err
+	}
+	var matched RecordSet
+	for _, record := range result {
+		if matchesParams(record, params) {
+			matched = append(matched, record)
+		}
+	}
+	switch len(matched) {
+	case 0:
+		return nil, &NotFoundError{
+			Resource: e.resourcePath,
+			Query:    params.ToQuery(),
+		}
+	case 1:
+		return matched[0], nil
+	default:
+		return nil, newTooManyMatchesError(opGet, e.resourcePath, params, matched)
+	}
+}
+
+// Exists reports whether at least one resource matches the given parameters.
+// Only first page of size 1 is requested.
+func (e *VastResourceEntry) Exists(ctx context.Context, params Params) (bool, error) {
+	if err := checkVastResourceVersionCompat(ctx, e); err != nil {
+		return false, err
+	}
+	query := Params{}
+	query.Update(params, false)
+	query["page_size"] = 1
+	result, err := request[RecordSet](ctx, e, http.MethodGet, e.resourcePath, e.apiVersion, query, nil)
+	if err != nil {
+		return false, err
+	}
+	return len(result) > 0, nil
+}
+
+// Count returns number of resources matching the given parameters.
+// Envelope "count" of paginated response is used when present. Otherwise response is not paginated
+// and contains all matched resources, so only their ids are requested (via "fields" query parameter) and counted.
+func (e *VastResourceEntry) Count(ctx context.Context, params Params) (int64, error) {
+	if err := checkVastResourceVersionCompat(ctx, e); err != nil {
+		return 0, err
+	}
+	query := Params{}
+	query.Update(params, false)
+	query["page_size"] = 1
+	query["fields"] = "id"
+	var page pageInfo
+	result, err := request[RecordSet](withPageCapture(ctx, &page), e, http.MethodGet, e.resourcePath, e.apiVersion, query, nil)
+	if err != nil {
+		return 0, err
+	}
+	if page.Count != nil {
+		return *page.Count, nil
 	}
+	return int64(len(result)), nil
 }
 
 // GetById retrieves a resource by its unique ID.
@@ -0,0 +1,327 @@
+package vast_client
+
+import (
+	"context"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDeleteMatches(t *testing.T) {
+	views := []map[string]any{
+		{"id": 1, "name": "a", "path": "/a"},
+		{"id": 2, "name": "b", "path": "/a"},
+		{"id": 3, "name": "c", "path": "/a"},
+	}
+	tests := []struct {
+		name        string
+		records     []map[string]any
+		expected    int // Passed with WithExpectedMatches if not zero
+		wantDeleted []string
+		wantErr     bool
+	}{
+		{name: "no matches", records: nil},
+		{name: "single match", records: views[:1], wantDeleted: []string{"DELETE views/1"}},
+		{name: "several matches", records: views, wantErr: true},
+		{name: "expected matches", records: views, expected: 3, wantDeleted: []string{"DELETE views/1", "DELETE views/2", "DELETE views/3"}},
+		{name: "expected matches mismatch", records: views[:2], expected: 3, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			srv := newTestServer(t, recordsHandler(tt.records...))
+			rest := newTestRest(t, srv.config())
+			ctx := context.Background()
+			if tt.expected > 0 {
+				ctx = WithExpectedMatches(ctx, tt.expected)
+			}
+
+			_, err := rest.Views.Delete(ctx, Params{"path": "/a"})
+
+			var deleted []string
+			for _, r := range methodsAndPaths(srv.Requests()) {
+				if strings.HasPrefix(r, http.MethodDelete) {
+					deleted = append(deleted, r)
+				}
+			}
+			assert.Equal(t, tt.wantDeleted, deleted)
+			if !tt.wantErr {
+				require.NoError(t, err)
+				return
+			}
+			var ambiguous *AmbiguousMatchError
+			require.ErrorAs(t, err, &ambiguous)
+			assert.Equal(t, opDelete, ambiguous.Operation)
+			assert.Equal(t, len(tt.records), ambiguous.Count)
+			assert.Contains(t, err.Error(), "WithExpectedMatches")
+			assert.Contains(t, err.Error(), "id=1 name=a")
+		})
+	}
+}
+
+func TestDeleteNegativeExpectedMatches(t *testing.T) {
+	srv := newTestServer(t, recordsHandler(map[string]any{"id": 1, "path": "/a"}))
+	rest := newTestRest(t, srv.config())
+
+	_, err := rest.Views.Delete(WithExpectedMatches(context.Background(), -1), Params{"path": "/a"})
+
+	assert.EqualError(t, err, "expected matches must not be negative, got -1")
+	assert.Empty(t, srv.Requests())
+}
+
+func TestExpectedMatchesApplyToOutermostDelete(t *testing.T) {
+	srv := newTestServer(t, func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case r.Method == http.MethodGet && resourceFromPath(r.URL.Path) == "views":
+			writeTestJSON(w, http.StatusOK, []map[string]any{{"id": 1, "path": "/a"}, {"id": 2, "path": "/a"}})
+		case r.Method == http.MethodGet:
+			writeTestJSON(w, http.StatusOK, []map[string]any{{"id": 7, "path": "/a"}})
+		default:
+			w.WriteHeader(http.StatusNoContent)
+		}
+	})
+	rest := newTestRest(t, srv.config())
+	// Quota of view is deleted from interceptor with context of view deletion request
+	views := rest.Views.WithInterceptors(Interceptor{Name: "cleanup", Before: func(ctx context.Context, info *RequestInfo) error {
+		if info.Verb != http.MethodDelete {
+			return nil
+		}
+		_, err := rest.Quotas.Delete(ctx, Params{"path": "/a"})
+		return err
+	}})
+
+	_, err := views.Delete(WithExpectedMatches(context.Background(), 2), Params{"path": "/a"})
+
+	require.NoError(t, err)
+	assert.Equal(t, []string{
+		"GET views",
+		"GET quotas", "DELETE quotas/7", "DELETE views/1",
+		"GET quotas", "DELETE quotas/7", "DELETE views/2",
+	}, methodsAndPaths(srv.Requests()))
+}
+
+func TestDeleteRecordIdentifier(t *testing.T) {
+	tests := []struct {
+		name        string
+		idField     string
+		record      map[string]any
+		wantDeleted []string
+		wantErr     []string
+	}{
+		{name: "id field", record: map[string]any{"id": 7, "name": "a"}, wantDeleted: []string{"DELETE views/7"}},
+		{
+			name:        "alternate id field",
+			idField:     "guid",
+			record:      map[string]any{"guid": "ab-cd", "name": "a"},
+			wantDeleted: []string{"DELETE views/ab-cd"},
+		},
+		{
+			name:    "missing id field",
+			record:  map[string]any{"name": "a", "path": "/a"},
+			wantErr: []string{"resource 'views' (View)", "params 'name=a'", `"id" field`, "name, path]"},
+		},
+		{
+			name:    "missing alternate id field",
+			idField: "guid",
+			record:  map[string]any{"id": 7, "name": "a"},
+			wantErr: []string{`"guid" field`, "id, name]"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			srv := newTestServer(t, recordsHandler(tt.record))
+			rest := newTestRest(t, srv.config())
+			if tt.idField != "" {
+				rest.Views.SetIdField(tt.idField)
+			}
+
+			_, err := rest.Views.Delete(context.Background(), Params{"name": "a"})
+
+			var deleted []string
+			for _, r := range methodsAndPaths(srv.Requests()) {
+				if strings.HasPrefix(r, http.MethodDelete) {
+					deleted = append(deleted, r)
+				}
+			}
+			assert.Equal(t, tt.wantDeleted, deleted)
+			if tt.wantErr == nil {
+				require.NoError(t, err)
+				return
+			}
+			require.Error(t, err)
+			assert.NotContains(t, err.Error(), "MISSING")
+			for _, want := range tt.wantErr {
+				assert.Contains(t, err.Error(), want)
+			}
+		})
+	}
+}
+
+func TestAmbiguousMatchErrorHint(t *testing.T) {
+	matches := RecordSet{{"id": 1, "name": "a"}, {"id": 2, "name": "b"}, {"id": 3, "name": "c"}, {"id": 4, "name": "d"}}
+	tests := []struct {
+		operation  string
+		wantDelete bool
+	}{
+		{operation: opGet},
+		{operation: opUpdate},
+		{operation: opDelete, wantDelete: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.operation, func(t *testing.T) {
+			err := newAmbiguousMatchError(tt.operation, "views", Params{"path": "/a"}, 1, matches)
+			msg := err.Error()
+			assert.Contains(t, msg, "found 4 [id=1 name=a, id=2 name=b, id=3 name=c, ...]")
+			assert.Equal(t, tt.wantDelete, containsAll(msg, "DeleteAll", "WithExpectedMatches(ctx, 4)"))
+		})
+	}
+}
+
+func TestGetOperationInTooManyMatchesError(t *testing.T) {
+	srv := newTestServer(t, recordsHandler(map[string]any{"id": 1, "name": "a"}, map[string]any{"id": 2, "name": "a"}))
+	rest := newTestRest(t, srv.config())
+	ctx := context.Background()
+
+	_, err := rest.Views.Get(ctx, Params{"name": "a"})
+	var tooMany *TooManyMatchesError
+	require.ErrorAs(t, err, &tooMany)
+	assert.Equal(t, opGet, tooMany.Operation)
+	assert.NotContains(t, err.Error(), "DeleteAll")
+
+	_, err = rest.Views.UpdateByParams(ctx, Params{"name": "a"}, Params{"path": "/b"})
+	require.ErrorAs(t, err, &tooMany)
+	assert.Equal(t, opUpdate, tooMany.Operation)
+	assert.NotContains(t, err.Error(), "DeleteAll")
+	for _, r := range srv.Requests() {
+		assert.Equal(t, http.MethodGet, r.Method)
+	}
+}
+
+func TestGetStrict(t *testing.T) {
+	tests := []struct {
+		name         string
+		records      []map[string]any
+		wantId       any
+		wantNotFound bool
+		wantConflict int
+	}{
+		{name: "single match", records: []map[string]any{{"id": 1, "name": "a"}}, wantId: float64(1)},
+		{
+			name:    "filter ignored by server",
+			records: []map[string]any{{"id": 1, "name": "a"}, {"id": 2, "name": "b"}},
+			wantId:  float64(1),
+		},
+		{name: "no match after client side filtering", records: []map[string]any{{"id": 2, "name": "b"}}, wantNotFound: true},
+		{
+			name: "too many matches",
+			records: []map[string]any{
+				{"id": 1, "name": "a", "tenant_id": 1},
+				{"id": 2, "name": "a", "tenant_id": 2},
+				{"id": 3, "name": "a", "tenant_id": 3},
+				{"id": 4, "name": "a", "tenant_id": 4},
+			},
+			wantConflict: 4,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			srv := newTestServer(t, recordsHandler(tt.records...))
+			rest := newTestRest(t, srv.config())
+
+			record, err := rest.Views.GetStrict(context.Background(), Params{"name": "a", "path__contains": "/"})
+
+			switch {
+			case tt.wantNotFound:
+				var notFound *NotFoundError
+				require.ErrorAs(t, err, &notFound)
+			case tt.wantConflict > 0:
+				var tooMany *TooManyMatchesError
+				require.ErrorAs(t, err, &tooMany)
+				var ambiguous *AmbiguousMatchError
+				require.ErrorAs(t, err, &ambiguous, "TooManyMatchesError wraps AmbiguousMatchError")
+				assert.Equal(t, tt.wantConflict, tooMany.Count)
+				assert.Equal(t, RecordSet{
+					{"id": float64(1), "name": "a", "tenant_id": float64(1)},
+					{"id": float64(2), "name": "a", "tenant_id": float64(2)},
+					{"id": float64(3), "name": "a", "tenant_id": float64(3)},
+				}, tooMany.Conflicts)
+			default:
+				require.NoError(t, err)
+				assert.Equal(t, tt.wantId, record["id"])
+			}
+		})
+	}
+}
+
+func TestTooManyMatchesErrorRender(t *testing.T) {
+	err := newTooManyMatchesError(opGet, "views", Params{"name": "a"}, RecordSet{
+		{"id": 1, "name": "a", "tenant_id": 1, "path": "/a"},
+		{"id": 2, "name": "a", "tenant_id": 2, "path": "/b"},
+	})
+	rendered := err.Render()
+	lines := strings.Split(rendered, "\n")
+	assert.Equal(t, err.Error(), lines[0])
+	assert.True(t, containsAll(rendered, "id", "name", "tenant_id"))
+	assert.NotContains(t, rendered, "/b", "only identifying fields are rendered")
+	var rows int
+	for _, line := range lines[1:] {
+		if strings.HasPrefix(line, "| 1 ") || strings.HasPrefix(line, "| 2 ") {
+			rows++
+		}
+	}
+	assert.Equal(t, 2, rows)
+
+	empty := &TooManyMatchesError{AmbiguousMatchError: err.AmbiguousMatchError}
+	assert.Equal(t, err.Error(), empty.Render())
+}
+
+func TestReplaceAndUpdate(t *testing.T) {
+	tests := []struct {
+		name       string
+		call       func(ctx context.Context, rest *VMSRest, body Params) (Record, error)
+		wantMethod string
+	}{
+		{
+			name: "replace",
+			call: func(ctx context.Context, rest *VMSRest, body Params) (Record, error) {
+				return rest.ProtectionPolicies.Replace(ctx, 3, body)
+			},
+			wantMethod: http.MethodPut,
+		},
+		{
+			name: "update",
+			call: func(ctx context.Context, rest *VMSRest, body Params) (Record, error) {
+				return rest.ProtectionPolicies.Update(ctx, 3, body)
+			},
+			wantMethod: http.MethodPatch,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			srv := newTestServer(t, recordsHandler())
+			rest := newTestRest(t, srv.config())
+			body := Params{"name": "p", "frames": []any{"every 1h keep-local 1d"}}
+
+			_, err := tt.call(context.Background(), rest, body)
+
+			require.NoError(t, err)
+			requests := srv.Requests()
+			require.Len(t, requests, 1)
+			assert.Equal(t, tt.wantMethod, requests[0].Method)
+			assert.Equal(t, "protectionpolicies/3", requests[0].Path)
+			assert.JSONEq(t, `{"name": "p", "frames": ["every 1h keep-local 1d"]}`, string(requests[0].Body))
+		})
+	}
+}
+
+// containsAll reports whether s contains all substrings.
+func containsAll(s string, substrings ...string) bool {
+	for _, sub := range substrings {
+		if !strings.Contains(s, sub) {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,170 @@
+package vast_client
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"slices"
+)
+
+// ApiVersionAuto makes client negotiate API version with cluster on first request (see VMSConfig.ApiVersion).
+// Supported versions are requested from "api/supportedversions" endpoint. If cluster doesn't provide it (404)
+// versions known to client are probed from newest to oldest. Negotiated version is cached on session.
+const ApiVersionAuto = "auto"
+
+// defaultApiVersion is used when ApiVersion is not set and when session can't negotiate version.
+const defaultApiVersion = "v5"
+
+// knownApiVersions are API versions supported by client in order of preference.
+var knownApiVersions = []string{"v5", "v4", "v3", "v2", "v1"}
+
+// apiVersionNegotiator is implemented by sessions which can negotiate and cache API version (see ApiVersionAuto).
+type apiVersionNegotiator interface {
+	negotiateApiVersion(ctx context.Context) (string, error)
+	cachedApiVersion() string
+}
+
+// ApiVersion returns API version used to build resource URLs. If VMSConfig.ApiVersion is ApiVersionAuto
+// version is negotiated with cluster (once per session). Resources with own API version (see SetApiVersion) are not affected.
+func (rest *VMSRest) ApiVersion(ctx context.Context) (string, error) {
+	return sessionApiVersion(ctx, rest.Session)
+}
+
+// SetApiVersion overrides API version used in URLs of the resource (e.g. "v4" or "latest").
+// Override takes precedence over VMSConfig.ApiVersion including negotiated version.
+func (e *VastResourceEntry) SetApiVersion(apiVersion string) {
+	e.apiVersion = apiVersion
+}
+
+// sessionApiVersion returns configured API version of session, negotiating it if necessary.
+func sessionApiVersion(ctx context.Context, s RESTSession) (string, error) {
+	configured := s.GetConfig().ApiVersion
+	if configured != ApiVersionAuto {
+		return configured, nil
+	}
+	if negotiator, ok := s.(apiVersionNegotiator); ok {
+		return negotiator.negotiateApiVersion(ctx)
+	}
+	return defaultApiVersion, nil
+}
+
+// configuredApiVersion returns API version of session without negotiation.
+// If version is not negotiated yet defaultApiVersion is returned.
+func configuredApiVersion(s RESTSession) string {
+	configured := s.GetConfig().ApiVersion
+	if configured != ApiVersionAuto {
+		return configured
+	}
+	if negotiator, ok := s.(apiVersionNegotiator); ok {
+		if negotiated := negotiator.cachedApiVersion(); negotiated != "" {
+			return negotiated
+		}
+	}
+	return defaultApiVersion
+}
+
+func (s *VMSSession) cachedApiVersion() string {
+	s.apiVerMu.Lock()
+	defer s.apiVerMu.Unlock()
+	return s.apiVersion
+}
+
+// negotiateApiVersion picks best API version supported by both client and cluster.
+// Result is cached; failed negotiation is retried on next request.
+func (s *VMSSession) negotiateApiVersion(ctx context.Context) (string, error) {
+	s.apiVerMu.Lock()
+	defer s.apiVerMu.Unlock()
+	if s.apiVersion != "" {
+		return s.apiVersion, nil
+	}
+	supported, err := s.supportedApiVersions(ctx)
+	if err != nil {
+		return "", fmt.Errorf("failed to negotiate API version: %w", err)
+	}
+	chosen := ""
+	for _, candidate := range knownApiVersions {
+		if slices.Contains(supported, candidate) {
+			chosen = candidate
+			break
+		}
+	}
+	if chosen == "" && slices.Contains(supported, "latest") {
+		chosen = "latest"
+	}
+	if chosen == "" {
+		// Endpoint is missing or lists no version known to client.
+		if chosen, err = s.probeApiVersion(ctx); err != nil {
+			return "", fmt.Errorf("failed to negotiate API version: %w", err)
+		}
+	}
+	s.apiVersion = chosen
+	if s.config.Logger != nil {
+		s.config.Logger.LogAttrs(ctx, slog.LevelDebug, "vast api version negotiated",
+			slog.String("api_version", chosen), slog.Any("supported", supported))
+	}
+	return chosen, nil
+}
+
+// supportedApiVersions returns versions listed by "api/supportedversions" endpoint.
+// Nil is returned if cluster doesn't provide the endpoint.
+func (s *VMSSession) supportedApiVersions(ctx context.Context) ([]string, error) {
+	_url, err := endpointUrl(s.config, "api", "supportedversions")
+	if err != nil {
+		return nil, err
+	}
+	response, err := s.Get(ctx, _url.String(), nil)
+	if err != nil {
+		if isApiErrWithStatus(err, http.StatusNotFound) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	defer response.Body.Close()
+	raw, err := io.ReadAll(response.Body)
+	if err != nil {
+		return nil, err
+	}
+	var items []any
+	if err = json.Unmarshal(raw, &items); err != nil {
+		return nil, nil
+	}
+	var supported []string
+	for _, item := range items {
+		switch v := item.(type) {
+		case string:
+			supported = append(supported, v)
+		case map[string]any:
+			for _, key := range []string{"version", "name"} {
+				if name, ok := v[key].(string); ok {
+					supported = append(supported, name)
+					break
+				}
+			}
+		}
+	}
+	return supported, nil
+}
+
+// probeApiVersion returns newest known version for which cluster serves "versions" endpoint.
+func (s *VMSSession) probeApiVersion(ctx context.Context) (string, error) {
+	for _, candidate := range knownApiVersions {
+		_url, err := endpointUrl(s.config, "api", candidate, "versions")
+		if err != nil {
+			return "", err
+		}
+		_url.RawQuery = "page_size=1"
+		response, err := s.Get(ctx, _url.String(), nil)
+		if err != nil {
+			if isApiErrWithStatus(err, http.StatusNotFound) {
+				continue
+			}
+			return "", err
+		}
+		response.Body.Close()
+		return candidate, nil
+	}
+	return "", fmt.Errorf("cluster serves none of API versions %v", knownApiVersions)
+}
@@ -0,0 +1,129 @@
+package vast_client
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"os"
+	"sync"
+	"time"
+)
+
+// AuditEntry describes mutating request (POST, PUT, PATCH, DELETE) sent to VMS (see VMSConfig.Audit).
+type AuditEntry struct {
+	Timestamp    time.Time       `json:"timestamp"`
+	ResourceType string          `json:"resource_type"`
+	Verb         string          `json:"verb"`
+	URL          string          `json:"url"`
+	RequestID    string          `json:"request_id,omitempty"`
+	Body         json.RawMessage `json:"body,omitempty"` // Request body with sensitive fields redacted
+	StatusCode   int             `json:"status_code"`    // 0 if no response was received
+	ObjectId     int64           `json:"object_id,omitempty"`
+	Error        string          `json:"error,omitempty"`
+}
+
+// AuditSink receives entries of all mutating requests made by client (see VMSConfig.Audit).
+// Implementation must be safe for concurrent use.
+type AuditSink interface {
+	RecordOperation(ctx context.Context, op AuditEntry) error
+}
+
+// JSONLinesAuditSink is AuditSink which writes every entry as single JSON line.
+type JSONLinesAuditSink struct {
+	mu     sync.Mutex
+	w      io.Writer
+	closer io.Closer
+}
+
+// NewJSONLinesAuditSink creates AuditSink appending entries to file (file is created if not exists).
+// Caller must Close sink when it is not needed anymore.
+func NewJSONLinesAuditSink(path string) (*JSONLinesAuditSink, error) {
+	file, err := os.OpenFile(path, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0o600)
+	if err != nil {
+		return nil, err
+	}
+	return &JSONLinesAuditSink{w: file, closer: file}, nil
+}
+
+// NewJSONLinesAuditWriter creates AuditSink writing entries to w.
+func NewJSONLinesAuditWriter(w io.Writer) *JSONLinesAuditSink {
+	return &JSONLinesAuditSink{w: w}
+}
+
+func (s *JSONLinesAuditSink) RecordOperation(_ context.Context, op AuditEntry) error {
+	line, err := json.Marshal(op)
+	if err != nil {
+		return err
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	_, err = s.w.Write(append(line, '\n'))
+	return err
+}
+
+// Close closes underlying file (if sink was created with NewJSONLinesAuditSink).
+func (s *JSONLinesAuditSink) Close() error {
+	if s.closer == nil {
+		return nil
+	}
+	return s.closer.Close()
+}
+
+// AuditError is returned when audit entry cannot be recorded and VMSConfig.AuditStrict is set.
+// NOTE: the request itself was already executed by VMS.
+type AuditError struct {
+	Entry AuditEntry
+	Err   error
+}
+
+func (e *AuditError) Error() string {
+	return fmt.Sprintf("failed to record audit entry of %s %s: %v", e.Entry.Verb, e.Entry.URL, e.Err)
+}
+
+func (e *AuditError) Unwrap() error {
+	return e.Err
+}
+
+// recordAudit sends entry of mutating request to VMSConfig.Audit.
+// Failure to record entry is logged and returned as AuditError only if VMSConfig.AuditStrict is set.
+func recordAudit(ctx context.Context, config *VMSConfig, resourceType string, info ResponseInfo, body []byte, result any, requestErr error) error {
+	if config.Audit == nil || info.Verb == http.MethodGet {
+		return nil
+	}
+	entry := AuditEntry{
+		Timestamp:    time.Now().UTC(),
+		ResourceType: resourceType,
+		Verb:         info.Verb,
+		URL:          info.URL,
+		RequestID:    info.RequestID,
+		StatusCode:   info.StatusCode,
+	}
+	if len(body) > 0 {
+		if redacted := redactJSON(body); json.Valid(redacted) {
+			entry.Body = redacted
+		} else {
+			entry.Body, _ = json.Marshal(string(redacted))
+		}
+	}
+	if record, ok := result.(Record); ok {
+		entry.ObjectId, _ = toInt(record["id"])
+	}
+	if requestErr != nil {
+		entry.Error = requestErr.Error()
+		var apiErr *ApiError
+		if entry.StatusCode == 0 && errors.As(requestErr, &apiErr) {
+			entry.StatusCode = apiErr.StatusCode
+		}
+	}
+	if err := config.Audit.RecordOperation(ctx, entry); err != nil {
+		logWarn(config, "failed to record audit entry", slog.String("verb", entry.Verb), slog.String("url", entry.URL), slog.String("error", err.Error()))
+		if config.AuditStrict {
+			return &AuditError{Entry: entry, Err: err}
+		}
+	}
+	return nil
+}
@@ -2,24 +2,49 @@ package vast_client
 
 import (
 	"bytes"
-	"crypto/tls"
+	"context"
 	"encoding/json"
+	"errors"
+	"fmt"
 	"io"
+	"log/slog"
 	"net/http"
-	"net/url"
-	"strconv"
+	"sync"
 	"time"
 )
 
 // TokenRefreshTime Time duration is set to 10 min after this we refresh the token
 const TokenRefreshTime = time.Duration(time.Minute * 10)
 
+// Bounds of backoff between failed background token refresh attempts
+const (
+	minRefreshBackoff = time.Second
+	maxRefreshBackoff = time.Minute
+)
+
 type Authenticator interface {
 	Authorize(s *VMSSession) error
 	SetAuthHeader(s *VMSSession, headers *http.Header) error
 }
 
 func CreateAuthenticator(config *VMSConfig) Authenticator {
+	if config.OIDC != nil {
+		return &OIDCAuthenticator{Config: config.OIDC}
+	}
+	// Pre-seeded tokens (see VMSConfig.InitialAccessToken) are used until refresh fails
+	if config.InitialAccessToken != "" && config.InitialRefreshToken != "" {
+		return &JWTAuthenticator{
+			Username:    config.Username,
+			Password:    config.Password,
+			Token:       seededToken(config),
+			Credentials: config.Credentials,
+			initialized: true,
+		}
+	}
+	// Authentication method is chosen lazily on first request (see credentialsAuthenticator)
+	if config.Credentials != nil {
+		return &credentialsAuthenticator{Credentials: config.Credentials}
+	}
 	// Check if username and password are provided
 	if config.Username != "" && config.Password != "" {
 		// Return a new JWTAuthenticator
@@ -48,8 +73,16 @@ type jwtToken struct {
 type JWTAuthenticator struct {
 	Username    string
 	Password    string
+	Credentials CredentialsProvider // If set, credentials are taken from provider on every token acquisition
 	Token       *jwtToken
 	initialized bool
+
+	tokenUsername string // Username of current token (resolved from Credentials if set), used as TokenStore key
+	clock         clock  // Source of time for token expiry and background refresh (realClock if nil)
+
+	refresherOnce sync.Once
+	stopOnce      sync.Once
+	stopRefresh   chan struct{}
 }
 
 func parseToken(rsp *http.Response) (*jwtToken, error) {
@@ -62,18 +95,34 @@ func parseToken(rsp *http.Response) (*jwtToken, error) {
 	if e != nil {
 		return nil, e
 	}
-	tokens.CreatedAt = time.Now()
 	return &tokens, nil
 }
 
-func (auth *JWTAuthenticator) refreshToken(client *http.Client, config VMSConfig) (*http.Response, error) {
+// clock abstracts time so token expiry and background refresh scheduling can be tested with fake clock.
+type clock interface {
+	Now() time.Time
+	After(d time.Duration) <-chan time.Time
+}
+
+type realClock struct{}
+
+func (realClock) Now() time.Time                         { return time.Now() }
+func (realClock) After(d time.Duration) <-chan time.Time { return time.After(d) }
+
+func (auth *JWTAuthenticator) getClock() clock {
+	if auth.clock == nil {
+		return realClock{}
+	}
+	return auth.clock
+}
+
+func (auth *JWTAuthenticator) refreshToken(client *http.Client, config VMSConfig, refresh string) (*http.Response, error) {
 	var resp *http.Response
-	path := url.URL{
-		Scheme: "https",
-		Host:   config.Host,
-		Path:   "api/token/refresh/",
+	path, err := endpointUrl(&config, "api/token/refresh/")
+	if err != nil {
+		return nil, err
 	}
-	body, err := json.Marshal(map[string]string{"refresh": auth.Token.Refresh})
+	body, err := json.Marshal(map[string]string{"refresh": refresh})
 	if err != nil {
 		return nil, err
 	}
@@ -84,89 +133,385 @@ func (auth *JWTAuthenticator) refreshToken(client *http.Client, config VMSConfig
 	return resp, nil
 }
 
-func (auth *JWTAuthenticator) acquireToken(client *http.Client, config VMSConfig) (*http.Response, error) {
-	// obtain new access & refresh tokens
-	var resp *http.Response
-	userPass := map[string]string{"username": config.Username, "password": config.Password}
-	server := config.Host + ":" + strconv.FormatUint(config.Port, 10)
+// tokenRequestTimeout limits duration of token requests.
+const tokenRequestTimeout = 10 * time.Second
+
+// revokeToken blacklists refresh token so it cannot be used anymore.
+// Clusters which don't support token blacklisting (404 response) are silently ignored.
+func (auth *JWTAuthenticator) revokeToken(s *VMSSession) error {
+	s.Lock()
+	defer s.Unlock()
+	if !auth.initialized {
+		return nil
+	}
+	config := s.GetConfig()
+	path, err := endpointUrl(config, "api/token/blacklist/")
+	if err != nil {
+		return err
+	}
+	body, err := json.Marshal(map[string]string{"refresh": auth.Token.Refresh})
+	if err != nil {
+		return err
+	}
+	resp, err := s.tokenClient().Post(path.String(), ApplicationJson, bytes.NewBuffer(body))
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if _, err = validateResponse(resp); err != nil && !isApiErrWithStatus(err, http.StatusNotFound) {
+		return err
+	}
+	auth.initialized = false
+	auth.Token = nil
+	return nil
+}
+
+// acquireToken obtains new access & refresh tokens with username/password. Returns username tokens are issued for.
+func (auth *JWTAuthenticator) acquireToken(client *http.Client, config VMSConfig) (*http.Response, string, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), tokenRequestTimeout)
+	defer cancel()
+	username, password, _, err := credentials(ctx, &config)
+	if err != nil {
+		return nil, "", err
+	}
+	if username == "" || password == "" {
+		return nil, "", errors.New("username/password are required to acquire JWT token")
+	}
+	userPass := map[string]string{"username": username, "password": password}
 	body, err := json.Marshal(userPass)
 	if err != nil {
-		return nil, err
+		return nil, "", err
 	}
 	// Generate URL to obtain token keys
-	path := url.URL{
-		Scheme: "https",
-		Host:   server,
-		Path:   "api/token/",
+	path, err := endpointUrl(&config, "api/token/")
+	if err != nil {
+		return nil, "", err
 	}
-	resp, err = client.Post(path.String(), "application/json", bytes.NewBuffer(body))
+	resp, err := client.Post(path.String(), "application/json", bytes.NewBuffer(body))
 	if err != nil {
-		return nil, err
+		return nil, "", err
 	}
-	return resp, nil
+	return resp, username, nil
 }
 
 func (auth *JWTAuthenticator) Authorize(s *VMSSession) error {
+	_, err := auth.accessToken(s)
+	return err
+}
+
+// accessToken makes sure token is valid (renewing it if needed) and returns access token.
+// Token is read while session is locked because background refresher (see refreshLoop)
+// and revokeToken replace it concurrently.
+func (auth *JWTAuthenticator) accessToken(s *VMSSession) (string, error) {
 	s.Lock()
 	defer s.Unlock()
-	var (
-		resp *http.Response
-		err  error
-	)
+	if auth.initialized {
+		tokenExpired := auth.getClock().Now().Sub(auth.Token.CreatedAt) >= TokenRefreshTime
+		if !tokenExpired {
+			return auth.Token.Access, nil
+		}
+	}
+	refreshing := auth.initialized
+	if err := auth.observedRenewToken(s); err != nil {
+		logWarn(s.GetConfig(), "vast token renewal failed", slog.String("error", err.Error()))
+		return "", err
+	}
+	if refreshing {
+		// Request had to wait for token refresh
+		logWarn(s.GetConfig(), "vast token refreshed on request path")
+	}
+	if s.GetConfig().TokenPreRefresh {
+		auth.startRefresher(s)
+	}
+	return auth.Token.Access, nil
+}
+
+// observedRenewToken renews token and reports attempt to MetricsRecorder.
+// NOTE: session must be locked by caller.
+func (auth *JWTAuthenticator) observedRenewToken(s *VMSSession) error {
+	started := time.Now()
+	err := auth.renewToken(s)
+	metricsRecorder(s.GetConfig()).ObserveAuthRefresh(err == nil, time.Since(started))
+	return err
+}
+
+// renewToken refreshes existing token or acquires new pair of tokens if there is no token yet.
+// Token loaded from VMSConfig.TokenStore is refreshed first so stale or revoked stored token is never used.
+// NOTE: session must be locked by caller.
+func (auth *JWTAuthenticator) renewToken(s *VMSSession) error {
 	config := s.GetConfig()
-	tr := &http.Transport{
-		TLSClientConfig: &tls.Config{InsecureSkipVerify: !config.SslVerify},
+	var refresh string
+	if auth.initialized || auth.loadStoredToken(config) {
+		refresh = auth.Token.Refresh
 	}
-	client := &http.Client{
-		Transport: tr,
-		Timeout:   10 * time.Second,
+	token, username, err := auth.fetchToken(s, refresh)
+	if err != nil {
+		return err
 	}
+	auth.setToken(token, username)
+	auth.saveStoredToken(config)
+	return nil
+}
 
-	if auth.initialized {
-		tokenExpired := time.Now().Sub(auth.Token.CreatedAt) >= TokenRefreshTime
-		if !tokenExpired {
-			return nil
+// backgroundRenewToken renews token like renewToken but session is locked only to read and replace token,
+// so requests are never blocked by token request. Renewed token is dropped if token was replaced concurrently
+// (e.g. by lazy refresh on request path or by revokeToken).
+func (auth *JWTAuthenticator) backgroundRenewToken(s *VMSSession) error {
+	config := s.GetConfig()
+	s.Lock()
+	current := auth.Token
+	s.Unlock()
+	if current == nil {
+		return nil
+	}
+	started := time.Now()
+	token, username, err := auth.fetchToken(s, current.Refresh)
+	metricsRecorder(config).ObserveAuthRefresh(err == nil, time.Since(started))
+	if err != nil {
+		return err
+	}
+	s.Lock()
+	defer s.Unlock()
+	if auth.Token != current {
+		return nil
+	}
+	auth.setToken(token, username)
+	auth.saveStoredToken(config)
+	return nil
+}
+
+// fetchToken refreshes token with refresh token (if not empty). If refresh fails and username/password are known
+// new pair of tokens is acquired. Returns new token and username it is issued for (empty if token is refreshed).
+// Authenticator is not modified so session lock is not required.
+func (auth *JWTAuthenticator) fetchToken(s *VMSSession, refresh string) (*jwtToken, string, error) {
+	config := s.GetConfig()
+	client := s.tokenClient()
+	if refresh != "" {
+		token, err := auth.readToken(auth.refreshToken(client, *config, refresh))
+		if err == nil {
+			return token, "", nil
 		}
-		resp, err = auth.refreshToken(client, *config)
-	} else {
-		resp, err = auth.acquireToken(client, *config)
-		auth.initialized = true
+		if !auth.canAcquire() {
+			return nil, "", err
+		}
+		// Refresh token is expired or revoked (e.g. pre-seeded or stored token): fall back to username/password
+		logWarn(config, "vast token refresh failed, acquiring new token", slog.String("error", err.Error()))
+	}
+	resp, username, err := auth.acquireToken(client, *config)
+	token, err := auth.readToken(resp, err)
+	if err != nil {
+		return nil, "", err
 	}
+	return token, username, nil
+}
+
+// canAcquire reports whether new pair of tokens can be acquired (username/password or credentials provider is set).
+func (auth *JWTAuthenticator) canAcquire() bool {
+	return auth.Credentials != nil || (auth.Username != "" && auth.Password != "")
+}
+
+// readToken reads pair of tokens from response of token request.
+func (auth *JWTAuthenticator) readToken(resp *http.Response, err error) (*jwtToken, error) {
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
 	if _, err = validateResponse(resp); err != nil {
-		return err
+		return nil, err
 	}
-	// Read response
 	token, err := parseToken(resp)
 	if err != nil {
-		return err
+		return nil, err
 	}
+	token.CreatedAt = auth.getClock().Now()
+	return token, nil
+}
+
+// setToken replaces current token. Username is kept if token is refreshed (username is empty).
+// NOTE: session must be locked by caller.
+func (auth *JWTAuthenticator) setToken(token *jwtToken, username string) {
 	auth.Token = token
-	return nil
+	auth.initialized = true
+	if username != "" {
+		auth.tokenUsername = username
+	}
+}
+
+// startRefresher starts background goroutine which refreshes token TokenRefreshMargin before it expires
+// so requests never pay for token refresh round-trip. Refresher is started only once and stopped by VMSSession.Close.
+func (auth *JWTAuthenticator) startRefresher(s *VMSSession) {
+	auth.refresherOnce.Do(func() {
+		auth.stopRefresh = make(chan struct{})
+		go auth.refreshLoop(s)
+	})
+}
+
+// stopRefresher stops background token refresher (if started).
+func (auth *JWTAuthenticator) stopRefresher() {
+	auth.refresherOnce.Do(func() {}) // Refresher cannot be started after stop
+	if auth.stopRefresh != nil {
+		auth.stopOnce.Do(func() { close(auth.stopRefresh) })
+	}
+}
+
+func (auth *JWTAuthenticator) refreshLoop(s *VMSSession) {
+	var (
+		failed  bool
+		backoff = minRefreshBackoff
+		margin  = s.GetConfig().TokenRefreshMargin
+	)
+	for {
+		wait := backoff
+		if !failed {
+			s.Lock()
+			if auth.Token == nil {
+				// Token is revoked (see revokeToken)
+				s.Unlock()
+				return
+			}
+			wait = auth.Token.CreatedAt.Add(TokenRefreshTime - margin).Sub(auth.getClock().Now())
+			s.Unlock()
+		}
+		select {
+		case <-auth.stopRefresh:
+			return
+		case <-auth.getClock().After(wait):
+		}
+		if err := auth.backgroundRenewToken(s); err != nil {
+			logWarn(s.GetConfig(), "vast background token refresh failed", slog.String("error", err.Error()))
+			// Request path falls back to lazy refresh in Authorize. Here we just retry later with backoff.
+			if failed {
+				backoff = min(backoff*2, maxRefreshBackoff)
+			}
+			failed = true
+			continue
+		}
+		failed = false
+		backoff = minRefreshBackoff
+	}
 }
 
 func (auth *JWTAuthenticator) SetAuthHeader(s *VMSSession, headers *http.Header) error {
-	if err := auth.Authorize(s); err != nil {
+	access, err := auth.accessToken(s)
+	if err != nil {
 		return err
 	}
-	headers.Add("Authorization", "Bearer "+auth.Token.Access)
+	headers.Set("Authorization", "Bearer "+access)
 	return nil
 }
 
+// credentialsCacheTTL is how long api token returned by CredentialsProvider is used before provider is consulted again.
+const credentialsCacheTTL = time.Minute
+
 type ApiRTokenAuthenticator struct {
-	Token string
+	Token       string
+	Credentials CredentialsProvider // If set, token is taken from provider and renewed every credentialsCacheTTL
+
+	mu        sync.Mutex
+	fetchedAt time.Time
 }
 
 func (auth *ApiRTokenAuthenticator) Authorize(s *VMSSession) error {
-	if auth.Token == "" {
-		auth.Token = s.GetConfig().ApiToken
+	_, err := auth.apiToken(s)
+	return err
+}
+
+// apiToken returns api token consulting credentials provider (if set) when cached token is older than credentialsCacheTTL.
+// If provider fails previously fetched token is kept until next renewal attempt.
+func (auth *ApiRTokenAuthenticator) apiToken(s *VMSSession) (string, error) {
+	auth.mu.Lock()
+	defer auth.mu.Unlock()
+	if auth.Credentials == nil {
+		if auth.Token == "" {
+			auth.Token = s.GetConfig().ApiToken
+		}
+		return auth.Token, nil
 	}
-	return nil
+	if auth.Token != "" && time.Since(auth.fetchedAt) < credentialsCacheTTL {
+		return auth.Token, nil
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), tokenRequestTimeout)
+	defer cancel()
+	_, _, apiToken, err := auth.Credentials.Credentials(ctx)
+	if err == nil && apiToken == "" {
+		err = errors.New("credentials provider returned no api token")
+	}
+	if err != nil {
+		if auth.Token == "" {
+			return "", fmt.Errorf("failed to get credentials: %w", err)
+		}
+		logWarn(s.GetConfig(), "vast api token renewal failed, using cached token", slog.String("error", err.Error()))
+	} else {
+		auth.Token = apiToken
+	}
+	auth.fetchedAt = time.Now()
+	return auth.Token, nil
 }
 
 func (auth *ApiRTokenAuthenticator) SetAuthHeader(s *VMSSession, headers *http.Header) error {
-	if err := auth.Authorize(s); err != nil {
+	token, err := auth.apiToken(s)
+	if err != nil {
 		return err
 	}
-	headers.Add("Authorization", "Api-Token "+auth.Token)
+	headers.Set("Authorization", "Api-Token "+token)
 	return nil
 }
+
+// credentialsAuthenticator chooses authentication method by credentials returned by VMSConfig.Credentials
+// on first use (so provider is never called during client creation and its errors are reported by requests):
+// JWT if provider returns username/password, api token otherwise. Method is not changed afterwards.
+type credentialsAuthenticator struct {
+	Credentials CredentialsProvider
+
+	mu       sync.Mutex
+	resolved Authenticator
+}
+
+// authenticator returns authenticator of resolved authentication method, resolving it on first call.
+func (auth *credentialsAuthenticator) authenticator(s *VMSSession) (Authenticator, error) {
+	auth.mu.Lock()
+	defer auth.mu.Unlock()
+	if auth.resolved != nil {
+		return auth.resolved, nil
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), tokenRequestTimeout)
+	defer cancel()
+	username, _, apiToken, err := credentials(ctx, s.GetConfig())
+	if err != nil {
+		return nil, err
+	}
+	if username == "" && apiToken != "" {
+		auth.resolved = &ApiRTokenAuthenticator{Token: apiToken, Credentials: auth.Credentials, fetchedAt: time.Now()}
+	} else {
+		auth.resolved = &JWTAuthenticator{Credentials: auth.Credentials}
+	}
+	return auth.resolved, nil
+}
+
+func (auth *credentialsAuthenticator) Authorize(s *VMSSession) error {
+	resolved, err := auth.authenticator(s)
+	if err != nil {
+		return err
+	}
+	return resolved.Authorize(s)
+}
+
+func (auth *credentialsAuthenticator) SetAuthHeader(s *VMSSession, headers *http.Header) error {
+	resolved, err := auth.authenticator(s)
+	if err != nil {
+		return err
+	}
+	return resolved.SetAuthHeader(s, headers)
+}
+
+// resolvedAuthenticator returns authenticator which actually authenticates requests
+// (nil if authentication method of credentials provider is not resolved yet).
+func resolvedAuthenticator(auth Authenticator) Authenticator {
+	creds, ok := auth.(*credentialsAuthenticator)
+	if !ok {
+		return auth
+	}
+	creds.mu.Lock()
+	defer creds.mu.Unlock()
+	return creds.resolved
+}
@@ -0,0 +1,356 @@
+package vast_client
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestJWTPreRefreshConcurrentRequests(t *testing.T) {
+	srv := newTestServer(t, recordsHandler())
+	rest := newTestRest(t, &VMSConfig{
+		BaseURL:            srv.URL,
+		Username:           "admin",
+		Password:           "123456",
+		TokenPreRefresh:    true,
+		TokenRefreshMargin: TokenRefreshTime - time.Millisecond, // Token is refreshed in background every millisecond
+		RevokeTokenOnClose: true,
+	})
+	ctx := context.Background()
+
+	session := rest.Session.(*VMSSession)
+	auth := session.auth.(*JWTAuthenticator)
+
+	var wg sync.WaitGroup
+	stop := make(chan struct{})
+	time.AfterFunc(200*time.Millisecond, func() { close(stop) })
+	for i := range 16 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for {
+				select {
+				case <-stop:
+					return
+				default:
+				}
+				var err error
+				if i%2 == 0 {
+					_, err = rest.Views.List(ctx, nil)
+				} else {
+					// Header is built without network round-trip so reads of token are not synchronized with refresher by transport
+					err = auth.SetAuthHeader(session, &http.Header{})
+				}
+				if err != nil {
+					assert.ErrorIs(t, err, ErrClientClosed)
+					return
+				}
+			}
+		}()
+	}
+	time.Sleep(100 * time.Millisecond)
+	require.NoError(t, rest.Close())
+	wg.Wait()
+
+	assert.Greater(t, srv.TokenRequests(), 2, "token must be refreshed in background")
+	for _, r := range srv.Requests() {
+		assert.True(t, strings.HasPrefix(r.Header.Get("Authorization"), "Bearer access-"))
+	}
+}
+
+func TestJWTSetAuthHeader(t *testing.T) {
+	tests := []struct {
+		name      string
+		createdAt time.Time
+		want      string
+	}{
+		{name: "valid token", createdAt: time.Now(), want: "Bearer seeded"},
+		{name: "expired token", createdAt: time.Now().Add(-TokenRefreshTime), want: "Bearer access-1"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			srv := newTestServer(t, recordsHandler())
+			rest := newTestRest(t, &VMSConfig{BaseURL: srv.URL, Username: "admin", Password: "123456"})
+			session := rest.Session.(*VMSSession)
+			auth := session.auth.(*JWTAuthenticator)
+			auth.Token = &jwtToken{Access: "seeded", Refresh: "seeded-refresh", CreatedAt: tt.createdAt}
+			auth.initialized = true
+
+			headers := http.Header{}
+			require.NoError(t, auth.SetAuthHeader(session, &headers))
+			assert.Equal(t, tt.want, headers.Get("Authorization"))
+		})
+	}
+}
+
+// fakeClock is clock which moves only when Advance is called.
+type fakeClock struct {
+	mu     sync.Mutex
+	now    time.Time
+	timers []fakeTimer
+	waits  []time.Duration // Durations requested with After
+}
+
+type fakeTimer struct {
+	deadline time.Time
+	ch       chan time.Time
+}
+
+func newFakeClock() *fakeClock {
+	return &fakeClock{now: time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)}
+}
+
+func (c *fakeClock) Now() time.Time {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.now
+}
+
+func (c *fakeClock) After(d time.Duration) <-chan time.Time {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.waits = append(c.waits, d)
+	ch := make(chan time.Time, 1)
+	if d <= 0 {
+		ch <- c.now
+		return ch
+	}
+	c.timers = append(c.timers, fakeTimer{deadline: c.now.Add(d), ch: ch})
+	return ch
+}
+
+// Advance moves clock forward firing expired timers.
+func (c *fakeClock) Advance(d time.Duration) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.now = c.now.Add(d)
+	pending := c.timers[:0]
+	for _, timer := range c.timers {
+		if timer.deadline.After(c.now) {
+			pending = append(pending, timer)
+			continue
+		}
+		timer.ch <- c.now
+	}
+	c.timers = pending
+}
+
+// Waits returns durations requested with After so far.
+func (c *fakeClock) Waits() []time.Duration {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return append([]time.Duration(nil), c.waits...)
+}
+
+// waitForTimer blocks until some goroutine waits for timer (e.g. background refresher is idle).
+func (c *fakeClock) waitForTimer(t *testing.T) {
+	t.Helper()
+	require.Eventually(t, func() bool {
+		c.mu.Lock()
+		defer c.mu.Unlock()
+		return len(c.timers) > 0
+	}, 5*time.Second, time.Millisecond)
+}
+
+// syncBuffer is bytes.Buffer safe for concurrent use (e.g. as log output of background goroutines).
+type syncBuffer struct {
+	mu  sync.Mutex
+	buf bytes.Buffer
+}
+
+func (b *syncBuffer) Write(p []byte) (int, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.buf.Write(p)
+}
+
+func (b *syncBuffer) String() string {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.buf.String()
+}
+
+// jwtServer emulates VMS issuing "access-N" tokens. Token requests are passed to hook first
+// which may block them or respond with error status (zero status issues token).
+type jwtServer struct {
+	*httptest.Server
+
+	mu             sync.Mutex
+	issued         int
+	tokenPaths     []string
+	authorizations []string
+}
+
+func newJWTServer(t *testing.T, hook func(path string) int) *jwtServer {
+	t.Helper()
+	srv := &jwtServer{}
+	srv.Server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		path := resourceFromPath(r.URL.Path)
+		if !strings.HasPrefix(path, "token") {
+			srv.mu.Lock()
+			srv.authorizations = append(srv.authorizations, r.Header.Get("Authorization"))
+			srv.mu.Unlock()
+			writeTestJSON(w, http.StatusOK, []map[string]any{})
+			return
+		}
+		srv.mu.Lock()
+		srv.tokenPaths = append(srv.tokenPaths, path)
+		srv.mu.Unlock()
+		if hook != nil {
+			if status := hook(path); status != 0 {
+				writeTestJSON(w, status, map[string]string{"detail": "token endpoint failure"})
+				return
+			}
+		}
+		srv.mu.Lock()
+		srv.issued++
+		n := srv.issued
+		srv.mu.Unlock()
+		writeTestJSON(w, http.StatusOK, map[string]string{"access": fmt.Sprintf("access-%d", n), "refresh": fmt.Sprintf("refresh-%d", n)})
+	}))
+	t.Cleanup(srv.Close)
+	return srv
+}
+
+// TokenPaths returns paths of token requests received so far.
+func (s *jwtServer) TokenPaths() []string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]string(nil), s.tokenPaths...)
+}
+
+// LastAuthorization returns Authorization header of last resource request.
+func (s *jwtServer) LastAuthorization() string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.authorizations[len(s.authorizations)-1]
+}
+
+// newPreRefreshRest creates VMSRest with background token refresh driven by fake clock.
+func newPreRefreshRest(t *testing.T, srv *jwtServer, clock *fakeClock, config *VMSConfig) *VMSRest {
+	t.Helper()
+	config.BaseURL, config.Username, config.Password, config.TokenPreRefresh = srv.URL, "admin", "123456", true
+	rest := newTestRest(t, config)
+	rest.Session.(*VMSSession).auth.(*JWTAuthenticator).clock = clock
+	return rest
+}
+
+func TestJWTPreRefreshSteadyTraffic(t *testing.T) {
+	srv := newJWTServer(t, nil)
+	clock := newFakeClock()
+	logs := &syncBuffer{}
+	metrics := NewInMemoryMetrics()
+	rest := newPreRefreshRest(t, srv, clock, &VMSConfig{Logger: slog.New(slog.NewTextHandler(logs, nil)), Metrics: metrics})
+	ctx := context.Background()
+
+	for cycle := range 5 {
+		for range 3 {
+			_, err := rest.Views.List(ctx, nil)
+			require.NoError(t, err)
+		}
+		assert.Equal(t, fmt.Sprintf("Bearer access-%d", cycle+1), srv.LastAuthorization())
+		clock.waitForTimer(t)
+		clock.Advance(TokenRefreshTime - time.Minute) // Default TokenRefreshMargin
+		clock.waitForTimer(t)                         // Refresher is idle again once new token is stored
+	}
+	_, err := rest.Views.List(ctx, nil)
+	require.NoError(t, err)
+
+	assert.Equal(t, "Bearer access-6", srv.LastAuthorization())
+	assert.Equal(t, []string{"token", "token/refresh", "token/refresh", "token/refresh", "token/refresh", "token/refresh"}, srv.TokenPaths())
+	assert.NotContains(t, logs.String(), "vast token refreshed on request path")
+	total, failed := metrics.AuthRefreshes()
+	assert.Equal(t, 6, total)
+	assert.Equal(t, 0, failed)
+}
+
+func TestJWTBackgroundRefreshDoesNotBlockRequests(t *testing.T) {
+	var (
+		refreshes atomic.Int32
+		blocked   = make(chan struct{})
+		release   = make(chan struct{})
+	)
+	srv := newJWTServer(t, func(path string) int {
+		if path == "token/refresh" && refreshes.Add(1) == 1 {
+			close(blocked)
+			<-release
+		}
+		return 0
+	})
+	clock := newFakeClock()
+	rest := newPreRefreshRest(t, srv, clock, &VMSConfig{})
+	ctx := context.Background()
+
+	_, err := rest.Views.List(ctx, nil)
+	require.NoError(t, err)
+	clock.waitForTimer(t)
+	clock.Advance(TokenRefreshTime - time.Minute)
+	<-blocked
+
+	// Background refresh is in flight: requests are served with current token
+	requestCtx, cancel := context.WithTimeout(ctx, 2*time.Second)
+	defer cancel()
+	_, err = rest.Views.List(requestCtx, nil)
+	require.NoError(t, err)
+	assert.Equal(t, "Bearer access-1", srv.LastAuthorization())
+
+	// Token expires while background refresh is still in flight so request path refreshes it
+	clock.Advance(2 * time.Minute)
+	_, err = rest.Views.List(ctx, nil)
+	require.NoError(t, err)
+	assert.Equal(t, "Bearer access-2", srv.LastAuthorization())
+
+	// Result of background refresh doesn't overwrite newer token
+	close(release)
+	clock.waitForTimer(t)
+	_, err = rest.Views.List(ctx, nil)
+	require.NoError(t, err)
+	assert.Equal(t, "Bearer access-2", srv.LastAuthorization())
+	assert.Equal(t, []string{"token", "token/refresh", "token/refresh"}, srv.TokenPaths())
+}
+
+func TestJWTPreRefreshBackoff(t *testing.T) {
+	var failing atomic.Bool
+	srv := newJWTServer(t, func(string) int {
+		if failing.Load() {
+			return http.StatusServiceUnavailable
+		}
+		return 0
+	})
+	clock := newFakeClock()
+	rest := newPreRefreshRest(t, srv, clock, &VMSConfig{})
+
+	_, err := rest.Views.List(context.Background(), nil)
+	require.NoError(t, err)
+	failing.Store(true)
+	for range 9 {
+		clock.waitForTimer(t)
+		waits := clock.Waits()
+		clock.Advance(waits[len(waits)-1])
+	}
+	clock.waitForTimer(t)
+
+	assert.Equal(t, []time.Duration{
+		TokenRefreshTime - time.Minute,
+		time.Second, 2 * time.Second, 4 * time.Second, 8 * time.Second, 16 * time.Second, 32 * time.Second,
+		maxRefreshBackoff, maxRefreshBackoff, maxRefreshBackoff,
+	}, clock.Waits())
+
+	// Backoff is reset once token endpoint recovers
+	failing.Store(false)
+	clock.Advance(maxRefreshBackoff)
+	clock.waitForTimer(t)
+	waits := clock.Waits()
+	assert.Equal(t, TokenRefreshTime-time.Minute, waits[len(waits)-1])
+}
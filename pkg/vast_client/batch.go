@@ -0,0 +1,154 @@
+package vast_client
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"sync"
+)
+
+// defaultEnsureParallelism is number of workers used by EnsureMany when parallelism is not positive.
+const defaultEnsureParallelism = 8
+
+// EnsureItem describes single resource reconciled by EnsureMany.
+type EnsureItem struct {
+	Name         string // Resource is looked up by name unless SearchParams are set.
+	SearchParams Params
+	Body         Params
+	Options      EnsureOptions
+}
+
+// EnsureResult is result of reconciliation of single EnsureItem.
+type EnsureResult struct {
+	Record Record
+	Status EnsureStatus // Empty if reconciliation failed
+	Err    error
+}
+
+// EnsureMany reconciles items (see EnsureByParams) using pool of parallelism workers.
+// Results preserve order of items. Failure of one item doesn't stop others: per-item errors are reported in results.
+// Requests of all workers share client rate limiter and concurrency limit (see VMSConfig.RequestsPerSecond,
+// VMSConfig.MaxConcurrentRequests). If context is done items which were not started are reported with context
+// error and context error is returned.
+func (e *VastResourceEntry) EnsureMany(ctx context.Context, items []EnsureItem, parallelism int) ([]EnsureResult, error) {
+	if parallelism <= 0 {
+		parallelism = defaultEnsureParallelism
+	}
+	results := make([]EnsureResult, len(items))
+	indexes := make(chan int)
+	var wg sync.WaitGroup
+	for range min(parallelism, len(items)) {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range indexes {
+				item := items[i]
+				searchParams := item.SearchParams
+				if len(searchParams) == 0 {
+					searchParams = Params{"name": item.Name}
+				}
+				record, status, err := e.ensureByParams(ctx, searchParams, item.Body, item.Options)
+				results[i] = EnsureResult{Record: record, Status: status, Err: err}
+			}
+		}()
+	}
+	next := 0
+feed:
+	for ; next < len(items); next++ {
+		select {
+		case indexes <- next:
+		case <-ctx.Done():
+			break feed
+		}
+	}
+	close(indexes)
+	wg.Wait()
+	for i := next; i < len(items); i++ {
+		results[i] = EnsureResult{Err: ctx.Err()}
+	}
+	return results, ctx.Err()
+}
+
+// DeleteAllOptions controls DeleteAll.
+type DeleteAllOptions struct {
+	Parallelism int  // Number of concurrent deletions. Default is 8.
+	Wait        bool // Wait until every deleted resource is actually gone (see WaitForDeletion).
+	PollOptions PollOptions
+	// DryRun makes DeleteAll only list matched resources. Nothing is deleted and number of matches is returned.
+	DryRun bool
+	// Matched (if not nil) receives all resources matched by query (in DryRun mode these are resources which would be deleted).
+	Matched *RecordSet
+}
+
+// DeleteAll deletes every resource matching params (all pages) by id using pool of workers.
+// If VMS responds with asynchronous VTask, task is awaited (see DeleteAsync).
+// Failure of one deletion doesn't stop others. Returns number of deleted resources and joined errors of failed deletions.
+func (e *VastResourceEntry) DeleteAll(ctx context.Context, params Params, opts DeleteAllOptions) (int, error) {
+	ctx = withFeature(ctx, "delete_all")
+	matched, err := e.List(ctx, params)
+	if err != nil {
+		return 0, err
+	}
+	if opts.Matched != nil {
+		*opts.Matched = matched
+	}
+	if opts.DryRun {
+		return len(matched), nil
+	}
+	errs := make([]error, len(matched))
+	parallelism := opts.Parallelism
+	if parallelism <= 0 {
+		parallelism = defaultEnsureParallelism
+	}
+	indexes := make(chan int)
+	var wg sync.WaitGroup
+	for range min(parallelism, len(matched)) {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range indexes {
+				errs[i] = e.deleteMatched(ctx, matched[i], params, opts)
+			}
+		}()
+	}
+	for i := range matched {
+		indexes <- i
+	}
+	close(indexes)
+	wg.Wait()
+	deleted := 0
+	for _, err := range errs {
+		if err == nil {
+			deleted++
+		}
+	}
+	return deleted, errors.Join(errs...)
+}
+
+// deleteMatched deletes single resource matched by DeleteAll.
+func (e *VastResourceEntry) deleteMatched(ctx context.Context, record Record, params Params, opts DeleteAllOptions) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	ident, err := e.recordIdentifier(record, params)
+	if err != nil {
+		return err
+	}
+	path := fmt.Sprintf("%s/%s", e.resourcePath, url.PathEscape(ident))
+	if _, _, err = e.requestAndWaitTask(ctx, http.MethodDelete, path, nil, false, nil); err != nil {
+		return fmt.Errorf("failed to delete %s %s: %w", e.resourceType, ident, err)
+	}
+	if opts.Wait {
+		id, err := strconv.ParseInt(ident, 10, 64)
+		if err != nil {
+			return fmt.Errorf("cannot wait for deletion of %s %s: identifier is not numeric", e.resourceType, ident)
+		}
+		if err = e.WaitForDeletion(ctx, id, opts.PollOptions); err != nil {
+			return fmt.Errorf("failed to wait for deletion of %s %s: %w", e.resourceType, ident, err)
+		}
+	}
+	return nil
+}
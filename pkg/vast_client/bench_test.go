@@ -0,0 +1,137 @@
+package vast_client
+
+import (
+	"bytes"
+	"compress/gzip"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+)
+
+// syntheticQuotas returns JSON encoded list of n quota-like records.
+func syntheticQuotas(b *testing.B, n int) []byte {
+	b.Helper()
+	records := make([]map[string]any, n)
+	for i := range records {
+		records[i] = map[string]any{
+			"id": i, "guid": fmt.Sprintf("3e1c5a2e-0000-4000-8000-%012d", i), "name": fmt.Sprintf("quota-%d", i),
+			"path": fmt.Sprintf("/tenants/t%d/data/%d", i%10, i), "tenant_id": i % 10, "hard_limit": 1 << 40,
+			"soft_limit": 1 << 39, "used_capacity": i * 1024, "state": "OK", "enable_alarms": true,
+		}
+	}
+	body, err := json.Marshal(records)
+	if err != nil {
+		b.Fatal(err)
+	}
+	return body
+}
+
+func gzipBytes(b *testing.B, data []byte) []byte {
+	b.Helper()
+	var buf bytes.Buffer
+	w := gzip.NewWriter(&buf)
+	if _, err := w.Write(data); err != nil {
+		b.Fatal(err)
+	}
+	if err := w.Close(); err != nil {
+		b.Fatal(err)
+	}
+	return buf.Bytes()
+}
+
+func BenchmarkBuildRequest(b *testing.B) {
+	rest, err := newVMSRest(&VMSConfig{BaseURL: "https://vms.example.com", ApiToken: testApiToken})
+	if err != nil {
+		b.Fatal(err)
+	}
+	defer rest.Close()
+	params := Params{"tenant_id": 1, "name__contains": "data", "page_size": 100, "fields": "id,name,path"}
+	body, _ := json.Marshal(Params{"path": "/data", "policy_id": 1, "protocols": []string{"NFS", "SMB"}})
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		info := newRequestInfo(http.MethodPost, "request-id", params, body)
+		if _, err := buildUrl(rest.Session, "views", info.Params.ToQuery(), "v5"); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func BenchmarkDecodeResponse(b *testing.B) {
+	plain := syntheticQuotas(b, 5000)
+	compressed := gzipBytes(b, plain)
+	cases := []struct {
+		name     string
+		body     []byte
+		encoding string
+	}{
+		{name: "plain", body: plain},
+		{name: "gzip", body: compressed, encoding: "gzip"},
+	}
+	for _, c := range cases {
+		b.Run(c.name, func(b *testing.B) {
+			b.SetBytes(int64(len(c.body)))
+			b.ReportAllocs()
+			for i := 0; i < b.N; i++ {
+				response := &http.Response{Header: http.Header{}, Body: io.NopCloser(bytes.NewReader(c.body))}
+				if c.encoding != "" {
+					response.Header.Set("Content-Encoding", c.encoding)
+				}
+				if err := decompressResponse(response); err != nil {
+					b.Fatal(err)
+				}
+				result, _, err := decodeResponse[RecordSet](response)
+				if err != nil {
+					b.Fatal(err)
+				}
+				if len(result) != 5000 {
+					b.Fatalf("decoded %d records", len(result))
+				}
+			}
+		})
+	}
+}
+
+// BenchmarkListCompression lists large synthetic collection with and without compression.
+// Reported wire-bytes/op shows bandwidth reduction.
+func BenchmarkListCompression(b *testing.B) {
+	plain := syntheticQuotas(b, 5000)
+	compressed := gzipBytes(b, plain)
+	var written atomic.Int64
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body := plain
+		if strings.Contains(r.Header.Get("Accept-Encoding"), "gzip") {
+			w.Header().Set("Content-Encoding", "gzip")
+			body = compressed
+		}
+		w.Header().Set("Content-Type", ApplicationJson)
+		n, _ := w.Write(body)
+		written.Add(int64(n))
+	}))
+	defer srv.Close()
+
+	for _, disable := range []bool{false, true} {
+		b.Run(fmt.Sprintf("DisableCompression=%t", disable), func(b *testing.B) {
+			rest, err := newVMSRest(&VMSConfig{BaseURL: srv.URL, ApiToken: testApiToken, DisableCompression: disable, ApiVersion: "v5"})
+			if err != nil {
+				b.Fatal(err)
+			}
+			defer rest.Close()
+			written.Store(0)
+			b.ReportAllocs()
+			b.ResetTimer()
+			for i := 0; i < b.N; i++ {
+				if _, err := rest.Quotas.List(context.Background(), nil); err != nil {
+					b.Fatal(err)
+				}
+			}
+			b.ReportMetric(float64(written.Load())/float64(b.N), "wire-bytes/op")
+		})
+	}
+}
@@ -0,0 +1,110 @@
+package vast_client
+
+import (
+	"context"
+	"fmt"
+	"time"
+)
+
+// recentTasksWindow is number of the most recent tasks used to estimate task failure rate.
+const recentTasksWindow = 50
+
+// BusynessReport describes how busy VAST cluster is at the moment.
+type BusynessReport struct {
+	RunningTasks       int            // Total number of running tasks
+	RunningTasksByName map[string]int // Number of running tasks per task name (type)
+	ClusterState       string         // Cluster state (e.g. "ONLINE")
+	UpgradeState       string         // Cluster upgrade state (e.g. "DONE", "RUNNING")
+	Rebuilding         bool           // Whether data rebuild is in progress
+	RecentFailureRate  float64        // Share (0..1) of failed tasks among the most recent ones
+}
+
+// BusynessThresholds defines when cluster is considered busy (see BusynessReport.IsIdle).
+type BusynessThresholds struct {
+	MaxRunningTasks int     // Max number of running tasks
+	MaxFailureRate  float64 // Max share (0..1) of recently failed tasks
+	AllowUpgrade    bool    // Consider cluster idle even if upgrade is in progress
+	AllowRebuild    bool    // Consider cluster idle even if data rebuild is in progress
+}
+
+// DefaultBusynessThresholds are reasonable thresholds before launching heavy bulk jobs.
+var DefaultBusynessThresholds = BusynessThresholds{
+	MaxRunningTasks: 10,
+	MaxFailureRate:  0.5,
+}
+
+// IsIdle reports whether cluster is idle according to thresholds.
+func (r BusynessReport) IsIdle(t BusynessThresholds) bool {
+	upgrading := r.UpgradeState != "" && !containsFold([]string{"done", "none", "idle"}, r.UpgradeState)
+	switch {
+	case r.RunningTasks > t.MaxRunningTasks:
+		return false
+	case r.RecentFailureRate > t.MaxFailureRate:
+		return false
+	case upgrading && !t.AllowUpgrade:
+		return false
+	case r.Rebuilding && !t.AllowRebuild:
+		return false
+	}
+	return true
+}
+
+// Busyness collects BusynessReport. Only few requests with selected fields are issued so it is cheap to call.
+func (rest *VMSRest) Busyness(ctx context.Context) (BusynessReport, error) {
+	report := BusynessReport{RunningTasksByName: make(map[string]int)}
+	running, err := rest.VTasks.List(ctx, Params{"state": "running", "fields": "id,name"})
+	if err != nil {
+		return report, err
+	}
+	report.RunningTasks = len(running)
+	for _, task := range running {
+		report.RunningTasksByName[fmt.Sprintf("%v", task["name"])]++
+	}
+	recent, err := rest.VTasks.List(ctx, WithFields(Params{}, "id", "state").OrderBy("-id").Limit(recentTasksWindow))
+	if err != nil {
+		return report, err
+	}
+	if len(recent) > 0 {
+		var failed int
+		for _, task := range recent {
+			if _, state, _ := describeTask(task); containsFold(taskFailedStates, state) {
+				failed++
+			}
+		}
+		report.RecentFailureRate = float64(failed) / float64(len(recent))
+	}
+	clusters, err := rest.Clusters.ListFields(ctx, nil, "id", "state", "upgrade_state", "rebuild_in_progress")
+	if err != nil {
+		return report, err
+	}
+	if len(clusters) > 0 {
+		cluster := clusters[0]
+		if state, ok := cluster["state"]; ok && state != nil {
+			report.ClusterState = fmt.Sprintf("%v", state)
+		}
+		if state, ok := cluster["upgrade_state"]; ok && state != nil {
+			report.UpgradeState = fmt.Sprintf("%v", state)
+		}
+		report.Rebuilding, _ = cluster["rebuild_in_progress"].(bool)
+	}
+	return report, nil
+}
+
+// WaitUntilIdle polls Busyness until cluster is idle according to thresholds or timeout is over.
+// Returns the last collected report.
+func (rest *VMSRest) WaitUntilIdle(ctx context.Context, thresholds BusynessThresholds, timeout time.Duration) (BusynessReport, error) {
+	var report BusynessReport
+	opts := PollOptions{Timeout: timeout, Interval: time.Second * 5, MaxInterval: time.Second * 30}
+	err := poll(ctx, opts, func(ctx context.Context) (bool, error) {
+		var err error
+		if report, err = rest.Busyness(ctx); err != nil {
+			return false, err
+		}
+		return report.IsIdle(thresholds), nil
+	})
+	if isContextErr(err) {
+		err = fmt.Errorf("cluster is still busy (running tasks: %d, upgrade state: %q, rebuilding: %v): %w",
+			report.RunningTasks, report.UpgradeState, report.Rebuilding, err)
+	}
+	return report, err
+}
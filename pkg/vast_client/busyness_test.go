@@ -0,0 +1,131 @@
+package vast_client
+
+import (
+	"context"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// clusterState is scripted state of cluster served by busynessHandler.
+type clusterState struct {
+	running []map[string]any // Running tasks
+	recent  []map[string]any // Most recent tasks
+	cluster map[string]any
+}
+
+func busynessHandler(state clusterState) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		switch resourceFromPath(r.URL.Path) {
+		case "vtasks":
+			if r.URL.Query().Get("state") == "running" {
+				writeTestJSON(w, http.StatusOK, state.running)
+			} else {
+				writeTestJSON(w, http.StatusOK, state.recent)
+			}
+		case "clusters":
+			writeTestJSON(w, http.StatusOK, []map[string]any{state.cluster})
+		default:
+			writeTestJSON(w, http.StatusNotFound, map[string]string{"detail": "not found"})
+		}
+	}
+}
+
+// tasks returns n tasks with given name and state.
+func tasks(n int, name, state string) []map[string]any {
+	var result []map[string]any
+	for i := range n {
+		result = append(result, map[string]any{"id": i + 1, "name": name, "state": state})
+	}
+	return result
+}
+
+func TestBusyness(t *testing.T) {
+	online := map[string]any{"id": 1, "state": "ONLINE", "upgrade_state": "DONE", "rebuild_in_progress": false}
+	tests := []struct {
+		name       string
+		state      clusterState
+		thresholds BusynessThresholds
+		want       BusynessReport
+		wantIdle   bool
+	}{
+		{
+			name:       "idle",
+			state:      clusterState{running: tasks(2, "snapshot", "running"), recent: tasks(4, "snapshot", "completed"), cluster: online},
+			thresholds: DefaultBusynessThresholds,
+			want:       BusynessReport{RunningTasks: 2, RunningTasksByName: map[string]int{"snapshot": 2}, ClusterState: "ONLINE", UpgradeState: "DONE"},
+			wantIdle:   true,
+		},
+		{
+			name:       "too many running tasks",
+			state:      clusterState{running: append(tasks(8, "replication", "running"), tasks(4, "snapshot", "running")...), cluster: online},
+			thresholds: DefaultBusynessThresholds,
+			want:       BusynessReport{RunningTasks: 12, RunningTasksByName: map[string]int{"replication": 8, "snapshot": 4}, ClusterState: "ONLINE", UpgradeState: "DONE"},
+		},
+		{
+			name:       "recent tasks fail",
+			state:      clusterState{recent: append(tasks(3, "x", "failed"), tasks(1, "x", "completed")...), cluster: online},
+			thresholds: DefaultBusynessThresholds,
+			want:       BusynessReport{RunningTasksByName: map[string]int{}, ClusterState: "ONLINE", UpgradeState: "DONE", RecentFailureRate: 0.75},
+		},
+		{
+			name:       "upgrade in progress",
+			state:      clusterState{cluster: map[string]any{"id": 1, "state": "ONLINE", "upgrade_state": "RUNNING"}},
+			thresholds: DefaultBusynessThresholds,
+			want:       BusynessReport{RunningTasksByName: map[string]int{}, ClusterState: "ONLINE", UpgradeState: "RUNNING"},
+		},
+		{
+			name:       "upgrade allowed",
+			state:      clusterState{cluster: map[string]any{"id": 1, "state": "ONLINE", "upgrade_state": "RUNNING"}},
+			thresholds: BusynessThresholds{MaxRunningTasks: 10, MaxFailureRate: 0.5, AllowUpgrade: true},
+			want:       BusynessReport{RunningTasksByName: map[string]int{}, ClusterState: "ONLINE", UpgradeState: "RUNNING"},
+			wantIdle:   true,
+		},
+		{
+			name:       "rebuild in progress",
+			state:      clusterState{cluster: map[string]any{"id": 1, "state": "ONLINE", "rebuild_in_progress": true}},
+			thresholds: DefaultBusynessThresholds,
+			want:       BusynessReport{RunningTasksByName: map[string]int{}, ClusterState: "ONLINE", Rebuilding: true},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			srv := newTestServer(t, busynessHandler(tt.state))
+			rest := newTestRest(t, srv.config())
+
+			report, err := rest.Busyness(context.Background())
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, report)
+			assert.Equal(t, tt.wantIdle, report.IsIdle(tt.thresholds))
+
+			requests := srv.Requests()
+			assert.Len(t, requests, 3)
+			for _, r := range requests {
+				assert.NotEmpty(t, r.Query.Get("fields"), "only selected fields are requested from %s", r.Path)
+			}
+		})
+	}
+}
+
+func TestWaitUntilIdle(t *testing.T) {
+	ctx := context.Background()
+	idle := clusterState{cluster: map[string]any{"id": 1, "state": "ONLINE"}}
+	srv := newTestServer(t, busynessHandler(idle))
+	rest := newTestRest(t, srv.config())
+
+	report, err := rest.WaitUntilIdle(ctx, DefaultBusynessThresholds, time.Second)
+	require.NoError(t, err)
+	assert.Equal(t, "ONLINE", report.ClusterState)
+
+	busy := clusterState{running: tasks(20, "snapshot", "running"), cluster: map[string]any{"id": 1, "state": "ONLINE"}}
+	srv = newTestServer(t, busynessHandler(busy))
+	rest = newTestRest(t, srv.config())
+
+	report, err = rest.WaitUntilIdle(ctx, DefaultBusynessThresholds, 50*time.Millisecond)
+	assert.ErrorIs(t, err, context.DeadlineExceeded)
+	assert.ErrorContains(t, err, "running tasks: 20")
+	assert.Equal(t, 20, report.RunningTasks)
+}
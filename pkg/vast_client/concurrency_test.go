@@ -0,0 +1,95 @@
+package vast_client
+
+import (
+	"context"
+	"net/http"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// blockingHandler reports arrival of each request to arrived and responds only when release is closed or receives value.
+func blockingHandler(arrived chan<- struct{}, release <-chan struct{}) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		arrived <- struct{}{}
+		<-release
+		writeTestJSON(w, http.StatusOK, []map[string]any{})
+	}
+}
+
+func TestMaxConcurrentRequests(t *testing.T) {
+	const limit = 2
+	arrived := make(chan struct{}, limit+1)
+	release := make(chan struct{})
+	srv := newTestServer(t, blockingHandler(arrived, release))
+	config := srv.config()
+	config.MaxConcurrentRequests = limit
+	metrics := NewInMemoryMetrics()
+	config.Metrics = metrics
+	rest := newTestRest(t, config)
+
+	var wg sync.WaitGroup
+	errs := make(chan error, limit+1)
+	for i := 0; i < limit+1; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			_, err := rest.Views.List(context.Background(), nil)
+			errs <- err
+		}()
+	}
+	for i := 0; i < limit; i++ {
+		select {
+		case <-arrived:
+		case <-time.After(5 * time.Second):
+			t.Fatal("requests below limit are not sent")
+		}
+	}
+	select {
+	case <-arrived:
+		t.Fatal("request above limit is sent before any request is completed")
+	case <-time.After(100 * time.Millisecond):
+	}
+	current, _ := metrics.InFlight()
+	assert.Equal(t, limit, current, "client is saturated")
+
+	release <- struct{}{}
+	select {
+	case <-arrived:
+	case <-time.After(5 * time.Second):
+		t.Fatal("blocked request is not sent after slot is released")
+	}
+	close(release)
+	wg.Wait()
+	close(errs)
+	for err := range errs {
+		require.NoError(t, err)
+	}
+	current, peak := metrics.InFlight()
+	assert.Equal(t, 0, current)
+	assert.Equal(t, limit, peak)
+}
+
+func TestMaxConcurrentRequestsContextDone(t *testing.T) {
+	arrived := make(chan struct{}, 1)
+	release := make(chan struct{})
+	srv := newTestServer(t, blockingHandler(arrived, release))
+	defer close(release)
+	config := srv.config()
+	config.MaxConcurrentRequests = 1
+	rest := newTestRest(t, config)
+
+	go func() { _, _ = rest.Views.List(context.Background(), nil) }()
+	<-arrived
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	_, err := rest.Views.List(ctx, nil)
+
+	require.ErrorIs(t, err, context.DeadlineExceeded)
+	assert.Contains(t, err.Error(), "waiting for free request slot")
+	assert.Len(t, srv.Requests(), 1, "waiting request is not sent")
+}
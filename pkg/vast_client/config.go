@@ -3,7 +3,13 @@ package vast_client
 import (
 	"context"
 	"errors"
+	"fmt"
 	"io"
+	"log/slog"
+	"net"
+	"net/url"
+	"strconv"
+	"strings"
 	"time"
 )
 
@@ -15,10 +21,117 @@ type VMSConfig struct {
 	Password       string         // The password for authentication (used with Username).
 	ApiToken       string         // Optional API token for authentication (alternative to Username/Password).
 	SslVerify      bool           // Whether to verify SSL certificates.
-	Timeout        *time.Duration // HTTP client timeout. If nil, a default is applied by validators.
+	Timeout        *time.Duration // Deprecated: used as IdleConnTimeout if the latter is not set. If nil, a default is applied by validators.
 	MaxConnections int            // Maximum number of concurrent HTTP connections.
 	UserAgent      string         // Optional custom User-Agent header to use in HTTP requests. If empty, a default may be applied.
-	ApiVersion     string         // Optional API version
+	ApiVersion     string         // Optional API version (e.g. "v5", "latest" or ApiVersionAuto to negotiate it with cluster)
+
+	// Credentials optionally supplies username/password or api token on demand instead of Username/Password/ApiToken
+	// (see CredentialsProvider, FileCredentials). Provider is consulted lazily, starting from first request.
+	Credentials CredentialsProvider
+
+	// OIDC enables OAuth2 client-credentials authentication against external identity provider (e.g. Keycloak)
+	// instead of VMS local users. Cannot be combined with other authentication methods.
+	OIDC *OIDCConfig
+
+	// BaseURL optionally overrides scheme, host and port of VMS (e.g. "http://127.0.0.1:8080" for test servers
+	// or "https://proxy.local/vms" behind reverse proxy). Host and Port are filled from it.
+	BaseURL string
+
+	// ProxyURL optionally sets proxy for all requests including token requests (e.g. "http://proxy.local:3128").
+	// If empty, proxy is taken from HTTP_PROXY, HTTPS_PROXY and NO_PROXY environment variables.
+	ProxyURL string
+
+	// EnableTelemetry adds X-Vast-Client-Feature header to every request describing client code path
+	// (resource type, helper name and client version) so VAST can plan deprecations. Off by default.
+	// Header never contains any payload data.
+	EnableTelemetry bool
+
+	// InitialAccessToken and InitialRefreshToken pre-seed JWT authentication with tokens obtained by other process
+	// (see VMSRest.ExportToken and ImportToken). Username/password exchange is skipped until token refresh fails;
+	// then Username/Password (if set) are used to acquire new tokens.
+	InitialAccessToken  string
+	InitialRefreshToken string
+
+	// TokenStore optionally persists JWT tokens between process restarts (see FileTokenStore) so short-lived
+	// processes don't log in on every run. Stored token is validated by refresh; if it is stale or revoked
+	// new token is acquired with username/password. DisableTokenCache turns store off without removing it from config.
+	TokenStore        TokenStore
+	DisableTokenCache bool
+
+	// TokenPreRefresh enables background goroutine that refreshes JWT token TokenRefreshMargin before it expires,
+	// so request path never waits for token refresh. If background refresh fails, token is refreshed lazily on request.
+	TokenPreRefresh    bool
+	TokenRefreshMargin time.Duration // How long before expiration token is refreshed in background. Default is 1 minute.
+
+	// RequestTimeout limits duration of every request which has no class timeout from Timeouts.
+	// Requests aborted by this timeout fail with ClientTimeoutError. Default is 5 minutes.
+	RequestTimeout time.Duration
+	// IdleConnTimeout is the maximum amount of time an idle (keep-alive) connection remains open.
+	// Defaults to Timeout (30 seconds unless set).
+	IdleConnTimeout time.Duration
+
+	// Timeouts are per-request timeouts applied according to request class.
+	// Zero value means no timeout. Deadline set on context by caller is never extended: the earlier of the two applies.
+	Timeouts Timeouts
+
+	// DisableCreatePreflight disables client-side check of fields required on Create.
+	// Useful for VAST versions where set of required fields changed.
+	DisableCreatePreflight bool
+
+	RevokeTokenOnClose bool // Revoke (blacklist) JWT refresh token on VMSRest.Close if cluster supports it.
+
+	ReadOnly bool // Guardrail that rejects all mutating requests (POST, PUT, PATCH, DELETE) before they are sent.
+
+	// DryRun makes mutating requests (POST, PUT, PATCH, DELETE) not to be sent. They are logged at info level
+	// (verb, url, redacted body) and synthetic response echoing request body with "dry_run": true marker is returned.
+	// GET requests are executed normally.
+	DryRun bool
+
+	// DisableCompression disables gzip compression of responses (Accept-Encoding: gzip is sent by default).
+	DisableCompression bool
+
+	// Logger is an optional structured logger. Each request is logged at debug level (verb, url, status, duration),
+	// failed requests at error level and token refreshes on request path or refresh failures at warn level.
+	// Nil logger disables logging.
+	Logger    *slog.Logger
+	LogBodies bool // Log request/response bodies (sensitive fields are redacted). Requires Logger.
+
+	// RedactErrorBodies redacts sensitive fields (see SetSensitiveKeys) in response bodies of failed requests
+	// kept in ApiError.Body, so secrets echoed by VMS don't leak into error messages and logs.
+	// Bodies which are not JSON are replaced with their size.
+	RedactErrorBodies bool
+
+	// RequestsPerSecond enables client-side rate limiting of requests sent to VMS (shared by all resources
+	// of VMSRest). Token requests are not limited. Zero disables rate limiting.
+	RequestsPerSecond float64
+	Burst             int // Max number of requests sent at once without waiting. Default is 1 when rate limiting is enabled.
+
+	// MaxConcurrentRequests bounds number of in-flight requests of VMSRest (shared by all resources).
+	// Requests above the limit wait for free slot (or context cancellation). Unlike MaxConnections, which limits
+	// transport connections per host (excess requests still queue inside transport without bound), this limit
+	// applies before request is dispatched. Zero means no limit.
+	MaxConcurrentRequests int
+
+	// Retry is an optional policy of retrying failed GET requests (see RetryPolicy). Nil disables retries.
+	// Can be overridden per resource with SetRetryPolicy and per call with WithRetryPolicy.
+	Retry *RetryPolicy
+
+	// Metrics is an optional recorder of request, retry and token refresh metrics (see MetricsRecorder).
+	Metrics MetricsRecorder
+
+	// Tracer is an optional tracer which starts span around every request (see Tracer for OpenTelemetry adapter).
+	Tracer Tracer
+
+	// Audit is an optional sink which receives entry for every mutating request (POST, PUT, PATCH, DELETE)
+	// with redacted body, response status and id of resulting object (see JSONLinesAuditSink).
+	// Failures to record entry are logged at warn level and don't fail request unless AuditStrict is set.
+	Audit       AuditSink
+	AuditStrict bool // Fail request with AuditError if audit entry cannot be recorded.
+
+	// DefaultHeaders are extra headers added to every request (e.g. headers required by API gateway).
+	// Headers set with WithHeaders context take precedence. Reserved headers (see reservedHeaders) are rejected.
+	DefaultHeaders map[string]string
 
 	// BeforeRequestFn is an optional function hook executed before an API request is sent.
 	// It allows for request inspection, mutation, or logging.
@@ -33,6 +146,14 @@ type VMSConfig struct {
 	//   - error: Any error returned will abort the request.
 	BeforeRequestFn func(ctx context.Context, verb, url string, body io.Reader) error
 
+	// BeforeRequestFnV2 is an optional function hook executed before an API request is sent (after BeforeRequestFn).
+	// Unlike BeforeRequestFn it can add headers and rewrite query params of request (see RequestInfo),
+	// e.g. for request signing or header injection.
+	//
+	// Return:
+	//   - error: Any error returned will abort the request.
+	BeforeRequestFnV2 func(ctx context.Context, info *RequestInfo) error
+
 	// AfterRequestFn is an optional function hook executed after receiving an API response.
 	// It can be used for post-processing, transformation, or logging of the response.
 	//
@@ -43,6 +164,37 @@ type VMSConfig struct {
 	//   - A potentially modified Renderable object.
 	//   - An error, if processing the response fails.
 	AfterRequestFn func(response Renderable) (Renderable, error)
+
+	// AfterRequestFnV2 is an optional function hook executed after receiving an API response (after AfterRequestFn
+	// or default response mutations). Along with response it receives request metadata (verb, URL, status code,
+	// latency and request ID) so it can be used as observability point.
+	//
+	// Returns:
+	//   - A potentially modified Renderable object.
+	//   - An error, if processing the response fails.
+	AfterRequestFnV2 func(ctx context.Context, info ResponseInfo, response Renderable) (Renderable, error)
+
+	// Interceptors is an ordered chain of request hooks run after BeforeRequestFn/BeforeRequestFnV2
+	// and before AfterRequestFnV2. Before hooks run in order, after hooks run in reverse order.
+	// Error returned by any hook aborts request with InterceptorError.
+	Interceptors []Interceptor
+}
+
+// AddBeforeRequest appends named before request hook to interceptors chain.
+func (config *VMSConfig) AddBeforeRequest(name string, fn func(ctx context.Context, info *RequestInfo) error) {
+	config.Interceptors = append(config.Interceptors, Interceptor{Name: name, Before: fn})
+}
+
+// AddAfterRequest appends named after request hook to interceptors chain.
+func (config *VMSConfig) AddAfterRequest(name string, fn func(ctx context.Context, info ResponseInfo, response Renderable) (Renderable, error)) {
+	config.Interceptors = append(config.Interceptors, Interceptor{Name: name, After: fn})
+}
+
+// Timeouts defines per-request timeouts for different classes of requests.
+type Timeouts struct {
+	Read        time.Duration // Timeout for GET requests.
+	Write       time.Duration // Timeout for mutating requests (POST, PUT, PATCH, DELETE).
+	LongRunning time.Duration // Timeout for requests made with AsLongRunning context. Also default timeout for task waits.
 }
 
 // VMSConfigFunc defines a function that can modify or validate a VMSConfig.
@@ -51,11 +203,19 @@ type VMSConfigFunc func(*VMSConfig) error
 // Validate applies the given VMSConfigFunc validators to the config.
 // Panics if any validator returns an error.
 func (config *VMSConfig) Validate(validators ...VMSConfigFunc) {
+	if err := config.validate(validators...); err != nil {
+		panic(err)
+	}
+}
+
+// validate applies the given VMSConfigFunc validators to the config and returns first error.
+func (config *VMSConfig) validate(validators ...VMSConfigFunc) error {
 	for _, fn := range validators {
 		if err := fn(config); err != nil {
-			panic(err)
+			return err
 		}
 	}
+	return nil
 }
 
 // withTimeout returns a VMSConfigFunc that sets a default timeout if none is provided.
@@ -68,9 +228,31 @@ func withTimeout(timeout time.Duration) VMSConfigFunc {
 	}
 }
 
-// withMaxConnections returns a VMSConfigFunc that sets the maximum number of connections
+// withRequestTimeout returns a VMSConfigFunc that sets a default per-request timeout if none is provided.
+func withRequestTimeout(timeout time.Duration) VMSConfigFunc {
+	return func(config *VMSConfig) error {
+		if config.RequestTimeout == 0 {
+			config.RequestTimeout = timeout
+		}
+		if config.RequestTimeout < 0 {
+			return errors.New("request timeout must not be negative")
+		}
+		return nil
+	}
+}
+
+// withIdleConnTimeout sets IdleConnTimeout from legacy Timeout field if not provided.
+// NOTE: must be applied after withTimeout.
+func withIdleConnTimeout(config *VMSConfig) error {
+	if config.IdleConnTimeout == 0 && config.Timeout != nil {
+		config.IdleConnTimeout = *config.Timeout
+	}
+	return nil
+}
+
+// withDefaultMaxConnections returns a VMSConfigFunc that sets the maximum number of connections
 // if not explicitly provided.
-func withMaxConnections(maxConnections int) VMSConfigFunc {
+func withDefaultMaxConnections(maxConnections int) VMSConfigFunc {
 	return func(config *VMSConfig) error {
 		if config.MaxConnections == 0 {
 			config.MaxConnections = maxConnections
@@ -79,12 +261,32 @@ func withMaxConnections(maxConnections int) VMSConfigFunc {
 	}
 }
 
-// withHost validates that the Host field is not empty.
-// Panics if Host is an empty string.
+// withHost validates that the Host field is a valid hostname, IPv4 or IPv6 address (without port).
+// IPv6 address can be provided with or without brackets and may contain zone identifier (e.g. "fe80::1%eth0").
+// Brackets are stripped so host can be safely joined with port.
 func withHost(config *VMSConfig) error {
-	if config.Host == "" {
-		panic("host cannot be empty string")
+	if config.BaseURL != "" {
+		if err := applyBaseUrl(config); err != nil {
+			return err
+		}
+	}
+	host := strings.TrimSpace(config.Host)
+	if host == "" {
+		return errors.New("host cannot be empty string")
+	}
+	if strings.HasPrefix(host, "[") && strings.HasSuffix(host, "]") {
+		host = host[1 : len(host)-1]
+	}
+	if strings.Contains(host, ":") {
+		// Only IPv6 literal can contain colons. Zone identifier is not part of address.
+		addr, _, _ := strings.Cut(host, "%")
+		if ip := net.ParseIP(addr); ip == nil || ip.To4() != nil {
+			return fmt.Errorf("invalid host %q: expected hostname, IPv4 or IPv6 address without port", config.Host)
+		}
+	} else if strings.ContainsAny(host, "/[]%@? ") {
+		return fmt.Errorf("invalid host %q: expected hostname, IPv4 or IPv6 address without port", config.Host)
 	}
+	config.Host = host
 	return nil
 }
 
@@ -98,13 +300,20 @@ func withPort(defaultPort uint64) VMSConfigFunc {
 	}
 }
 
-// withAuth validates that either a username/password combination or an API token
-// is provided for authentication. Returns an error if neither is set.
+// withAuth validates that at least one authentication method is provided
+// (username/password, API token, credentials provider, OIDC or pre-seeded tokens) and that OIDC is not combined with others.
 func withAuth(config *VMSConfig) error {
 	hasUserPass := config.Username != "" && config.Password != ""
 	hasToken := config.ApiToken != ""
-	if !hasUserPass && !hasToken {
-		return errors.New("either username/password or api token must be provided")
+	hasInitialToken := config.InitialAccessToken != "" && config.InitialRefreshToken != ""
+	if config.OIDC != nil {
+		if hasUserPass || hasToken || hasInitialToken || config.Credentials != nil {
+			return errors.New("OIDC cannot be combined with other authentication methods")
+		}
+		return config.OIDC.validate()
+	}
+	if !hasUserPass && !hasToken && !hasInitialToken && config.Credentials == nil {
+		return errors.New("either username/password, api token, credentials provider, OIDC or initial access/refresh tokens must be provided")
 	}
 	return nil
 }
@@ -119,6 +328,168 @@ func withUserAgent(config *VMSConfig) error {
 	return nil
 }
 
+// withTokenRefreshMargin sets a default margin for background token refresh
+// and validates it is shorter than token refresh interval.
+func withTokenRefreshMargin(margin time.Duration) VMSConfigFunc {
+	return func(config *VMSConfig) error {
+		if config.TokenRefreshMargin == 0 {
+			config.TokenRefreshMargin = margin
+		}
+		if config.TokenRefreshMargin < 0 || config.TokenRefreshMargin >= TokenRefreshTime {
+			return fmt.Errorf("token refresh margin must be in range (0, %s)", TokenRefreshTime)
+		}
+		return nil
+	}
+}
+
+// withDefaultRateLimit returns a VMSConfigFunc that sets default rate limit if none is provided.
+// Zero rate keeps rate limiting disabled unless set by user. Burst defaults to 1 when rate limiting is enabled.
+func withDefaultRateLimit(requestsPerSecond float64, burst int) VMSConfigFunc {
+	return func(config *VMSConfig) error {
+		if config.RequestsPerSecond == 0 {
+			config.RequestsPerSecond = requestsPerSecond
+		}
+		if config.Burst == 0 {
+			config.Burst = burst
+		}
+		if config.RequestsPerSecond < 0 || config.Burst < 0 {
+			return errors.New("requests per second and burst must not be negative")
+		}
+		if config.RequestsPerSecond > 0 && config.Burst == 0 {
+			config.Burst = 1
+		}
+		return nil
+	}
+}
+
+// withDefaultHeaders checks that DefaultHeaders don't override reserved headers.
+func withDefaultHeaders(config *VMSConfig) error {
+	return checkReservedHeaders(config.DefaultHeaders)
+}
+
+// withProxyURL validates ProxyURL.
+func withProxyURL(config *VMSConfig) error {
+	if config.ProxyURL == "" {
+		return nil
+	}
+	parsed, err := url.Parse(config.ProxyURL)
+	if err != nil {
+		return fmt.Errorf("invalid proxy url %q: %w", config.ProxyURL, err)
+	}
+	switch parsed.Scheme {
+	case "http", "https", "socks5":
+	default:
+		return fmt.Errorf("invalid proxy url %q: scheme must be http, https or socks5", config.ProxyURL)
+	}
+	if parsed.Hostname() == "" {
+		return fmt.Errorf("invalid proxy url %q: host is missing", config.ProxyURL)
+	}
+	return nil
+}
+
+// applyBaseUrl validates BaseURL and fills Host and Port from it.
+func applyBaseUrl(config *VMSConfig) error {
+	parsed, err := url.Parse(config.BaseURL)
+	if err != nil {
+		return fmt.Errorf("invalid base url %q: %w", config.BaseURL, err)
+	}
+	if parsed.Scheme != "http" && parsed.Scheme != "https" {
+		return fmt.Errorf("invalid base url %q: scheme must be http or https", config.BaseURL)
+	}
+	if parsed.Hostname() == "" {
+		return fmt.Errorf("invalid base url %q: host is missing", config.BaseURL)
+	}
+	if parsed.RawQuery != "" || parsed.Fragment != "" {
+		return fmt.Errorf("invalid base url %q: query and fragment are not allowed", config.BaseURL)
+	}
+	config.Host = parsed.Hostname()
+	switch {
+	case parsed.Port() != "":
+		if config.Port, err = strconv.ParseUint(parsed.Port(), 10, 16); err != nil {
+			return fmt.Errorf("invalid base url %q: %w", config.BaseURL, err)
+		}
+	case parsed.Scheme == "http":
+		config.Port = 80
+	default:
+		config.Port = 443
+	}
+	return nil
+}
+
+// withReadOnly enables read-only guardrail.
+func withReadOnly(config *VMSConfig) error {
+	config.ReadOnly = true
+	return nil
+}
+
+// withDefaultRetry returns a VMSConfigFunc that sets default retry policy if none is provided.
+func withDefaultRetry(policy RetryPolicy) VMSConfigFunc {
+	return func(config *VMSConfig) error {
+		if config.Retry == nil {
+			config.Retry = &policy
+		}
+		return nil
+	}
+}
+
+// withDefaultTimeouts returns a VMSConfigFunc that sets class timeouts which are not provided.
+func withDefaultTimeouts(timeouts Timeouts) VMSConfigFunc {
+	return func(config *VMSConfig) error {
+		if config.Timeouts.Read == 0 {
+			config.Timeouts.Read = timeouts.Read
+		}
+		if config.Timeouts.Write == 0 {
+			config.Timeouts.Write = timeouts.Write
+		}
+		if config.Timeouts.LongRunning == 0 {
+			config.Timeouts.LongRunning = timeouts.LongRunning
+		}
+		return nil
+	}
+}
+
+// WithConfig returns option which modifies config with fn (e.g. to override any value set by preset
+// of NewForAutomation, NewForTests or NewReadOnly).
+func WithConfig(fn func(config *VMSConfig)) VMSConfigFunc {
+	return func(config *VMSConfig) error {
+		fn(config)
+		return nil
+	}
+}
+
+// WithRetry returns option which sets retry policy of failed GET requests (see VMSConfig.Retry).
+func WithRetry(policy RetryPolicy) VMSConfigFunc {
+	return WithConfig(func(config *VMSConfig) { config.Retry = &policy })
+}
+
+// WithTimeouts returns option which sets per-request timeouts (see VMSConfig.Timeouts).
+func WithTimeouts(timeouts Timeouts) VMSConfigFunc {
+	return WithConfig(func(config *VMSConfig) { config.Timeouts = timeouts })
+}
+
+// WithRateLimit returns option which sets client-side rate limit (see VMSConfig.RequestsPerSecond).
+// Zero requestsPerSecond disables rate limiting.
+func WithRateLimit(requestsPerSecond float64, burst int) VMSConfigFunc {
+	return WithConfig(func(config *VMSConfig) { config.RequestsPerSecond, config.Burst = requestsPerSecond, burst })
+}
+
+// WithMaxConnections returns option which sets maximum number of concurrent HTTP connections.
+func WithMaxConnections(maxConnections int) VMSConfigFunc {
+	return WithConfig(func(config *VMSConfig) { config.MaxConnections = maxConnections })
+}
+
+// withRedactErrorBodies enables redaction of error response bodies.
+func withRedactErrorBodies(config *VMSConfig) error {
+	config.RedactErrorBodies = true
+	return nil
+}
+
+// withTokenPreRefresh enables background token refresh.
+func withTokenPreRefresh(config *VMSConfig) error {
+	config.TokenPreRefresh = true
+	return nil
+}
+
 // witAPIVersion sets a default API version
 // NOTE: API version can be overwritten for particular VastResource
 func witApiVersion(defaultVer string) VMSConfigFunc {
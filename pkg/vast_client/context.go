@@ -0,0 +1,233 @@
+package vast_client
+
+import (
+	"context"
+	"net/http"
+	"time"
+)
+
+type expectedMatchesCtxKey struct{}
+
+// WithExpectedMatches returns context that makes Delete assert that provided params match exactly n resources
+// before anything is modified. If number of matches differs AmbiguousMatchError is returned.
+// n must not be negative, otherwise Delete fails without sending any request.
+// Assertion applies only to the outermost Delete: requests made by it (including nested Delete calls
+// from interceptors or composite operations such as Tenant.DeleteSafely) don't see it.
+func WithExpectedMatches(ctx context.Context, n int) context.Context {
+	return context.WithValue(ctx, expectedMatchesCtxKey{}, n)
+}
+
+// expectedMatchesFromContext returns number of expected matches set by WithExpectedMatches (if any)
+func expectedMatchesFromContext(ctx context.Context) (int, bool) {
+	n, ok := ctx.Value(expectedMatchesCtxKey{}).(int)
+	return n, ok
+}
+
+// withoutExpectedMatches returns context which doesn't carry number of expected matches set by WithExpectedMatches.
+func withoutExpectedMatches(ctx context.Context) context.Context {
+	if _, ok := expectedMatchesFromContext(ctx); !ok {
+		return ctx
+	}
+	return context.WithValue(ctx, expectedMatchesCtxKey{}, nil)
+}
+
+type headersCtxKey struct{}
+
+// WithHeaders returns context that makes requests made with it carry provided extra headers.
+// Headers are merged with headers set by outer WithHeaders calls and VMSConfig.DefaultHeaders (inner values win).
+// Reserved headers (Authorization, Content-Type) cannot be set; such requests fail with error.
+func WithHeaders(ctx context.Context, headers map[string]string) context.Context {
+	merged := make(map[string]string)
+	for key, value := range headersFromContext(ctx) {
+		merged[http.CanonicalHeaderKey(key)] = value
+	}
+	for key, value := range headers {
+		merged[http.CanonicalHeaderKey(key)] = value
+	}
+	return context.WithValue(ctx, headersCtxKey{}, merged)
+}
+
+// headersFromContext returns extra headers set by WithHeaders (if any)
+func headersFromContext(ctx context.Context) map[string]string {
+	headers, _ := ctx.Value(headersCtxKey{}).(map[string]string)
+	return headers
+}
+
+type interceptorHeadersCtxKey struct{}
+
+// withInterceptorHeaders returns context carrying headers added by before request interceptors (see RequestInfo).
+func withInterceptorHeaders(ctx context.Context, headers http.Header) context.Context {
+	return context.WithValue(ctx, interceptorHeadersCtxKey{}, headers)
+}
+
+// interceptorHeadersFromContext returns headers set by withInterceptorHeaders (if any)
+func interceptorHeadersFromContext(ctx context.Context) http.Header {
+	headers, _ := ctx.Value(interceptorHeadersCtxKey{}).(http.Header)
+	return headers
+}
+
+type requestIdCtxKey struct{}
+
+// WithRequestID returns context which makes request carry provided identifier in X-Request-Id header.
+// If not set, random identifier is generated for each request.
+func WithRequestID(ctx context.Context, id string) context.Context {
+	return context.WithValue(ctx, requestIdCtxKey{}, id)
+}
+
+// requestIdFromContext returns identifier set by WithRequestID (if any)
+func requestIdFromContext(ctx context.Context) (string, bool) {
+	id, ok := ctx.Value(requestIdCtxKey{}).(string)
+	return id, ok && id != ""
+}
+
+type longRunningCtxKey struct{}
+
+// AsLongRunning marks context so requests made with it use VMSConfig.Timeouts.LongRunning
+// instead of Read/Write timeout.
+func AsLongRunning(ctx context.Context) context.Context {
+	return context.WithValue(ctx, longRunningCtxKey{}, true)
+}
+
+// isLongRunning reports whether context is marked with AsLongRunning
+func isLongRunning(ctx context.Context) bool {
+	longRunning, _ := ctx.Value(longRunningCtxKey{}).(bool)
+	return longRunning
+}
+
+type retryPolicyCtxKey struct{}
+
+// WithRetryPolicy returns context which makes requests made with it use provided retry policy
+// instead of policy of resource or VMSConfig.Retry (e.g. NoRetry for calls which should fail fast).
+func WithRetryPolicy(ctx context.Context, policy RetryPolicy) context.Context {
+	return context.WithValue(ctx, retryPolicyCtxKey{}, policy)
+}
+
+// retryPolicyFromContext returns retry policy set by WithRetryPolicy (if any)
+func retryPolicyFromContext(ctx context.Context) (RetryPolicy, bool) {
+	policy, ok := ctx.Value(retryPolicyCtxKey{}).(RetryPolicy)
+	return policy, ok
+}
+
+type requestTimeoutCtxKey struct{}
+
+// WithRequestTimeout returns context which makes every request made with it limited by timeout
+// (including retries) instead of VMSConfig.Timeouts and VMSConfig.RequestTimeout.
+// Unlike context.WithTimeout, timeout applies to each request separately (e.g. to each page of List).
+// Deadline of context is never extended: the earlier of the two applies.
+func WithRequestTimeout(ctx context.Context, timeout time.Duration) context.Context {
+	return context.WithValue(ctx, requestTimeoutCtxKey{}, timeout)
+}
+
+// requestTimeoutFromContext returns timeout set by WithRequestTimeout (if any)
+func requestTimeoutFromContext(ctx context.Context) (time.Duration, bool) {
+	timeout, ok := ctx.Value(requestTimeoutCtxKey{}).(time.Duration)
+	return timeout, ok
+}
+
+type apiVersionCtxKey struct{}
+
+// WithApiVersion returns context which makes requests made with it use provided API version (e.g. "v4" or "latest")
+// instead of version of resource (see SetApiVersion) or VMSConfig.ApiVersion.
+func WithApiVersion(ctx context.Context, apiVersion string) context.Context {
+	return context.WithValue(ctx, apiVersionCtxKey{}, apiVersion)
+}
+
+// apiVersionFromContext returns API version set by WithApiVersion (if any)
+func apiVersionFromContext(ctx context.Context) (string, bool) {
+	apiVersion, ok := ctx.Value(apiVersionCtxKey{}).(string)
+	return apiVersion, ok && apiVersion != ""
+}
+
+// withVerbTimeout applies timeout set by WithRequestTimeout or timeout from VMSConfig.Timeouts according
+// to verb class falling back to VMSConfig.RequestTimeout (except for long-running requests).
+// Deadline set by caller is never extended: effective deadline is the earlier of caller deadline and timeout
+// (e.g. GET made inside WaitForState fails after read timeout instead of waiting for the whole poll budget).
+// Returns applied timeout (zero if no timeout is applied or caller deadline is earlier).
+func withVerbTimeout(ctx context.Context, config *VMSConfig, verb string) (context.Context, context.CancelFunc, time.Duration) {
+	var timeout time.Duration
+	ctxTimeout, hasCtxTimeout := requestTimeoutFromContext(ctx)
+	switch {
+	case hasCtxTimeout:
+		timeout = ctxTimeout
+	case isLongRunning(ctx):
+		timeout = config.Timeouts.LongRunning
+	case verb == http.MethodGet:
+		timeout = config.Timeouts.Read
+	default:
+		timeout = config.Timeouts.Write
+	}
+	if timeout <= 0 && !isLongRunning(ctx) && !hasCtxTimeout {
+		timeout = config.RequestTimeout
+	}
+	if timeout <= 0 {
+		return ctx, func() {}, 0
+	}
+	if deadline, ok := ctx.Deadline(); ok && time.Until(deadline) <= timeout {
+		// Caller deadline comes first so timeout of request is caller's one
+		return ctx, func() {}, 0
+	}
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	return ctx, cancel, timeout
+}
+
+type pageCaptureCtxKey struct{}
+
+// withPageCapture returns context that makes request store pagination metadata of list response into page.
+func withPageCapture(ctx context.Context, page *pageInfo) context.Context {
+	return context.WithValue(ctx, pageCaptureCtxKey{}, page)
+}
+
+// pageCaptureFromContext returns pageInfo set by withPageCapture (if any)
+func pageCaptureFromContext(ctx context.Context) *pageInfo {
+	page, _ := ctx.Value(pageCaptureCtxKey{}).(*pageInfo)
+	return page
+}
+
+type responseMetaCtxKey struct{}
+
+// CaptureResponseMeta returns context that makes requests made with it store response metadata
+// (status code, headers, duration, request id) into meta. Metadata is stored for failed requests as well.
+// If call makes several requests (e.g. List fetching all pages) meta describes the last one.
+//
+// Example:
+//
+//	var meta client.ResponseInfo
+//	views, err := rest.Views.List(client.CaptureResponseMeta(ctx, &meta), nil)
+//	total := meta.Header.Get("X-Total-Count")
+func CaptureResponseMeta(ctx context.Context, meta *ResponseInfo) context.Context {
+	return context.WithValue(ctx, responseMetaCtxKey{}, meta)
+}
+
+// responseMetaFromContext returns ResponseInfo set by CaptureResponseMeta (if any)
+func responseMetaFromContext(ctx context.Context) *ResponseInfo {
+	meta, _ := ctx.Value(responseMetaCtxKey{}).(*ResponseInfo)
+	return meta
+}
+
+type multipartCtxKey struct{}
+
+// asMultipart marks context so request body is sent as multipart/form-data instead of JSON (see Params.ToMultipartBody).
+func asMultipart(ctx context.Context) context.Context {
+	return context.WithValue(ctx, multipartCtxKey{}, true)
+}
+
+// isMultipart reports whether context is marked with asMultipart
+func isMultipart(ctx context.Context) bool {
+	multipart, _ := ctx.Value(multipartCtxKey{}).(bool)
+	return multipart
+}
+
+type contentTypeCtxKey struct{}
+
+// withContentType overrides Content-Type header of request (application/json by default).
+func withContentType(ctx context.Context, contentType string) context.Context {
+	return context.WithValue(ctx, contentTypeCtxKey{}, contentType)
+}
+
+// contentTypeFromContext returns Content-Type of request set by withContentType or application/json.
+func contentTypeFromContext(ctx context.Context) string {
+	if contentType, ok := ctx.Value(contentTypeCtxKey{}).(string); ok && contentType != "" {
+		return contentType
+	}
+	return ApplicationJson
+}
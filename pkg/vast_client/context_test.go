@@ -0,0 +1,168 @@
+package vast_client
+
+import (
+	"context"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestWithVerbTimeout(t *testing.T) {
+	config := &VMSConfig{
+		Timeouts:       Timeouts{Read: 5 * time.Second, Write: time.Minute, LongRunning: 10 * time.Minute},
+		RequestTimeout: 5 * time.Minute,
+	}
+	tests := []struct {
+		name           string
+		config         *VMSConfig
+		ctx            func() (context.Context, context.CancelFunc)
+		verb           string
+		want           time.Duration // Applied timeout
+		wantDeadlineIn time.Duration // Expected time until deadline (zero if no deadline)
+	}{
+		{name: "read", config: config, verb: http.MethodGet, want: 5 * time.Second, wantDeadlineIn: 5 * time.Second},
+		{name: "write", config: config, verb: http.MethodPost, want: time.Minute, wantDeadlineIn: time.Minute},
+		{name: "delete is write", config: config, verb: http.MethodDelete, want: time.Minute, wantDeadlineIn: time.Minute},
+		{
+			name: "long running", config: config, verb: http.MethodGet, want: 10 * time.Minute, wantDeadlineIn: 10 * time.Minute,
+			ctx: func() (context.Context, context.CancelFunc) { return AsLongRunning(context.Background()), func() {} },
+		},
+		{
+			name: "per-call timeout", config: config, verb: http.MethodPost, want: time.Second, wantDeadlineIn: time.Second,
+			ctx: func() (context.Context, context.CancelFunc) {
+				return WithRequestTimeout(context.Background(), time.Second), func() {}
+			},
+		},
+		{name: "fallback to request timeout", config: &VMSConfig{RequestTimeout: time.Minute}, verb: http.MethodGet, want: time.Minute, wantDeadlineIn: time.Minute},
+		{name: "no timeout", config: &VMSConfig{}, verb: http.MethodGet},
+		{
+			name: "earlier caller deadline is kept", config: config, verb: http.MethodGet, wantDeadlineIn: time.Second,
+			ctx: func() (context.Context, context.CancelFunc) {
+				return context.WithTimeout(context.Background(), time.Second)
+			},
+		},
+		{
+			name: "caller deadline is never extended", config: config, verb: http.MethodPost, wantDeadlineIn: 30 * time.Second,
+			ctx: func() (context.Context, context.CancelFunc) {
+				return context.WithTimeout(WithRequestTimeout(context.Background(), time.Minute), 30*time.Second)
+			},
+		},
+		{
+			name: "later caller deadline is shortened", config: config, verb: http.MethodGet, want: 5 * time.Second, wantDeadlineIn: 5 * time.Second,
+			ctx: func() (context.Context, context.CancelFunc) {
+				return context.WithTimeout(context.Background(), 2*time.Minute)
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx, cancelCaller := context.Background(), context.CancelFunc(func() {})
+			if tt.ctx != nil {
+				ctx, cancelCaller = tt.ctx()
+			}
+			defer cancelCaller()
+
+			ctx, cancel, timeout := withVerbTimeout(ctx, tt.config, tt.verb)
+			defer cancel()
+
+			assert.Equal(t, tt.want, timeout)
+			deadline, ok := ctx.Deadline()
+			if tt.wantDeadlineIn == 0 {
+				assert.False(t, ok)
+				return
+			}
+			require.True(t, ok)
+			assert.InDelta(t, tt.wantDeadlineIn, time.Until(deadline), float64(100*time.Millisecond))
+		})
+	}
+}
+
+func TestReadTimeoutAppliesInsidePoll(t *testing.T) {
+	srv := newTestServer(t, func(w http.ResponseWriter, r *http.Request) {
+		// Hung request
+		select {
+		case <-r.Context().Done():
+		case <-time.After(5 * time.Second):
+		}
+	})
+	config := srv.config()
+	config.Timeouts.Read = 50 * time.Millisecond
+	rest := newTestRest(t, config)
+
+	started := time.Now()
+	_, err := rest.Views.WaitForState(context.Background(), 1, "state", []string{"READY"}, nil, PollOptions{Timeout: time.Minute})
+
+	var timeoutErr *ClientTimeoutError
+	require.ErrorAs(t, err, &timeoutErr)
+	assert.Equal(t, 50*time.Millisecond, timeoutErr.Timeout)
+	assert.Less(t, time.Since(started), 5*time.Second)
+}
+
+func TestRequestID(t *testing.T) {
+	tests := []struct {
+		name     string
+		ctxId    string // Set with WithRequestID if not empty
+		serverId string // Echoed by server in response header if not empty
+	}{
+		{name: "generated"},
+		{name: "from context", ctxId: "my-request"},
+		{name: "echoed by server", ctxId: "my-request", serverId: "vms-request"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			srv := newTestServer(t, func(w http.ResponseWriter, r *http.Request) {
+				if tt.serverId != "" {
+					w.Header().Set(RequestIdHeader, tt.serverId)
+				}
+				writeTestJSON(w, http.StatusInternalServerError, map[string]string{"detail": "boom"})
+			})
+			config := srv.config()
+			var beforeId string
+			config.BeforeRequestFnV2 = func(ctx context.Context, info *RequestInfo) error {
+				beforeId = info.RequestID
+				return nil
+			}
+			rest := newTestRest(t, config)
+			ctx := context.Background()
+			if tt.ctxId != "" {
+				ctx = WithRequestID(ctx, tt.ctxId)
+			}
+
+			_, err := rest.Views.Create(ctx, Params{"path": "/v", "policy_id": 1})
+
+			var apiErr *ApiError
+			require.ErrorAs(t, err, &apiErr)
+			sent := srv.Requests()[0].Header.Get(RequestIdHeader)
+			require.NotEmpty(t, sent)
+			if tt.ctxId != "" {
+				assert.Equal(t, tt.ctxId, sent)
+			} else {
+				assert.Regexp(t, `^[0-9a-f]{8}-[0-9a-f]{4}-4[0-9a-f]{3}-[89ab][0-9a-f]{3}-[0-9a-f]{12}$`, sent)
+			}
+			assert.Equal(t, sent, beforeId)
+			want := sent
+			if tt.serverId != "" {
+				want = tt.serverId
+			}
+			assert.Equal(t, want, apiErr.RequestID)
+			assert.Contains(t, err.Error(), "request id "+want)
+		})
+	}
+}
+
+func TestRequestIDIsUniquePerRequest(t *testing.T) {
+	srv := newTestServer(t, recordsHandler())
+	rest := newTestRest(t, srv.config())
+
+	for i := 0; i < 2; i++ {
+		_, err := rest.Views.List(context.Background(), nil)
+		require.NoError(t, err)
+	}
+
+	requests := srv.Requests()
+	require.Len(t, requests, 2)
+	assert.NotEqual(t, requests[0].Header.Get(RequestIdHeader), requests[1].Header.Get(RequestIdHeader))
+}
@@ -0,0 +1,77 @@
+package vast_client
+
+import (
+	"context"
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCount(t *testing.T) {
+	tests := []struct {
+		name    string
+		handler http.HandlerFunc
+		want    int64
+	}{
+		{
+			name: "paginated envelope count",
+			handler: func(w http.ResponseWriter, r *http.Request) {
+				writeTestJSON(w, http.StatusOK, map[string]any{"count": 42, "next": "page2", "results": []map[string]any{{"id": 1}}})
+			},
+			want: 42,
+		},
+		{
+			name:    "not paginated response",
+			handler: recordsHandler(map[string]any{"id": 1}, map[string]any{"id": 2}, map[string]any{"id": 3}),
+			want:    3,
+		},
+		{
+			name:    "nothing matched",
+			handler: recordsHandler(),
+			want:    0,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			srv := newTestServer(t, tt.handler)
+			rest := newTestRest(t, srv.config())
+
+			count, err := rest.Quotas.Count(context.Background(), Params{"tenant_id": 1})
+
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, count)
+			requests := srv.Requests()
+			require.Len(t, requests, 1, "count is resolved with single round trip")
+			assert.Equal(t, "1", requests[0].Query.Get("page_size"))
+			assert.Equal(t, "id", requests[0].Query.Get("fields"))
+			assert.Equal(t, "1", requests[0].Query.Get("tenant_id"))
+		})
+	}
+}
+
+func TestExists(t *testing.T) {
+	tests := []struct {
+		name    string
+		records []map[string]any
+		want    bool
+	}{
+		{name: "exists", records: []map[string]any{{"id": 1}}, want: true},
+		{name: "does not exist", want: false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			srv := newTestServer(t, recordsHandler(tt.records...))
+			rest := newTestRest(t, srv.config())
+
+			exists, err := rest.Views.Exists(context.Background(), Params{"name": "v"})
+
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, exists)
+			requests := srv.Requests()
+			require.Len(t, requests, 1)
+			assert.Equal(t, "1", requests[0].Query.Get("page_size"))
+		})
+	}
+}
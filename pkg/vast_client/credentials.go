@@ -0,0 +1,65 @@
+package vast_client
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+)
+
+// CredentialsProvider supplies credentials on demand (e.g. from secret manager which rotates them).
+// When set on VMSConfig it is consulted every time new JWT token must be acquired (or every minute for api token),
+// so rotated credentials are picked up without recreating client. Provider returns either username/password or api token.
+// Authentication method is chosen by credentials returned on first request.
+type CredentialsProvider interface {
+	Credentials(ctx context.Context) (username, password, apiToken string, err error)
+}
+
+// StaticCredentials is CredentialsProvider which returns fixed credentials
+// (same as setting VMSConfig.Username/Password or VMSConfig.ApiToken).
+type StaticCredentials struct {
+	Username string
+	Password string
+	ApiToken string
+}
+
+func (c StaticCredentials) Credentials(context.Context) (string, string, string, error) {
+	return c.Username, c.Password, c.ApiToken, nil
+}
+
+// FileCredentials is CredentialsProvider which reads credentials from JSON file on every call,
+// e.g. {"username": "admin", "password": "..."} or {"api_token": "..."}.
+type FileCredentials struct {
+	Path string
+}
+
+func (c FileCredentials) Credentials(context.Context) (string, string, string, error) {
+	raw, err := os.ReadFile(c.Path)
+	if err != nil {
+		return "", "", "", fmt.Errorf("failed to read credentials: %w", err)
+	}
+	var creds struct {
+		Username string `json:"username"`
+		Password string `json:"password"`
+		ApiToken string `json:"api_token"`
+	}
+	if err = json.Unmarshal(raw, &creds); err != nil {
+		return "", "", "", fmt.Errorf("failed to parse credentials file %s: %w", c.Path, err)
+	}
+	return creds.Username, creds.Password, creds.ApiToken, nil
+}
+
+// credentials returns username/password or api token from VMSConfig.Credentials (if set) or from config fields.
+func credentials(ctx context.Context, config *VMSConfig) (username, password, apiToken string, err error) {
+	if config.Credentials == nil {
+		return config.Username, config.Password, config.ApiToken, nil
+	}
+	if username, password, apiToken, err = config.Credentials.Credentials(ctx); err != nil {
+		return "", "", "", fmt.Errorf("failed to get credentials: %w", err)
+	}
+	if (username == "" || password == "") && apiToken == "" {
+		return "", "", "", errors.New("credentials provider returned neither username/password nor api token")
+	}
+	return username, password, apiToken, nil
+}
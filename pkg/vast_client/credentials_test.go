@@ -0,0 +1,204 @@
+package vast_client
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// countingCredentials is CredentialsProvider which counts calls and returns credentials set by set.
+type countingCredentials struct {
+	mu       sync.Mutex
+	calls    int
+	username string
+	password string
+	apiToken string
+	err      error
+}
+
+func (c *countingCredentials) Credentials(context.Context) (string, string, string, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.calls++
+	return c.username, c.password, c.apiToken, c.err
+}
+
+func (c *countingCredentials) set(username, password, apiToken string, err error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.username, c.password, c.apiToken, c.err = username, password, apiToken, err
+}
+
+func (c *countingCredentials) Calls() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.calls
+}
+
+// writeCredentialsFile writes credentials JSON file used by FileCredentials.
+func writeCredentialsFile(t *testing.T, path string, creds map[string]string) {
+	t.Helper()
+	raw, err := json.Marshal(creds)
+	require.NoError(t, err)
+	require.NoError(t, os.WriteFile(path, raw, 0o600))
+}
+
+// passwordServer emulates VMS which issues JWT tokens only for current password of "admin" user
+// and never refreshes tokens (so every renewal acquires new token with password).
+type passwordServer struct {
+	*httptest.Server
+	password     atomic.Value
+	acquisitions atomic.Int32
+}
+
+func newPasswordServer(t *testing.T, password string) *passwordServer {
+	srv := &passwordServer{}
+	srv.password.Store(password)
+	srv.Server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch resourceFromPath(r.URL.Path) {
+		case "token":
+			var creds map[string]string
+			_ = json.NewDecoder(r.Body).Decode(&creds)
+			if creds["username"] != "admin" || creds["password"] != srv.password.Load() {
+				writeTestJSON(w, http.StatusUnauthorized, map[string]string{"detail": "invalid credentials"})
+				return
+			}
+			n := srv.acquisitions.Add(1)
+			writeTestJSON(w, http.StatusOK, map[string]string{"access": fmt.Sprintf("access-%d", n), "refresh": "refresh"})
+		case "token/refresh":
+			writeTestJSON(w, http.StatusUnauthorized, map[string]string{"detail": "token is expired"})
+		default:
+			writeTestJSON(w, http.StatusOK, []map[string]any{})
+		}
+	}))
+	t.Cleanup(srv.Close)
+	return srv
+}
+
+// expireToken makes JWT token of rest expired so next request renews it.
+func expireToken(t *testing.T, rest *VMSRest) {
+	session := rest.Session.(*VMSSession)
+	auth, ok := resolvedAuthenticator(session.auth).(*JWTAuthenticator)
+	require.True(t, ok)
+	session.Lock()
+	defer session.Unlock()
+	auth.Token.CreatedAt = time.Now().Add(-TokenRefreshTime)
+}
+
+func TestCredentialsProviderIsConsultedLazily(t *testing.T) {
+	srv := newTestServer(t, recordsHandler())
+	provider := &countingCredentials{err: errors.New("secret manager is unavailable")}
+	rest := newTestRest(t, &VMSConfig{BaseURL: srv.URL, Credentials: provider})
+	assert.Equal(t, 0, provider.Calls(), "provider is not called on client creation")
+
+	_, err := rest.Views.List(context.Background(), nil)
+	assert.ErrorContains(t, err, "secret manager is unavailable")
+	assert.Empty(t, srv.Requests())
+
+	// Mode is chosen once provider returns credentials
+	provider.set("", "", "token-1", nil)
+	_, err = rest.Views.List(context.Background(), nil)
+	require.NoError(t, err)
+	assert.Equal(t, "Api-Token token-1", srv.Requests()[0].Header.Get("Authorization"))
+}
+
+func TestFileCredentialsRotationJWT(t *testing.T) {
+	srv := newPasswordServer(t, "old-password")
+	path := filepath.Join(t.TempDir(), "credentials.json")
+	writeCredentialsFile(t, path, map[string]string{"username": "admin", "password": "old-password"})
+	rest := newTestRest(t, &VMSConfig{BaseURL: srv.URL, Credentials: FileCredentials{Path: path}})
+	ctx := context.Background()
+
+	_, err := rest.Views.List(ctx, nil)
+	require.NoError(t, err)
+
+	// Password is rotated in VMS and in secret file mid-session
+	srv.password.Store("new-password")
+	writeCredentialsFile(t, path, map[string]string{"username": "admin", "password": "new-password"})
+	expireToken(t, rest)
+
+	_, err = rest.Views.List(ctx, nil)
+	require.NoError(t, err)
+	assert.Equal(t, int32(2), srv.acquisitions.Load())
+
+	access, _, _, err := rest.AuthToken(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, "access-2", access)
+}
+
+func TestCredentialsProviderRotationApiToken(t *testing.T) {
+	srv := newTestServer(t, recordsHandler())
+	provider := &countingCredentials{apiToken: "token-1"}
+	rest := newTestRest(t, &VMSConfig{BaseURL: srv.URL, Credentials: provider})
+	session := rest.Session.(*VMSSession)
+	ctx := context.Background()
+	expireApiToken := func() {
+		auth := resolvedAuthenticator(session.auth).(*ApiRTokenAuthenticator)
+		auth.mu.Lock()
+		defer auth.mu.Unlock()
+		auth.fetchedAt = time.Now().Add(-credentialsCacheTTL)
+	}
+	authorizations := func() []string {
+		var values []string
+		for _, r := range srv.Requests() {
+			values = append(values, r.Header.Get("Authorization"))
+		}
+		return values
+	}
+
+	for range 3 {
+		_, err := rest.Views.List(ctx, nil)
+		require.NoError(t, err)
+	}
+	assert.Equal(t, 1, provider.Calls(), "api token is cached")
+
+	provider.set("", "", "token-2", nil)
+	expireApiToken()
+	_, err := rest.Views.List(ctx, nil)
+	require.NoError(t, err)
+
+	provider.set("", "", "", errors.New("secret manager is unavailable"))
+	expireApiToken()
+	_, err = rest.Views.List(ctx, nil)
+	require.NoError(t, err, "cached token is used if provider fails")
+
+	assert.Equal(t, []string{
+		"Api-Token token-1", "Api-Token token-1", "Api-Token token-1", "Api-Token token-2", "Api-Token token-2",
+	}, authorizations())
+	assert.Equal(t, 3, provider.Calls())
+
+	_, _, _, err = rest.AuthToken(ctx)
+	assert.ErrorContains(t, err, "requires username/password authentication")
+}
+
+func TestCredentialsProviderConcurrentFirstUse(t *testing.T) {
+	srv := newTestServer(t, recordsHandler())
+	provider := &countingCredentials{username: "admin", password: "123456"}
+	rest := newTestRest(t, &VMSConfig{BaseURL: srv.URL, Credentials: provider})
+
+	var wg sync.WaitGroup
+	for range 10 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			_, err := rest.Views.List(context.Background(), nil)
+			assert.NoError(t, err)
+		}()
+	}
+	wg.Wait()
+
+	assert.Equal(t, 1, srv.TokenRequests())
+	assert.Equal(t, 2, provider.Calls(), "provider is called to choose authentication method and to acquire token")
+}
@@ -0,0 +1,208 @@
+package vast_client
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"reflect"
+	"sort"
+	"strings"
+)
+
+// normalizeValue converts value to canonical JSON representation
+// (float64 for numbers, []any for slices, map[string]any for objects) so values of different Go types can be compared.
+func normalizeValue(v any) any {
+	raw, err := json.Marshal(v)
+	if err != nil {
+		return v
+	}
+	var normalized any
+	if err = json.Unmarshal(raw, &normalized); err != nil {
+		return v
+	}
+	return normalized
+}
+
+// valuesEqual compares two JSON-like values. Numbers are compared numerically regardless of Go type
+// (JSON float64 vs int). If setLike is true top-level slices are compared order-insensitively.
+func valuesEqual(a, b any, setLike bool) bool {
+	a, b = normalizeValue(a), normalizeValue(b)
+	if setLike {
+		aSlice, aOk := a.([]any)
+		bSlice, bOk := b.([]any)
+		if aOk && bOk {
+			return reflect.DeepEqual(sortedByJSON(aSlice), sortedByJSON(bSlice))
+		}
+	}
+	return reflect.DeepEqual(a, b)
+}
+
+// serverManagedKeys are keys assigned by VMS which are never compared by Diff.
+var serverManagedKeys = map[string]struct{}{
+	"id":            empty,
+	"created":       empty,
+	"guid":          empty,
+	resourceTypeKey: empty,
+}
+
+// Diff compares desired params against Record and returns subset of desired params whose values differ
+// from actual values, along with flag whether there is no difference at all.
+// Only keys present in desired are compared. Numbers are compared numerically (JSON float64 vs int),
+// set-like fields (protocols, ip_ranges etc.) are compared order-insensitively
+// and server-managed keys (id, created, guid, @resourceType) are ignored.
+func (r Record) Diff(desired Params) (changed Params, equal bool) {
+	changed = changedParams(r, desired)
+	return changed, len(changed) == 0
+}
+
+// changedParams returns subset of desired params whose values differ from values in record (see Record.Diff).
+func changedParams(record Record, desired Params) Params {
+	changed := Params{}
+	for key, value := range desired {
+		if _, ok := serverManagedKeys[key]; ok {
+			continue
+		}
+		if !valuesEqual(record[key], value, isSetLikeField(key)) {
+			changed[key] = value
+		}
+	}
+	return changed
+}
+
+// matchesParams reports whether record values are equal to params values.
+// Only keys present in record are compared. Query values are often passed as strings
+// so string representations are compared as well.
+func matchesParams(record Record, params Params) bool {
+	for key, value := range params {
+		actual, ok := record[key]
+		if !ok {
+			continue
+		}
+		if !valuesEqual(actual, value, isSetLikeField(key)) && fmt.Sprintf("%v", actual) != fmt.Sprintf("%v", value) {
+			return false
+		}
+	}
+	return true
+}
+
+// sortedByJSON returns copy of slice sorted by JSON representation of elements.
+func sortedByJSON(values []any) []any {
+	type keyed struct {
+		key   string
+		value any
+	}
+	items := make([]keyed, len(values))
+	for i, v := range values {
+		raw, _ := json.Marshal(v)
+		items[i] = keyed{key: string(raw), value: v}
+	}
+	sort.SliceStable(items, func(i, j int) bool { return items[i].key < items[j].key })
+	result := make([]any, len(items))
+	for i, item := range items {
+		result[i] = item.value
+	}
+	return result
+}
+
+// setLikeFields are fields whose values are compared order-insensitively.
+var setLikeFields = map[string]struct{}{
+	"protocols":        empty,
+	"ip_ranges":        empty,
+	"client_ip_ranges": empty,
+	"s3_policies_ids":  empty,
+	"permissions_list": empty,
+	"object_types":     empty,
+}
+
+// isSetLikeField reports whether field values should be compared order-insensitively.
+func isSetLikeField(field string) bool {
+	_, ok := setLikeFields[field]
+	return ok
+}
+
+// sortedKeys returns keys of params in sorted order.
+func sortedKeys(params Params) []string {
+	keys := make([]string, 0, len(params))
+	for key := range params {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// toFloat converts numeric value to float64.
+func toFloat(v any) (float64, bool) {
+	switch n := normalizeValue(v).(type) {
+	case float64:
+		return n, true
+	default:
+		return 0, false
+	}
+}
+
+// volatileKeys are keys whose values change without user modification (usage counters, sync state).
+// They are not compared by UpdateIfUnchanged unless passed as guard fields explicitly.
+var volatileKeys = map[string]struct{}{
+	"sync":              empty,
+	"sync_time":         empty,
+	"logical_capacity":  empty,
+	"physical_capacity": empty,
+	"used_capacity":     empty,
+	"used_inodes":       empty,
+	"used_effective":    empty,
+}
+
+// ConflictError is returned by UpdateIfUnchanged when resource was modified after expected version was read.
+type ConflictError struct {
+	Resource string
+	Id       int64
+	Fields   []string // Guard fields whose values differ from expected ones
+}
+
+func (e *ConflictError) Error() string {
+	if len(e.Fields) == 0 {
+		return fmt.Sprintf("resource '%s' with id %d was modified concurrently (precondition failed)", e.Resource, e.Id)
+	}
+	return fmt.Sprintf("resource '%s' with id %d was modified concurrently: fields changed: %s", e.Resource, e.Id, strings.Join(e.Fields, ", "))
+}
+
+// UpdateIfUnchanged patches resource with body only if it was not modified since expected record was read.
+// Resource is re-fetched and values of guardFields (or, if none given, all keys of expected except server-managed
+// and volatile ones) are compared with expected. If any differs ConflictError is returned and nothing is patched.
+// If VMS responds with ETag header it is sent back with If-Match so server rejects concurrent modification atomically.
+// NOTE: without ETag support there is a short race window between re-fetch and PATCH.
+func (e *VastResourceEntry) UpdateIfUnchanged(ctx context.Context, id int64, expected Record, body Params, guardFields ...string) (Record, error) {
+	var meta ResponseInfo
+	current, err := e.GetById(CaptureResponseMeta(ctx, &meta), id)
+	if err != nil {
+		return nil, err
+	}
+	if len(guardFields) == 0 {
+		for key := range expected {
+			_, managed := serverManagedKeys[key]
+			_, volatile := volatileKeys[key]
+			if !managed && !volatile {
+				guardFields = append(guardFields, key)
+			}
+		}
+		sort.Strings(guardFields)
+	}
+	var changed []string
+	for _, field := range guardFields {
+		if !valuesEqual(current[field], expected[field], isSetLikeField(field)) {
+			changed = append(changed, field)
+		}
+	}
+	if len(changed) > 0 {
+		return nil, &ConflictError{Resource: e.resourcePath, Id: id, Fields: changed}
+	}
+	if etag := meta.Header.Get("ETag"); etag != "" {
+		ctx = WithHeaders(ctx, map[string]string{"If-Match": etag})
+	}
+	result, err := e.Update(ctx, id, body)
+	if isApiErrWithStatus(err, http.StatusPreconditionFailed) {
+		return nil, &ConflictError{Resource: e.resourcePath, Id: id}
+	}
+	return result, err
+}
@@ -0,0 +1,120 @@
+package vast_client
+
+import (
+	"bytes"
+	"context"
+	"log/slog"
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDryRun(t *testing.T) {
+	tests := []struct {
+		name     string
+		call     func(ctx context.Context, rest *VMSRest) (Renderable, error)
+		wantEcho Record // Expected subset of synthetic Record (nil if call doesn't return Record)
+		wantLog  string
+	}{
+		{
+			name: "create",
+			call: func(ctx context.Context, rest *VMSRest) (Renderable, error) {
+				return rest.Views.Create(ctx, Params{"path": "/v", "policy_id": 1})
+			},
+			wantEcho: Record{"path": "/v", "policy_id": 1},
+			wantLog:  "verb=POST",
+		},
+		{
+			name: "update",
+			call: func(ctx context.Context, rest *VMSRest) (Renderable, error) {
+				return rest.Views.Update(ctx, 1, Params{"path": "/w"})
+			},
+			wantEcho: Record{"path": "/w"},
+			wantLog:  "verb=PATCH",
+		},
+		{
+			name: "replace",
+			call: func(ctx context.Context, rest *VMSRest) (Renderable, error) {
+				return rest.Views.Replace(ctx, 1, Params{"path": "/w"})
+			},
+			wantEcho: Record{"path": "/w"},
+			wantLog:  "verb=PUT",
+		},
+		{
+			name: "delete",
+			call: func(ctx context.Context, rest *VMSRest) (Renderable, error) {
+				return rest.Views.Delete(ctx, Params{"name": "v"})
+			},
+			wantLog: "verb=DELETE",
+		},
+		{
+			name: "delete async",
+			call: func(ctx context.Context, rest *VMSRest) (Renderable, error) {
+				return rest.Views.DeleteAsync(ctx, Params{"name": "v"})
+			},
+			wantLog: "verb=DELETE",
+		},
+		{
+			name: "ensure with drift update",
+			call: func(ctx context.Context, rest *VMSRest) (Renderable, error) {
+				return rest.Views.EnsureByParams(ctx, Params{"name": "v"}, Params{"path": "/w"}, EnsureOptions{UpdateOnDrift: true})
+			},
+			wantEcho: Record{"path": "/w"},
+			wantLog:  "verb=PATCH",
+		},
+		{
+			name: "endpoint responding with task",
+			call: func(ctx context.Context, rest *VMSRest) (Renderable, error) {
+				return rest.BlockHostMappings.Map(ctx, 1, 2)
+			},
+			wantEcho: Record{},
+			wantLog:  "verb=PATCH",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			srv := newTestServer(t, recordsHandler(map[string]any{"id": 1, "name": "v", "path": "/v"}))
+			config := srv.config()
+			config.DryRun = true
+			var logs bytes.Buffer
+			config.Logger = slog.New(slog.NewTextHandler(&logs, nil))
+			rest := newTestRest(t, config)
+
+			result, err := tt.call(context.Background(), rest)
+
+			require.NoError(t, err)
+			for _, r := range srv.Requests() {
+				assert.Equal(t, http.MethodGet, r.Method, "mutating request is sent in dry-run mode")
+			}
+			if tt.wantEcho != nil {
+				record, ok := result.(Record)
+				require.True(t, ok, "got %T", result)
+				assert.Equal(t, true, record[DryRunKey])
+				for key, value := range tt.wantEcho {
+					assert.Equal(t, value, record[key], key)
+				}
+			}
+			assert.Contains(t, logs.String(), "vast dry-run request (not sent)")
+			assert.Contains(t, logs.String(), tt.wantLog)
+		})
+	}
+}
+
+func TestDryRunRedactsLoggedBody(t *testing.T) {
+	srv := newTestServer(t, recordsHandler())
+	config := srv.config()
+	config.DryRun = true
+	var logs bytes.Buffer
+	config.Logger = slog.New(slog.NewTextHandler(&logs, nil))
+	rest := newTestRest(t, config)
+
+	record, err := rest.Users.Create(context.Background(), Params{"name": "u", "uid": 1001, "password": "secret"})
+
+	require.NoError(t, err)
+	assert.Empty(t, srv.Requests())
+	assert.Equal(t, "u", record["name"])
+	assert.Contains(t, logs.String(), "request_body")
+	assert.NotContains(t, logs.String(), "secret")
+}
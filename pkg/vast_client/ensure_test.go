@@ -0,0 +1,93 @@
+package vast_client
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestEnsureByParams(t *testing.T) {
+	existing := map[string]any{"id": 5, "path": "/data", "tenant_id": 1, "hard_limit": 1000}
+	tests := []struct {
+		name        string
+		records     []map[string]any
+		body        Params
+		opts        EnsureOptions
+		wantStatus  EnsureStatus
+		wantCalls   []string
+		wantPayload map[string]any
+		wantDrift   Params
+	}{
+		{
+			name:        "create",
+			body:        Params{"hard_limit": 1000},
+			wantStatus:  EnsureCreated,
+			wantCalls:   []string{"GET quotas", "POST quotas"},
+			wantPayload: map[string]any{"path": "/data", "tenant_id": float64(1), "hard_limit": float64(1000)},
+		},
+		{
+			name:       "no-op without update on drift",
+			records:    []map[string]any{existing},
+			body:       Params{"hard_limit": 2000},
+			wantStatus: EnsureUnchanged,
+			wantCalls:  []string{"GET quotas"},
+		},
+		{
+			name:       "no-op when nothing drifted",
+			records:    []map[string]any{existing},
+			body:       Params{"hard_limit": 1000},
+			opts:       EnsureOptions{UpdateOnDrift: true},
+			wantStatus: EnsureUnchanged,
+			wantCalls:  []string{"GET quotas"},
+		},
+		{
+			name:        "drift update patches only changed keys",
+			records:     []map[string]any{existing},
+			body:        Params{"hard_limit": 2000, "tenant_id": 1},
+			opts:        EnsureOptions{UpdateOnDrift: true},
+			wantStatus:  EnsureUpdated,
+			wantCalls:   []string{"GET quotas", "PATCH quotas/5"},
+			wantPayload: map[string]any{"hard_limit": float64(2000)},
+			wantDrift:   Params{"hard_limit": 2000},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			srv := newTestServer(t, recordsHandler(tt.records...))
+			rest := newTestRest(t, srv.config())
+			var drift Params
+			tt.opts.OnDrift = func(_ Record, changed Params) { drift = changed }
+
+			record, status, err := rest.Quotas.ensureByParams(context.Background(), Params{"path": "/data", "tenant_id": 1}, tt.body, tt.opts)
+
+			require.NoError(t, err)
+			require.NotNil(t, record)
+			assert.Equal(t, tt.wantStatus, status)
+			requests := srv.Requests()
+			assert.Equal(t, tt.wantCalls, methodsAndPaths(requests))
+			if tt.wantPayload != nil {
+				var payload map[string]any
+				require.NoError(t, json.Unmarshal(requests[len(requests)-1].Body, &payload))
+				assert.Equal(t, tt.wantPayload, payload)
+			}
+			assert.Equal(t, tt.wantDrift, drift)
+		})
+	}
+}
+
+func TestEnsureIsThinWrapper(t *testing.T) {
+	srv := newTestServer(t, recordsHandler(map[string]any{"id": 3, "name": "v", "path": "/old"}))
+	rest := newTestRest(t, srv.config())
+
+	_, err := rest.Views.Ensure(context.Background(), "v", Params{"path": "/new"})
+	require.NoError(t, err)
+
+	requests := srv.Requests()
+	assert.Equal(t, []string{"GET views"}, methodsAndPaths(requests), "Ensure never updates existing resource")
+	assert.Equal(t, "v", requests[0].Query.Get("name"))
+	assert.Equal(t, http.MethodGet, requests[0].Method)
+}
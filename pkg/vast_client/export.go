@@ -0,0 +1,211 @@
+package vast_client
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"sort"
+	"time"
+)
+
+// ExportedResource is a list of records of single resource type in ExportBundle.
+type ExportedResource struct {
+	ResourceType string    `json:"resource_type"` // Resource type (e.g. "View", "ViewPolicy")
+	Records      RecordSet `json:"records"`
+}
+
+// ExportBundle is JSON serializable snapshot of resources made by VMSRest.Export.
+type ExportBundle struct {
+	ExportedAt time.Time          `json:"exported_at"`
+	Resources  []ExportedResource `json:"resources"`
+}
+
+// ImportOptions controls VMSRest.Import.
+type ImportOptions struct {
+	UpdateOnDrift bool     // Update existing objects with fields that differ from exported values.
+	StripFields   []string // Extra fields to strip from exported records (in addition to server-managed fields).
+	// KeepUnresolved makes objects with unresolved references to be imported with original ids
+	// (unresolved references are still reported). By default such objects are skipped.
+	KeepUnresolved bool
+}
+
+// ImportResult describes result of import of single object.
+type ImportResult struct {
+	ResourceType string
+	Name         string
+	SourceId     int64        // Id of object on source cluster
+	TargetId     int64        // Id of object on target cluster (0 if object was not imported)
+	Status       EnsureStatus // Empty if object was not imported
+	Unresolved   []string     // Reference fields (e.g. "policy_id") which could not be remapped
+	Err          error
+}
+
+// exportStripFields are server-managed fields which are never sent on import.
+var exportStripFields = []string{"id", "guid", "created", "url", "sync", "sync_time", resourceTypeKey}
+
+// exportReferences maps reference fields to resource type they refer to.
+// References are remapped on import by name of referenced object.
+var exportReferences = map[string]string{
+	"tenant_id":            "Tenant",
+	"policy_id":            "ViewPolicy",
+	"qos_policy_id":        "QosPolicy",
+	"protection_policy_id": "ProtectionPolicy",
+	"view_id":              "View",
+}
+
+// importOrder is order in which resource types are imported so referenced objects exist before objects referring to them.
+// Resource types which are not listed are imported last in bundle order.
+var importOrder = []string{"Tenant", "ViewPolicy", "QosPolicy", "ProtectionPolicy", "View", "Quota"}
+
+// Export lists all resources of given types (e.g. "ViewPolicy", "View", "Quota") matching params
+// and returns them as ExportBundle which can be serialized to JSON and replayed with Import on another cluster.
+func (rest *VMSRest) Export(ctx context.Context, resourceTypes []string, params Params) (ExportBundle, error) {
+	bundle := ExportBundle{ExportedAt: time.Now().UTC()}
+	for _, resourceType := range resourceTypes {
+		resource, ok := rest.resourceMap[resourceType]
+		if !ok {
+			return ExportBundle{}, fmt.Errorf("unknown resource type %q", resourceType)
+		}
+		records, err := resource.List(ctx, params)
+		if err != nil {
+			return ExportBundle{}, fmt.Errorf("failed to export %s: %w", resourceType, err)
+		}
+		bundle.Resources = append(bundle.Resources, ExportedResource{ResourceType: resourceType, Records: records})
+	}
+	return bundle, nil
+}
+
+// ensureWithStatus is implemented by all resources (see VastResourceEntry.ensureByParams).
+type ensureWithStatus interface {
+	ensureByParams(ctx context.Context, searchParams Params, body Params, opts EnsureOptions) (Record, EnsureStatus, error)
+}
+
+// Import re-creates objects of bundle made by Export. Objects are matched by name (Ensure-style) so import is idempotent.
+// Server-managed fields (id, guid, created, sync state) are stripped and references by id (e.g. view "policy_id")
+// are remapped by name of referenced object. Objects with references which cannot be remapped are reported
+// with Unresolved fields and skipped (see ImportOptions.KeepUnresolved).
+// Per-object failures are reported in results. Returned error is not nil only if import was aborted (e.g. context is done).
+func (rest *VMSRest) Import(ctx context.Context, bundle ExportBundle, opts ImportOptions) ([]ImportResult, error) {
+	resources := slices.Clone(bundle.Resources)
+	sort.SliceStable(resources, func(i, j int) bool {
+		return importRank(resources[i].ResourceType) < importRank(resources[j].ResourceType)
+	})
+	// Names of exported objects by source id
+	sourceNames := map[string]map[int64]string{}
+	for _, exported := range bundle.Resources {
+		names := sourceNames[exported.ResourceType]
+		if names == nil {
+			names = map[int64]string{}
+			sourceNames[exported.ResourceType] = names
+		}
+		for _, record := range exported.Records {
+			id, idErr := record.ID()
+			name, nameErr := record.GetString("name")
+			if idErr == nil && nameErr == nil {
+				names[id] = name
+			}
+		}
+	}
+	// Ids of objects on target cluster by name
+	targetIds := map[string]map[string]int64{}
+	resolve := func(field string, sourceId int64) (int64, bool) {
+		refType := exportReferences[field]
+		name, ok := sourceNames[refType][sourceId]
+		if !ok {
+			return 0, false
+		}
+		if id, ok := targetIds[refType][name]; ok {
+			return id, true
+		}
+		resource, ok := rest.resourceMap[refType]
+		if !ok {
+			return 0, false
+		}
+		target, err := resource.Get(ctx, Params{"name": name})
+		if err != nil {
+			return 0, false
+		}
+		id, err := target.ID()
+		if err != nil {
+			return 0, false
+		}
+		if targetIds[refType] == nil {
+			targetIds[refType] = map[string]int64{}
+		}
+		targetIds[refType][name] = id
+		return id, true
+	}
+
+	strip := append(slices.Clone(exportStripFields), opts.StripFields...)
+	var results []ImportResult
+	for _, exported := range resources {
+		resource, ok := rest.resourceMap[exported.ResourceType]
+		if !ok {
+			results = append(results, ImportResult{ResourceType: exported.ResourceType, Err: fmt.Errorf("unknown resource type %q", exported.ResourceType)})
+			continue
+		}
+		for _, record := range exported.Records {
+			if err := ctx.Err(); err != nil {
+				return results, err
+			}
+			result := ImportResult{ResourceType: exported.ResourceType}
+			result.SourceId, _ = record.ID()
+			name, err := record.GetString("name")
+			if err != nil {
+				result.Err = fmt.Errorf("object without name cannot be imported: %w", err)
+				results = append(results, result)
+				continue
+			}
+			result.Name = name
+			body := Params{}
+			for key, value := range record {
+				if !slices.Contains(strip, key) {
+					body[key] = value
+				}
+			}
+			for _, field := range sortedKeys(body) {
+				if _, isRef := exportReferences[field]; !isRef || body[field] == nil {
+					continue
+				}
+				sourceId, err := toInt(body[field])
+				if err != nil {
+					continue
+				}
+				if targetId, ok := resolve(field, sourceId); ok {
+					body[field] = targetId
+				} else {
+					result.Unresolved = append(result.Unresolved, field)
+				}
+			}
+			if len(result.Unresolved) > 0 && !opts.KeepUnresolved {
+				result.Err = fmt.Errorf("unresolved references: %v", result.Unresolved)
+				results = append(results, result)
+				continue
+			}
+			delete(body, "name")
+			imported, status, err := resource.(ensureWithStatus).ensureByParams(ctx, Params{"name": name}, body, EnsureOptions{UpdateOnDrift: opts.UpdateOnDrift})
+			if err != nil {
+				result.Err = err
+				results = append(results, result)
+				continue
+			}
+			result.Status = status
+			if result.TargetId, err = imported.ID(); err == nil {
+				if targetIds[exported.ResourceType] == nil {
+					targetIds[exported.ResourceType] = map[string]int64{}
+				}
+				targetIds[exported.ResourceType][name] = result.TargetId
+			}
+			results = append(results, result)
+		}
+	}
+	return results, nil
+}
+
+// importRank returns position of resource type in importOrder.
+func importRank(resourceType string) int {
+	if i := slices.Index(importOrder, resourceType); i >= 0 {
+		return i
+	}
+	return len(importOrder)
+}
@@ -0,0 +1,162 @@
+package vast_client
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"math/rand"
+	"net/http"
+	"strings"
+	"sync"
+	"syscall"
+	"time"
+)
+
+// FaultRule describes misbehavior injected into requests matched by Resource and Verb.
+// Several kinds of faults can be combined in one rule (e.g. Latency with StatusCode).
+type FaultRule struct {
+	Resource     string        // Resource path (e.g. "views", "vtasks", "token"). Empty matches all resources except token requests.
+	Verb         string        // HTTP method. Empty matches all methods.
+	Percentage   float64       // Percentage (0-100] of matching requests affected by the rule.
+	Latency      time.Duration // Delay before request is sent.
+	Reset        bool          // Fail request with connection reset error.
+	StatusCode   int           // Respond with given status code without reaching VMS.
+	TruncateBody bool          // Cut response body in half.
+	TokenExpiry  bool          // Respond with 401 Unauthorized as if token expired.
+}
+
+// FaultPlan is a set of fault rules applied by WithFaults.
+// First rule that matches request is applied. Plan is deterministic for the same Seed and sequence of requests.
+type FaultPlan struct {
+	Seed  int64
+	Rules []FaultRule
+}
+
+// WithFaults injects faults from plan into every request made by rest.
+// It is intended for resilience testing of code that uses the client and must never be used in production.
+func WithFaults(rest *VMSRest, plan FaultPlan) (*VMSRest, error) {
+	session, ok := rest.Session.(*VMSSession)
+	if !ok {
+		return nil, fmt.Errorf("fault injection is supported only for *VMSSession, got %T", rest.Session)
+	}
+	for _, rule := range plan.Rules {
+		if rule.Percentage <= 0 || rule.Percentage > 100 {
+			return nil, fmt.Errorf("fault rule percentage must be in range (0, 100], got %v", rule.Percentage)
+		}
+	}
+	next := session.client.Transport
+	if next == nil {
+		next = http.DefaultTransport
+	}
+	session.client.Transport = &faultTransport{
+		next: next,
+		plan: plan,
+		rnd:  rand.New(rand.NewSource(plan.Seed)),
+	}
+	return rest, nil
+}
+
+// faultTransport is http.RoundTripper that applies FaultPlan to requests.
+type faultTransport struct {
+	next http.RoundTripper
+	plan FaultPlan
+	mu   sync.Mutex
+	rnd  *rand.Rand
+}
+
+// pick returns rule that should be applied to request (if any).
+func (t *faultTransport) pick(req *http.Request) (FaultRule, bool) {
+	resource := resourceFromPath(req.URL.Path)
+	for _, rule := range t.plan.Rules {
+		if rule.Verb != "" && !strings.EqualFold(rule.Verb, req.Method) {
+			continue
+		}
+		// Token requests share transport with session but are affected only by rules targeting them explicitly
+		if rule.Resource == "" && strings.HasPrefix(resource, "token") {
+			continue
+		}
+		if rule.Resource != "" && !strings.HasPrefix(resource, strings.Trim(rule.Resource, "/")) {
+			continue
+		}
+		t.mu.Lock()
+		roll := t.rnd.Float64() * 100
+		t.mu.Unlock()
+		return rule, roll < rule.Percentage
+	}
+	return FaultRule{}, false
+}
+
+func (t *faultTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	rule, ok := t.pick(req)
+	if !ok {
+		return t.next.RoundTrip(req)
+	}
+	if rule.Latency > 0 {
+		timer := time.NewTimer(rule.Latency)
+		select {
+		case <-req.Context().Done():
+			timer.Stop()
+			return nil, req.Context().Err()
+		case <-timer.C:
+		}
+	}
+	switch {
+	case rule.Reset:
+		return nil, fmt.Errorf("injected fault: %w", syscall.ECONNRESET)
+	case rule.TokenExpiry:
+		return syntheticResponse(req, http.StatusUnauthorized, `{"detail": "Token is invalid or expired (injected fault)"}`), nil
+	case rule.StatusCode != 0:
+		return syntheticResponse(req, rule.StatusCode, `{"detail": "injected fault"}`), nil
+	}
+	response, err := t.next.RoundTrip(req)
+	if err != nil || !rule.TruncateBody {
+		return response, err
+	}
+	body, err := io.ReadAll(response.Body)
+	response.Body.Close()
+	if err != nil {
+		return nil, err
+	}
+	body = body[:len(body)/2]
+	response.Body = io.NopCloser(bytes.NewReader(body))
+	response.ContentLength = int64(len(body))
+	response.Header.Del("Content-Length")
+	return response, nil
+}
+
+// syntheticResponse builds response that never reached the server.
+func syntheticResponse(req *http.Request, statusCode int, body string) *http.Response {
+	return &http.Response{
+		Status:        fmt.Sprintf("%d %s", statusCode, http.StatusText(statusCode)),
+		StatusCode:    statusCode,
+		Proto:         "HTTP/1.1",
+		ProtoMajor:    1,
+		ProtoMinor:    1,
+		Header:        http.Header{"Content-Type": []string{ApplicationJson}},
+		Body:          io.NopCloser(strings.NewReader(body)),
+		ContentLength: int64(len(body)),
+		Request:       req,
+	}
+}
+
+// resourceFromPath strips "api" and version segments from URL path. E.g. "/api/v5/views/1" -> "views/1"
+func resourceFromPath(path string) string {
+	path = strings.TrimPrefix(strings.Trim(path, "/"), "api/")
+	if segment, rest, found := strings.Cut(path, "/"); found && len(segment) > 1 && segment[0] == 'v' && isDigits(segment[1:]) {
+		return rest
+	}
+	return path
+}
+
+// isDigits reports whether s is not empty and consists only of ASCII digits.
+func isDigits(s string) bool {
+	if s == "" {
+		return false
+	}
+	for _, c := range s {
+		if c < '0' || c > '9' {
+			return false
+		}
+	}
+	return true
+}
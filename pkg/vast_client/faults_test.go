@@ -0,0 +1,181 @@
+package vast_client
+
+import (
+	"context"
+	"net/http"
+	"syscall"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// newFaultyRest creates client for server with faults from plan injected.
+func newFaultyRest(t *testing.T, config *VMSConfig, plan FaultPlan) *VMSRest {
+	t.Helper()
+	rest, err := WithFaults(newTestRest(t, config), plan)
+	require.NoError(t, err)
+	return rest
+}
+
+func TestFaultsErrorClassification(t *testing.T) {
+	tests := []struct {
+		name  string
+		rule  FaultRule
+		check func(t *testing.T, err error)
+	}{
+		{
+			name: "connection reset",
+			rule: FaultRule{Reset: true},
+			check: func(t *testing.T, err error) {
+				assert.ErrorIs(t, err, syscall.ECONNRESET)
+				assert.False(t, isTimeoutErr(err))
+			},
+		},
+		{
+			name: "status code",
+			rule: FaultRule{StatusCode: http.StatusBadGateway},
+			check: func(t *testing.T, err error) {
+				assert.True(t, isApiErrWithStatus(err, http.StatusBadGateway))
+				assert.True(t, isTimeoutErr(err), "gateway errors mean unknown outcome")
+			},
+		},
+		{
+			name: "token expiry",
+			rule: FaultRule{TokenExpiry: true},
+			check: func(t *testing.T, err error) {
+				assert.True(t, isApiErrWithStatus(err, http.StatusUnauthorized))
+			},
+		},
+		{
+			name: "truncated body",
+			rule: FaultRule{TruncateBody: true},
+			check: func(t *testing.T, err error) {
+				assert.ErrorContains(t, err, "failed to decode response")
+			},
+		},
+		{
+			name: "latency over client timeout",
+			rule: FaultRule{Latency: time.Second},
+			check: func(t *testing.T, err error) {
+				var timeoutErr *ClientTimeoutError
+				require.ErrorAs(t, err, &timeoutErr)
+				assert.Equal(t, 50*time.Millisecond, timeoutErr.Timeout)
+				assert.ErrorIs(t, err, context.DeadlineExceeded)
+				assert.True(t, isTimeoutErr(err))
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			srv := newTestServer(t, recordsHandler(map[string]any{"id": 1, "name": "a"}))
+			config := srv.config()
+			config.RequestTimeout = 50 * time.Millisecond
+			tt.rule.Percentage = 100
+			rest := newFaultyRest(t, config, FaultPlan{Rules: []FaultRule{tt.rule}})
+
+			_, err := rest.Views.List(context.Background(), nil)
+			require.Error(t, err)
+			tt.check(t, err)
+		})
+	}
+}
+
+func TestFaultsRetry(t *testing.T) {
+	tests := []struct {
+		name         string
+		rule         FaultRule
+		verb         string
+		wantErr      bool
+		wantAttempts int
+	}{
+		{name: "gateway error is retried", rule: FaultRule{StatusCode: http.StatusServiceUnavailable}, verb: http.MethodGet, wantErr: true, wantAttempts: 3},
+		{name: "too many requests is retried", rule: FaultRule{StatusCode: http.StatusTooManyRequests}, verb: http.MethodGet, wantErr: true, wantAttempts: 3},
+		{name: "read timeout covers all attempts", rule: FaultRule{Latency: time.Second}, verb: http.MethodGet, wantErr: true, wantAttempts: 1},
+		{name: "client error is not retried", rule: FaultRule{StatusCode: http.StatusBadRequest}, verb: http.MethodGet, wantErr: true, wantAttempts: 1},
+		{name: "connection reset is not retried", rule: FaultRule{Reset: true}, verb: http.MethodGet, wantErr: true, wantAttempts: 1},
+		{name: "mutation is not retried", rule: FaultRule{StatusCode: http.StatusServiceUnavailable}, verb: http.MethodPost, wantErr: true, wantAttempts: 1},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			srv := newTestServer(t, recordsHandler())
+			config := srv.config()
+			config.Timeouts.Read = 50 * time.Millisecond
+			config.Retry = &RetryPolicy{MaxAttempts: 3, Backoff: time.Millisecond}
+			metrics := NewInMemoryMetrics()
+			config.Metrics = metrics
+			tt.rule.Percentage = 100
+			rest := newFaultyRest(t, config, FaultPlan{Rules: []FaultRule{tt.rule}})
+
+			var err error
+			if tt.verb == http.MethodGet {
+				_, err = rest.Views.List(context.Background(), nil)
+			} else {
+				_, err = rest.Views.Create(context.Background(), Params{"path": "/a", "policy_id": 1})
+			}
+			assert.Equal(t, tt.wantErr, err != nil, "unexpected error %v", err)
+			assert.Equal(t, tt.wantAttempts-1, metrics.RetryCount("View", tt.verb))
+			assert.Empty(t, srv.Requests(), "faults are injected before request reaches server")
+		})
+	}
+}
+
+func TestFaultsRetryRecovers(t *testing.T) {
+	srv := newTestServer(t, recordsHandler(map[string]any{"id": 1, "name": "a"}))
+	config := srv.config()
+	config.Retry = &RetryPolicy{MaxAttempts: 10, Backoff: time.Millisecond}
+	rest := newFaultyRest(t, config, FaultPlan{Seed: 7, Rules: []FaultRule{{Resource: "views", Percentage: 50, StatusCode: http.StatusServiceUnavailable}}})
+
+	for range 10 {
+		views, err := rest.Views.List(context.Background(), nil)
+		require.NoError(t, err)
+		assert.Len(t, views, 1)
+	}
+}
+
+func TestFaultsAreDeterministic(t *testing.T) {
+	outcomes := func(seed int64) []bool {
+		srv := newTestServer(t, recordsHandler())
+		rest := newFaultyRest(t, srv.config(), FaultPlan{Seed: seed, Rules: []FaultRule{{Percentage: 30, StatusCode: http.StatusInternalServerError}}})
+		var result []bool
+		for range 30 {
+			_, err := rest.Views.List(context.Background(), nil)
+			result = append(result, err == nil)
+		}
+		return result
+	}
+
+	first := outcomes(42)
+	assert.Equal(t, first, outcomes(42))
+	assert.Contains(t, first, true)
+	assert.Contains(t, first, false)
+	assert.NotEqual(t, first, outcomes(43))
+}
+
+func TestFaultsMatching(t *testing.T) {
+	srv := newTestServer(t, recordsHandler())
+	config := srv.config()
+	config.Username, config.Password, config.ApiToken = "admin", "123456", ""
+	rest := newFaultyRest(t, config, FaultPlan{Rules: []FaultRule{
+		{Resource: "quotas", Verb: http.MethodGet, Percentage: 100, StatusCode: http.StatusInternalServerError},
+		{Percentage: 100, StatusCode: http.StatusNotImplemented},
+	}})
+	ctx := context.Background()
+
+	_, err := rest.Quotas.List(ctx, nil)
+	assert.True(t, isApiErrWithStatus(err, http.StatusInternalServerError))
+	_, err = rest.Quotas.Create(ctx, Params{"path": "/a"})
+	assert.True(t, isApiErrWithStatus(err, http.StatusNotImplemented))
+	_, err = rest.Views.List(ctx, nil)
+	assert.True(t, isApiErrWithStatus(err, http.StatusNotImplemented))
+	assert.Equal(t, 1, srv.TokenRequests(), "token requests are affected only by rules targeting them")
+}
+
+func TestWithFaultsValidation(t *testing.T) {
+	srv := newTestServer(t, recordsHandler())
+	_, err := WithFaults(newTestRest(t, srv.config()), FaultPlan{Rules: []FaultRule{{Percentage: 0, Reset: true}}})
+	assert.Error(t, err)
+	_, err = WithFaults(newTestRest(t, srv.config()), FaultPlan{Rules: []FaultRule{{Percentage: 101, Reset: true}}})
+	assert.Error(t, err)
+}
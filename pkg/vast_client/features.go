@@ -0,0 +1,112 @@
+package vast_client
+
+import (
+	"context"
+	"fmt"
+
+	version "github.com/hashicorp/go-version"
+)
+
+// Known capabilities of VAST cluster and minimal cluster versions which support them (see VMSRest.Features).
+const (
+	FeatureViewBucketOwner   = "view_bucket_owner"
+	FeatureQosCapacityModes  = "qos_capacity_modes"
+	FeatureVmsLoginBanner    = "vms_login_banner"
+	FeatureVmsSessionTimeout = "vms_session_timeout"
+	FeatureApiTokens         = "api_tokens"
+)
+
+var knownFeatures = map[string]string{
+	FeatureViewBucketOwner:   "5.1.0",
+	FeatureQosCapacityModes:  "5.2.0",
+	FeatureVmsLoginBanner:    "5.1.0",
+	FeatureVmsSessionTimeout: "5.2.0",
+	FeatureApiTokens:         "5.2.0",
+}
+
+// UnsupportedVersionError is returned when operation requires newer cluster version than connected cluster has.
+type UnsupportedVersionError struct {
+	What           string // Operation, field or feature which is not supported
+	ClusterVersion string
+	MinVersion     string
+}
+
+func (e *UnsupportedVersionError) Error() string {
+	return fmt.Sprintf("%s is not supported in VAST cluster version %s (supported from version %s)", e.What, e.ClusterVersion, e.MinVersion)
+}
+
+// RequiresVersion returns UnsupportedVersionError if version of connected cluster is older than minVersion (e.g. "5.2.0").
+// Cluster version is requested once and cached (see Version.GetVersion).
+func (rest *VMSRest) RequiresVersion(ctx context.Context, minVersion string) error {
+	return rest.requireVersion(ctx, fmt.Sprintf("operation requiring version %s", minVersion), minVersion)
+}
+
+// requireVersion returns UnsupportedVersionError describing what if cluster version is older than minVersion.
+func (rest *VMSRest) requireVersion(ctx context.Context, what, minVersion string) error {
+	minimal, err := version.NewVersion(minVersion)
+	if err != nil {
+		return fmt.Errorf("invalid version %q: %w", minVersion, err)
+	}
+	clusterVersion, err := rest.Versions.GetVersion(ctx)
+	if err != nil {
+		return err
+	}
+	if clusterVersion.LessThan(minimal) {
+		return &UnsupportedVersionError{What: what, ClusterVersion: clusterVersion.String(), MinVersion: minVersion}
+	}
+	return nil
+}
+
+// requireFeature returns UnsupportedVersionError if known feature is not supported by cluster version.
+func (rest *VMSRest) requireFeature(ctx context.Context, feature string) error {
+	minVersion, ok := knownFeatures[feature]
+	if !ok {
+		return fmt.Errorf("unknown feature %q", feature)
+	}
+	return rest.requireVersion(ctx, fmt.Sprintf("feature %q", feature), minVersion)
+}
+
+// requireFeatures returns UnsupportedVersionError for first known feature which is not supported by cluster version.
+func (rest *VMSRest) requireFeatures(ctx context.Context, features []string) error {
+	for _, feature := range features {
+		if err := rest.requireFeature(ctx, feature); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// fieldFeatures are body fields of resources (by resource type) which are supported only since version of known feature.
+var fieldFeatures = map[string]map[string]string{
+	"View": {"bucket_owner": FeatureViewBucketOwner},
+}
+
+// checkFieldFeatures verifies that version-dependent fields present in body are supported by cluster version
+// (see fieldFeatures). Cluster version is not requested if body has no such fields.
+func (e *VastResourceEntry) checkFieldFeatures(ctx context.Context, body Params) error {
+	features := fieldFeatures[e.resourceType]
+	for _, field := range sortedKeys(body) {
+		feature, ok := features[field]
+		if !ok {
+			continue
+		}
+		if err := e.rest.requireVersion(ctx, fmt.Sprintf("field %q of %s", field, e.resourcePath), knownFeatures[feature]); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Features reports which known capabilities (see Feature* constants) are supported by connected cluster
+// based on its version.
+func (rest *VMSRest) Features(ctx context.Context) (map[string]bool, error) {
+	clusterVersion, err := rest.Versions.GetVersion(ctx)
+	if err != nil {
+		return nil, err
+	}
+	features := make(map[string]bool, len(knownFeatures))
+	for feature, minVersion := range knownFeatures {
+		features[feature] = !clusterVersion.LessThan(version.Must(version.NewVersion(minVersion)))
+	}
+	return features, nil
+}
@@ -0,0 +1,202 @@
+package vast_client
+
+import (
+	"context"
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// versionedRecordsHandler serves cluster version on "versions" endpoint and behaves like recordsHandler otherwise.
+func versionedRecordsHandler(sysVersion string, records ...map[string]any) http.HandlerFunc {
+	versions, others := versionHandler(sysVersion), recordsHandler(records...)
+	return func(w http.ResponseWriter, r *http.Request) {
+		if resourceFromPath(r.URL.Path) == "versions" {
+			versions(w, r)
+			return
+		}
+		others(w, r)
+	}
+}
+
+func TestRequiresVersion(t *testing.T) {
+	tests := []struct {
+		clusterVersion string
+		minVersion     string
+		wantErr        bool
+	}{
+		{clusterVersion: "5.2.0.10", minVersion: "5.2.0"},
+		{clusterVersion: "5.3.1", minVersion: "5.2"},
+		{clusterVersion: "5.1.0.5", minVersion: "5.2.0", wantErr: true},
+		{clusterVersion: "4.7.0", minVersion: "5.0.0", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.clusterVersion+">="+tt.minVersion, func(t *testing.T) {
+			srv := newTestServer(t, versionHandler(tt.clusterVersion))
+			rest := newTestRest(t, srv.config())
+
+			err := rest.RequiresVersion(context.Background(), tt.minVersion)
+			if !tt.wantErr {
+				require.NoError(t, err)
+				return
+			}
+			var unsupported *UnsupportedVersionError
+			require.ErrorAs(t, err, &unsupported)
+			assert.Equal(t, tt.minVersion, unsupported.MinVersion)
+		})
+	}
+}
+
+func TestRequiresVersionInvalid(t *testing.T) {
+	srv := newTestServer(t, versionHandler("5.2.0"))
+	rest := newTestRest(t, srv.config())
+
+	err := rest.RequiresVersion(context.Background(), "five")
+	assert.ErrorContains(t, err, `invalid version "five"`)
+	assert.Empty(t, srv.Requests())
+}
+
+func TestFeatures(t *testing.T) {
+	tests := []struct {
+		clusterVersion string
+		want           map[string]bool
+	}{
+		{
+			clusterVersion: "5.0.0",
+			want: map[string]bool{
+				FeatureViewBucketOwner: false, FeatureQosCapacityModes: false, FeatureVmsLoginBanner: false,
+				FeatureVmsSessionTimeout: false, FeatureApiTokens: false,
+			},
+		},
+		{
+			clusterVersion: "5.1.2.3",
+			want: map[string]bool{
+				FeatureViewBucketOwner: true, FeatureQosCapacityModes: false, FeatureVmsLoginBanner: true,
+				FeatureVmsSessionTimeout: false, FeatureApiTokens: false,
+			},
+		},
+		{
+			clusterVersion: "5.2.0",
+			want: map[string]bool{
+				FeatureViewBucketOwner: true, FeatureQosCapacityModes: true, FeatureVmsLoginBanner: true,
+				FeatureVmsSessionTimeout: true, FeatureApiTokens: true,
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.clusterVersion, func(t *testing.T) {
+			ctx := context.Background()
+			srv := newTestServer(t, versionHandler(tt.clusterVersion))
+			rest := newTestRest(t, srv.config())
+
+			features, err := rest.Features(ctx)
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, features)
+
+			// Cached cluster version is reused by subsequent checks.
+			_ = rest.RequiresVersion(ctx, "5.0.0")
+			assert.Equal(t, 1, countRequests(srv.Requests(), "versions"))
+		})
+	}
+}
+
+func TestVersionGatedMethods(t *testing.T) {
+	tests := []struct {
+		name       string
+		call       func(ctx context.Context, rest *VMSRest) error
+		oldVersion string // Defaults to 5.1.0
+		wantWhat   string
+		wantWrite  string
+	}{
+		{
+			name: "vms setting",
+			call: func(ctx context.Context, rest *VMSRest) error {
+				_, err := rest.Vms.SetMaxApiTokens(ctx, 10)
+				return err
+			},
+			wantWhat:  `vms setting "max_api_tokens"`,
+			wantWrite: "PATCH vms/1",
+		},
+		{
+			name: "qos capacity mode",
+			call: func(ctx context.Context, rest *VMSRest) error {
+				_, err := rest.QosPolicies.CreateCapacity(ctx, "qos", QosModeUsedCapacity, QosCapacityLimits{MaxReadsBwMbpsPerGb: 1}, nil)
+				return err
+			},
+			wantWhat:  `feature "qos_capacity_modes"`,
+			wantWrite: "POST qospolicies",
+		},
+		{
+			name: "api token revoke",
+			call: func(ctx context.Context, rest *VMSRest) error {
+				_, err := rest.ApiTokens.Revoke(ctx, 1)
+				return err
+			},
+			wantWhat:  `feature "api_tokens"`,
+			wantWrite: "PATCH apitokens/1/revoke",
+		},
+		{
+			name: "buckets by owner",
+			call: func(ctx context.Context, rest *VMSRest) error {
+				_, err := rest.Buckets.ListByOwner(ctx, "jdoe")
+				return err
+			},
+			oldVersion: "5.0.0",
+			wantWhat:   `feature "view_bucket_owner"`,
+			wantWrite:  "GET buckets",
+		},
+		{
+			name: "view with bucket owner",
+			call: func(ctx context.Context, rest *VMSRest) error {
+				_, err := rest.Views.Create(ctx, Params{"path": "/b", "policy_id": 1, "bucket_owner": "jdoe"})
+				return err
+			},
+			oldVersion: "5.0.0",
+			wantWhat:   `field "bucket_owner" of views`,
+			wantWrite:  "POST views",
+		},
+		{
+			name: "view bucket owner update",
+			call: func(ctx context.Context, rest *VMSRest) error {
+				_, err := rest.Views.Update(ctx, 1, Params{"bucket_owner": "jdoe"})
+				return err
+			},
+			oldVersion: "5.0.0",
+			wantWhat:   `field "bucket_owner" of views`,
+			wantWrite:  "PATCH views/1",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := context.Background()
+
+			oldVersion := tt.oldVersion
+			if oldVersion == "" {
+				oldVersion = "5.1.0"
+			}
+			oldSrv := newTestServer(t, versionedRecordsHandler(oldVersion, map[string]any{"id": 1}))
+			err := tt.call(ctx, newTestRest(t, oldSrv.config()))
+			var unsupported *UnsupportedVersionError
+			require.ErrorAs(t, err, &unsupported)
+			assert.Equal(t, tt.wantWhat, unsupported.What)
+			assert.Equal(t, oldVersion, unsupported.ClusterVersion)
+			assert.NotContains(t, methodsAndPaths(oldSrv.Requests()), tt.wantWrite, "request is not sent to older cluster")
+
+			newSrv := newTestServer(t, versionedRecordsHandler("5.2.0", map[string]any{"id": 1}))
+			require.NoError(t, tt.call(ctx, newTestRest(t, newSrv.config())))
+			assert.Contains(t, methodsAndPaths(newSrv.Requests()), tt.wantWrite)
+		})
+	}
+}
+
+func TestFieldFeaturesCheckedOnlyWhenFieldIsSet(t *testing.T) {
+	srv := newTestServer(t, versionedRecordsHandler("5.0.0", map[string]any{"id": 1}))
+	rest := newTestRest(t, srv.config())
+
+	_, err := rest.Views.Create(context.Background(), Params{"path": "/v", "policy_id": 1})
+
+	require.NoError(t, err)
+	assert.Equal(t, []string{"POST views"}, methodsAndPaths(srv.Requests()), "cluster version is not requested")
+}
@@ -0,0 +1,132 @@
+package vast_client
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+const testApiToken = "test-api-token"
+
+// recordedRequest is request received by testServer.
+type recordedRequest struct {
+	Method string
+	Path   string // Resource path without api and version prefix (e.g. "views/1")
+	Query  url.Values
+	Header http.Header
+	Body   []byte
+}
+
+// testServer is httptest.Server emulating VMS. Token endpoints issue JWT tokens ("access-N"/"refresh-N"),
+// all other requests are recorded and served by handler.
+type testServer struct {
+	*httptest.Server
+
+	mu            sync.Mutex
+	requests      []recordedRequest
+	tokenRequests int
+}
+
+func newTestServer(t *testing.T, handler http.HandlerFunc) *testServer {
+	t.Helper()
+	srv := &testServer{}
+	srv.Server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		path := resourceFromPath(r.URL.Path)
+		switch path {
+		case "token", "token/refresh":
+			srv.mu.Lock()
+			srv.tokenRequests++
+			n := srv.tokenRequests
+			srv.mu.Unlock()
+			writeTestJSON(w, http.StatusOK, map[string]string{"access": fmt.Sprintf("access-%d", n), "refresh": fmt.Sprintf("refresh-%d", n)})
+			return
+		case "token/blacklist":
+			writeTestJSON(w, http.StatusOK, map[string]string{})
+			return
+		}
+		body, _ := io.ReadAll(r.Body)
+		srv.mu.Lock()
+		srv.requests = append(srv.requests, recordedRequest{
+			Method: r.Method,
+			Path:   path,
+			Query:  r.URL.Query(),
+			Header: r.Header.Clone(),
+			Body:   body,
+		})
+		srv.mu.Unlock()
+		r.Body = io.NopCloser(strings.NewReader(string(body)))
+		handler(w, r)
+	}))
+	t.Cleanup(srv.Close)
+	return srv
+}
+
+// Requests returns resource requests received so far.
+func (s *testServer) Requests() []recordedRequest {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]recordedRequest(nil), s.requests...)
+}
+
+// TokenRequests returns number of token requests (acquire and refresh) received so far.
+func (s *testServer) TokenRequests() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.tokenRequests
+}
+
+// config returns VMSConfig pointing to server with api token authentication.
+func (s *testServer) config() *VMSConfig {
+	return &VMSConfig{BaseURL: s.URL, ApiToken: testApiToken}
+}
+
+// newTestRest creates VMSRest from config and closes it when test is over.
+func newTestRest(t *testing.T, config *VMSConfig) *VMSRest {
+	t.Helper()
+	rest, err := newVMSRest(config)
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = rest.Close() })
+	return rest
+}
+
+func writeTestJSON(w http.ResponseWriter, status int, payload any) {
+	w.Header().Set("Content-Type", ApplicationJson)
+	w.WriteHeader(status)
+	_ = json.NewEncoder(w).Encode(payload)
+}
+
+// recordsHandler serves list of records for GET requests to resource collection and empty response for other requests.
+func recordsHandler(records ...map[string]any) http.HandlerFunc {
+	if records == nil {
+		records = []map[string]any{}
+	}
+	return func(w http.ResponseWriter, r *http.Request) {
+		path := resourceFromPath(r.URL.Path)
+		if r.Method == http.MethodGet && !isDigits(path[strings.LastIndex(path, "/")+1:]) {
+			writeTestJSON(w, http.StatusOK, records)
+			return
+		}
+		if r.Method == http.MethodDelete {
+			w.WriteHeader(http.StatusNoContent)
+			return
+		}
+		writeTestJSON(w, http.StatusOK, map[string]any{"id": 1})
+	}
+}
+
+// methodsAndPaths returns "<METHOD> <path>" of requests.
+func methodsAndPaths(requests []recordedRequest) []string {
+	var result []string
+	for _, r := range requests {
+		result = append(result, r.Method+" "+r.Path)
+	}
+	return result
+}
@@ -0,0 +1,214 @@
+package vast_client
+
+import (
+	"context"
+	"net/http"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// createOutcome is scripted behavior of POST request handled by idempotentHandler.
+type createOutcome struct {
+	create bool          // Resource is stored on server side
+	status int           // Response status code
+	delay  time.Duration // Delay before response
+}
+
+// idempotentHandler serves snapshots collection: GET lists stored snapshots, POST follows scripted outcomes
+// (last outcome is repeated when script is over).
+func idempotentHandler(existing bool, outcomes ...createOutcome) http.HandlerFunc {
+	var (
+		mu    sync.Mutex
+		store []map[string]any
+		posts int
+	)
+	if existing {
+		store = append(store, map[string]any{"id": 1, "name": "snap", "path": "/data"})
+	}
+	return func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		if r.Method == http.MethodGet {
+			records := append([]map[string]any{}, store...)
+			mu.Unlock()
+			writeTestJSON(w, http.StatusOK, records)
+			return
+		}
+		outcome := outcomes[min(posts, len(outcomes)-1)]
+		posts++
+		record := map[string]any{"id": len(store) + 1, "name": "snap", "path": "/data"}
+		if outcome.create {
+			store = append(store, record)
+		}
+		mu.Unlock()
+		time.Sleep(outcome.delay)
+		writeTestJSON(w, outcome.status, record)
+	}
+}
+
+func TestCreateIdempotent(t *testing.T) {
+	created := createOutcome{create: true, status: http.StatusCreated}
+	retry := &RetryPolicy{MaxAttempts: 3, Backoff: time.Millisecond}
+	tests := []struct {
+		name        string
+		existing    bool
+		outcomes    []createOutcome
+		retry       *RetryPolicy  // VMSConfig.Retry
+		ctxRetry    *RetryPolicy  // Retry policy of caller context if not nil
+		timeout     time.Duration // Caller context timeout if not zero
+		wantCalls   []string
+		wantStatus  int // Status of returned ApiError if not zero
+		wantTimeout bool
+	}{
+		{
+			name:      "existing resource",
+			existing:  true,
+			outcomes:  []createOutcome{created},
+			wantCalls: []string{"GET snapshots"},
+		},
+		{
+			name:      "created",
+			outcomes:  []createOutcome{created},
+			wantCalls: []string{"GET snapshots", "POST snapshots"},
+		},
+		{
+			name:      "timeout after success",
+			outcomes:  []createOutcome{{create: true, status: http.StatusGatewayTimeout}},
+			wantCalls: []string{"GET snapshots", "POST snapshots", "GET snapshots"},
+		},
+		{
+			name:      "timeout before success is retried",
+			outcomes:  []createOutcome{{status: http.StatusBadGateway}, created},
+			retry:     retry,
+			wantCalls: []string{"GET snapshots", "POST snapshots", "GET snapshots", "POST snapshots"},
+		},
+		{
+			name:     "attempts are exhausted",
+			outcomes: []createOutcome{{status: http.StatusServiceUnavailable}},
+			retry:    retry,
+			wantCalls: []string{
+				"GET snapshots", "POST snapshots", "GET snapshots", "POST snapshots", "GET snapshots", "POST snapshots", "GET snapshots",
+			},
+			wantStatus: http.StatusServiceUnavailable,
+		},
+		{
+			name:       "not retried without retry policy",
+			outcomes:   []createOutcome{{status: http.StatusBadGateway}, created},
+			wantCalls:  []string{"GET snapshots", "POST snapshots", "GET snapshots"},
+			wantStatus: http.StatusBadGateway,
+		},
+		{
+			name:       "not retried with NoRetry in context",
+			outcomes:   []createOutcome{{status: http.StatusBadGateway}, created},
+			retry:      retry,
+			ctxRetry:   &NoRetry,
+			wantCalls:  []string{"GET snapshots", "POST snapshots", "GET snapshots"},
+			wantStatus: http.StatusBadGateway,
+		},
+		{
+			name:       "non timeout error is not retried",
+			outcomes:   []createOutcome{{status: http.StatusBadRequest}},
+			wantCalls:  []string{"GET snapshots", "POST snapshots"},
+			wantStatus: http.StatusBadRequest,
+		},
+		{
+			name:      "caller deadline is over after success",
+			outcomes:  []createOutcome{{create: true, status: http.StatusCreated, delay: 200 * time.Millisecond}},
+			timeout:   100 * time.Millisecond,
+			wantCalls: []string{"GET snapshots", "POST snapshots", "GET snapshots"},
+		},
+		{
+			name:        "caller deadline is over without success",
+			outcomes:    []createOutcome{{status: http.StatusCreated, delay: 200 * time.Millisecond}},
+			timeout:     100 * time.Millisecond,
+			wantCalls:   []string{"GET snapshots", "POST snapshots", "GET snapshots"},
+			wantTimeout: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			srv := newTestServer(t, idempotentHandler(tt.existing, tt.outcomes...))
+			config := srv.config()
+			config.Retry = tt.retry
+			rest := newTestRest(t, config)
+			ctx := context.Background()
+			if tt.ctxRetry != nil {
+				ctx = WithRetryPolicy(ctx, *tt.ctxRetry)
+			}
+			if tt.timeout > 0 {
+				var cancel context.CancelFunc
+				ctx, cancel = context.WithTimeout(ctx, tt.timeout)
+				defer cancel()
+			}
+
+			record, err := rest.Snapshots.CreateIdempotent(ctx, []string{"name", "path"}, Params{"name": "snap", "path": "/data"})
+
+			assert.Equal(t, tt.wantCalls, methodsAndPaths(srv.Requests()))
+			switch {
+			case tt.wantStatus != 0:
+				var apiErr *ApiError
+				require.ErrorAs(t, err, &apiErr)
+				assert.Equal(t, tt.wantStatus, apiErr.StatusCode)
+			case tt.wantTimeout:
+				assert.True(t, isTimeoutErr(err), "got %v", err)
+			default:
+				require.NoError(t, err)
+				assert.Equal(t, float64(1), record["id"], "single resource is created")
+			}
+			for _, r := range srv.Requests() {
+				if r.Method == http.MethodGet {
+					assert.Equal(t, "snap", r.Query.Get("name"))
+					assert.Equal(t, "/data", r.Query.Get("path"))
+				}
+			}
+		})
+	}
+}
+
+func TestCreateIdempotentValidation(t *testing.T) {
+	srv := newTestServer(t, recordsHandler())
+	rest := newTestRest(t, srv.config())
+	ctx := context.Background()
+
+	_, err := rest.Snapshots.CreateIdempotent(ctx, nil, Params{"name": "snap"})
+	require.Error(t, err)
+
+	_, err = rest.Snapshots.CreateIdempotent(ctx, []string{"name", "path"}, Params{"name": "snap"})
+	var validationErr *ValidationError
+	require.ErrorAs(t, err, &validationErr)
+	assert.Equal(t, []string{"path"}, validationErr.Fields)
+	assert.Empty(t, srv.Requests())
+}
+
+func TestCreateIdempotentBackoff(t *testing.T) {
+	srv := newTestServer(t, idempotentHandler(false, createOutcome{status: http.StatusServiceUnavailable}))
+	rest := newTestRest(t, srv.config())
+	ctx := WithRetryPolicy(context.Background(), RetryPolicy{MaxAttempts: 3, Backoff: 40 * time.Millisecond})
+
+	start := time.Now()
+	_, err := rest.Snapshots.CreateIdempotent(ctx, []string{"name"}, Params{"name": "snap", "path": "/data"})
+
+	assert.True(t, isApiErrWithStatus(err, http.StatusServiceUnavailable), "got %v", err)
+	assert.Equal(t, []string{
+		"GET snapshots", "POST snapshots", "GET snapshots", "POST snapshots", "GET snapshots", "POST snapshots", "GET snapshots",
+	}, methodsAndPaths(srv.Requests()))
+	assert.GreaterOrEqual(t, time.Since(start), 120*time.Millisecond, "waits 40ms and 80ms between attempts")
+}
+
+func TestCreateIdempotentBackoffIsCanceled(t *testing.T) {
+	srv := newTestServer(t, idempotentHandler(false, createOutcome{status: http.StatusServiceUnavailable}))
+	rest := newTestRest(t, srv.config())
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel()
+	ctx = WithRetryPolicy(ctx, RetryPolicy{MaxAttempts: 3, Backoff: time.Minute})
+
+	start := time.Now()
+	_, err := rest.Snapshots.CreateIdempotent(ctx, []string{"name"}, Params{"name": "snap", "path": "/data"})
+
+	assert.True(t, isApiErrWithStatus(err, http.StatusServiceUnavailable), "got %v", err)
+	assert.Equal(t, []string{"GET snapshots", "POST snapshots", "GET snapshots"}, methodsAndPaths(srv.Requests()))
+	assert.Less(t, time.Since(start), 10*time.Second, "backoff is interrupted by caller context")
+}
@@ -1,9 +1,12 @@
 package vast_client
 
 import (
+	"bytes"
 	"context"
 	"fmt"
 	"io"
+	"net/http"
+	"time"
 )
 
 // RequestInterceptor defines a middleware-style interface for intercepting API requests
@@ -35,10 +38,134 @@ type RequestInterceptor interface {
 	afterRequest(Renderable) (Renderable, error)
 
 	// doBeforeRequest No need to implement on VAST API Resources. For internal usage only
-	doBeforeRequest(context.Context, string, string, io.Reader) error
+	doBeforeRequest(context.Context, *RequestInfo) error
 
 	// doAfterRequest No need to implement on VAST API Resources. For internal usage only
-	doAfterRequest(Renderable) (Renderable, error)
+	doAfterRequest(context.Context, ResponseInfo, Renderable) (Renderable, error)
+}
+
+// RequestInfo describes request passed to before request interceptors (see VMSConfig.BeforeRequestFnV2).
+// Header and Params are mutable: headers added by interceptor are set on request and URL is rebuilt from Params.
+// Interceptors run before any header is set on request. Headers added by interceptors are applied after
+// auth, default (VMSConfig.DefaultHeaders) and context (WithHeaders) headers so they take precedence over them.
+// Reserved headers (Authorization, Content-Type) cannot be set.
+type RequestInfo struct {
+	Verb      string      // HTTP method (e.g., GET, POST, PUT).
+	URL       string      // Full URL built from original Params. Changing it has no effect, modify Params instead.
+	RequestID string      // Request identifier sent in X-Request-Id header (see WithRequestID).
+	Header    http.Header // Extra headers to set on request.
+	Params    Params      // Query params (copy of params passed by caller).
+	Body      []byte      // JSON encoded request body with sensitive fields redacted (nil if request has no body). Read-only.
+
+	rawBody []byte
+}
+
+// UnsafeBody returns JSON encoded request body as it is sent to VMS (without redaction),
+// e.g. for request signing. Never log returned value.
+func (info *RequestInfo) UnsafeBody() []byte {
+	return info.rawBody
+}
+
+// newRequestInfo creates RequestInfo with copy of params and redacted copy of body.
+func newRequestInfo(verb, requestId string, params Params, body []byte) *RequestInfo {
+	info := &RequestInfo{Verb: verb, RequestID: requestId, Header: http.Header{}, Params: Params{}, rawBody: body}
+	info.Params.Update(params, false)
+	if body != nil {
+		info.Body = redactJSON(body)
+	}
+	return info
+}
+
+// RequestIdHeader is header carrying request identifier (see WithRequestID). Sent with every request.
+// If server echoes it in response its value is reported in ResponseInfo and ApiError.
+const RequestIdHeader = "X-Request-Id"
+
+// ResponseInfo describes request and response passed to after request interceptors (see VMSConfig.AfterRequestFnV2).
+type ResponseInfo struct {
+	Verb       string        // HTTP method (e.g., GET, POST, PUT).
+	URL        string        // Full request URL (including query params).
+	StatusCode int           // HTTP status code of response.
+	Duration   time.Duration // Time from sending request to receiving response headers.
+	RequestID  string        // Request identifier (echoed by server in X-Request-Id response header or sent by client).
+	Header     http.Header   // Response headers (e.g. X-Total-Count, deprecation warnings). Nil if no response was received.
+}
+
+// bodyReader returns reader over request body or nil if request has no body.
+func (info *RequestInfo) bodyReader() io.Reader {
+	if info.Body == nil {
+		return nil
+	}
+	return bytes.NewReader(info.Body)
+}
+
+// adaptBeforeRequestFn adapts BeforeRequestFn with legacy signature to RequestInfo based hook.
+func adaptBeforeRequestFn(fn func(ctx context.Context, verb, url string, body io.Reader) error) func(context.Context, *RequestInfo) error {
+	return func(ctx context.Context, info *RequestInfo) error {
+		return fn(ctx, info.Verb, info.URL, info.bodyReader())
+	}
+}
+
+// Interceptor is a named pair of request hooks which can be chained (see VMSConfig.Interceptors).
+// Before hooks of chain run in registration order and after hooks run in reverse order.
+// Either hook can be nil.
+type Interceptor struct {
+	Name   string // Used to identify interceptor in errors. Index in chain is used if empty.
+	Before func(ctx context.Context, info *RequestInfo) error
+	After  func(ctx context.Context, info ResponseInfo, response Renderable) (Renderable, error)
+}
+
+// InterceptorError is returned when interceptor hook aborts request.
+type InterceptorError struct {
+	Interceptor string // Name of interceptor
+	Stage       string // "before" or "after"
+	Err         error
+}
+
+func (e *InterceptorError) Error() string {
+	return fmt.Sprintf("interceptor %q aborted request (%s request): %v", e.Interceptor, e.Stage, e.Err)
+}
+
+func (e *InterceptorError) Unwrap() error {
+	return e.Err
+}
+
+// WithInterceptors returns handle of the resource which runs provided interceptors (after global ones from VMSConfig)
+// for requests made through it. Original resource is not modified.
+//
+// Example:
+//
+//	views := rest.Views.WithInterceptors(client.Interceptor{Name: "audit", Before: auditFn})
+//	views.Create(ctx, params)
+func (e *VastResourceEntry) WithInterceptors(interceptors ...Interceptor) *VastResourceEntry {
+	scoped := *e
+	scoped.interceptors = append(append([]Interceptor{}, e.interceptors...), interceptors...)
+	return &scoped
+}
+
+// interceptorChain returns ordered chain of user-defined interceptors: legacy config hooks,
+// VMSConfig.Interceptors and then interceptors of the resource (see WithInterceptors).
+func (e *VastResourceEntry) interceptorChain() []Interceptor {
+	config := e.Session().GetConfig()
+	var chain []Interceptor
+	if config.BeforeRequestFn != nil {
+		chain = append(chain, Interceptor{Name: "BeforeRequestFn", Before: adaptBeforeRequestFn(config.BeforeRequestFn)})
+	}
+	if config.BeforeRequestFnV2 != nil {
+		chain = append(chain, Interceptor{Name: "BeforeRequestFnV2", Before: config.BeforeRequestFnV2})
+	}
+	if config.AfterRequestFnV2 != nil {
+		chain = append(chain, Interceptor{Name: "AfterRequestFnV2", After: config.AfterRequestFnV2})
+	}
+	chain = append(chain, config.Interceptors...)
+	return append(chain, e.interceptors...)
+}
+
+// interceptorName returns name of interceptor or its position in chain if name is empty.
+func interceptorName(interceptor Interceptor, idx int) string {
+	if interceptor.Name != "" {
+		return interceptor.Name
+	}
+	return fmt.Sprintf("#%d", idx)
 }
 
 // ######################################################
@@ -60,26 +187,30 @@ func (e *VastResourceEntry) afterRequest(response Renderable) (Renderable, error
 }
 
 // doBeforeRequest Do not override this method in VastResource implementations. For internal use only
-func (e *VastResourceEntry) doBeforeRequest(ctx context.Context, verb, url string, body io.Reader) error {
+func (e *VastResourceEntry) doBeforeRequest(ctx context.Context, info *RequestInfo) error {
 	caller, ok := e.rest.resourceMap[e.GetResourceType()]
 	if !ok {
 		panic(fmt.Sprintf("resource not found in resourceMap for %s", e.GetResourceType()))
 	}
 	if extractor, ok := caller.(RequestInterceptor); ok {
-		if err := extractor.beforeRequest(ctx, verb, url, body); err != nil {
+		if err := extractor.beforeRequest(ctx, info.Verb, info.URL, info.bodyReader()); err != nil {
 			return err
 		}
 	}
-	// User-defined callback
-	config := e.Session().GetConfig()
-	if config.BeforeRequestFn != nil {
-		return config.BeforeRequestFn(ctx, verb, url, body)
+	// User-defined callbacks
+	for idx, interceptor := range e.interceptorChain() {
+		if interceptor.Before == nil {
+			continue
+		}
+		if err := interceptor.Before(ctx, info); err != nil {
+			return &InterceptorError{Interceptor: interceptorName(interceptor, idx), Stage: "before", Err: err}
+		}
 	}
 	return nil
 }
 
 // doAfterRequest Do not override this method in VastResource implementations. For internal use only
-func (e *VastResourceEntry) doAfterRequest(response Renderable) (Renderable, error) {
+func (e *VastResourceEntry) doAfterRequest(ctx context.Context, info ResponseInfo, response Renderable) (Renderable, error) {
 	var err error
 	caller, ok := e.rest.resourceMap[e.GetResourceType()]
 	if !ok {
@@ -94,10 +225,24 @@ func (e *VastResourceEntry) doAfterRequest(response Renderable) (Renderable, err
 	// User-defined callback
 	config := e.Session().GetConfig()
 	if config.AfterRequestFn != nil {
-		return config.AfterRequestFn(response)
+		response, err = config.AfterRequestFn(response)
+	} else {
+		// Common VAST Response mutations.
+		response, err = defaultResponseMutations(response)
+	}
+	if err != nil {
+		return nil, err
+	}
+	chain := e.interceptorChain()
+	for idx := len(chain) - 1; idx >= 0; idx-- {
+		if chain[idx].After == nil {
+			continue
+		}
+		if response, err = chain[idx].After(ctx, info, response); err != nil {
+			return nil, &InterceptorError{Interceptor: interceptorName(chain[idx], idx), Stage: "after", Err: err}
+		}
 	}
-	// Common VAST Response mutations.
-	return defaultResponseMutations(response)
+	return response, nil
 }
 
 // defaultResponseMutations A set of common response transformations in the VAST REST API
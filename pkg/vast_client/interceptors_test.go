@@ -0,0 +1,140 @@
+package vast_client
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestBeforeRequestInterceptor(t *testing.T) {
+	tests := []struct {
+		name       string
+		configure  func(config *VMSConfig)
+		wantQuery  map[string]string
+		wantHeader map[string]string
+		wantErr    bool
+	}{
+		{
+			name: "add header and rewrite query param",
+			configure: func(config *VMSConfig) {
+				config.BeforeRequestFnV2 = func(ctx context.Context, info *RequestInfo) error {
+					info.Header.Set("X-Signature", "signed")
+					info.Params["name"] = "rewritten"
+					info.Params["page_size"] = 10
+					return nil
+				}
+			},
+			wantQuery:  map[string]string{"name": "rewritten", "page_size": "10"},
+			wantHeader: map[string]string{"X-Signature": "signed"},
+		},
+		{
+			name: "interceptor header wins over default and context headers",
+			configure: func(config *VMSConfig) {
+				config.DefaultHeaders = map[string]string{"X-Team": "default"}
+				config.AddBeforeRequest("team", func(ctx context.Context, info *RequestInfo) error {
+					info.Header.Set("X-Team", "interceptor")
+					return nil
+				})
+			},
+			wantQuery:  map[string]string{"name": "v"},
+			wantHeader: map[string]string{"X-Team": "interceptor", "Authorization": "Api-Token " + testApiToken},
+		},
+		{
+			name: "reserved header is rejected",
+			configure: func(config *VMSConfig) {
+				config.AddBeforeRequest("auth", func(ctx context.Context, info *RequestInfo) error {
+					info.Header.Set("Authorization", "Bearer stolen")
+					return nil
+				})
+			},
+			wantErr: true,
+		},
+		{
+			name: "error aborts request",
+			configure: func(config *VMSConfig) {
+				config.AddBeforeRequest("deny", func(ctx context.Context, info *RequestInfo) error {
+					return errors.New("denied")
+				})
+			},
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			srv := newTestServer(t, recordsHandler())
+			config := srv.config()
+			tt.configure(config)
+			rest := newTestRest(t, config)
+
+			_, err := rest.Views.List(WithHeaders(context.Background(), map[string]string{"X-Team": "context"}), Params{"name": "v"})
+
+			if tt.wantErr {
+				require.Error(t, err)
+				assert.Empty(t, srv.Requests(), "request is not sent")
+				return
+			}
+			require.NoError(t, err)
+			requests := srv.Requests()
+			require.Len(t, requests, 1)
+			for key, want := range tt.wantQuery {
+				assert.Equal(t, want, requests[0].Query.Get(key), key)
+			}
+			for key, want := range tt.wantHeader {
+				assert.Equal(t, want, requests[0].Header.Get(key), key)
+			}
+		})
+	}
+}
+
+func TestBeforeRequestInterceptorError(t *testing.T) {
+	srv := newTestServer(t, recordsHandler())
+	config := srv.config()
+	denied := errors.New("denied")
+	config.AddBeforeRequest("deny", func(ctx context.Context, info *RequestInfo) error { return denied })
+	rest := newTestRest(t, config)
+
+	_, err := rest.Views.List(context.Background(), nil)
+
+	var interceptorErr *InterceptorError
+	require.ErrorAs(t, err, &interceptorErr)
+	assert.Equal(t, "deny", interceptorErr.Interceptor)
+	assert.Equal(t, "before", interceptorErr.Stage)
+	assert.ErrorIs(t, err, denied)
+}
+
+func TestBeforeRequestInfo(t *testing.T) {
+	srv := newTestServer(t, recordsHandler())
+	config := srv.config()
+	var legacyVerb, legacyUrl, legacyBody string
+	config.BeforeRequestFn = func(ctx context.Context, verb, url string, body io.Reader) error {
+		legacyVerb, legacyUrl = verb, url
+		raw, _ := io.ReadAll(body)
+		legacyBody = string(raw)
+		return nil
+	}
+	var info *RequestInfo
+	config.BeforeRequestFnV2 = func(ctx context.Context, i *RequestInfo) error {
+		info = i
+		return nil
+	}
+	rest := newTestRest(t, config)
+
+	_, err := rest.Users.Create(context.Background(), Params{"name": "u", "uid": 1001, "password": "secret"})
+	require.NoError(t, err)
+
+	assert.Equal(t, http.MethodPost, legacyVerb)
+	assert.Equal(t, srv.URL+"/api/v5/users", legacyUrl)
+	assert.NotContains(t, legacyBody, "secret")
+	require.NotNil(t, info)
+	assert.Equal(t, http.MethodPost, info.Verb)
+	assert.Equal(t, legacyUrl, info.URL)
+	assert.NotContains(t, string(info.Body), "secret", "body passed to interceptors is redacted")
+	assert.JSONEq(t, `{"name": "u", "uid": 1001, "password": "secret"}`, string(info.UnsafeBody()))
+	assert.NotEmpty(t, info.RequestID)
+	assert.Equal(t, info.RequestID, srv.Requests()[0].Header.Get(RequestIdHeader))
+}
@@ -0,0 +1,116 @@
+package vast_client
+
+import (
+	"fmt"
+	"net"
+	"net/netip"
+	"sort"
+)
+
+// maxExpandedIPs limits number of addresses returned by expandIpRanges.
+const maxExpandedIPs = 1 << 16
+
+// normalizeIpRanges validates ranges (pairs of start/end addresses) and returns them in canonical form:
+// addresses are formatted canonically and ranges are sorted. Reversed ranges and ranges mixing
+// IPv4 and IPv6 addresses are rejected.
+func normalizeIpRanges(ranges [][2]string) ([][2]string, error) {
+	normalized := make([][2]string, 0, len(ranges))
+	for _, r := range ranges {
+		start, end, err := parseIpRange(r)
+		if err != nil {
+			return nil, err
+		}
+		normalized = append(normalized, [2]string{start.String(), end.String()})
+	}
+	sort.Slice(normalized, func(i, j int) bool {
+		a, b := netip.MustParseAddr(normalized[i][0]), netip.MustParseAddr(normalized[j][0])
+		if c := a.Compare(b); c != 0 {
+			return c < 0
+		}
+		return netip.MustParseAddr(normalized[i][1]).Less(netip.MustParseAddr(normalized[j][1]))
+	})
+	return normalized, nil
+}
+
+// parseIpRange parses and validates single range.
+func parseIpRange(r [2]string) (netip.Addr, netip.Addr, error) {
+	start, err := netip.ParseAddr(r[0])
+	if err != nil {
+		return netip.Addr{}, netip.Addr{}, fmt.Errorf("invalid ip range %v: %w", r, err)
+	}
+	end, err := netip.ParseAddr(r[1])
+	if err != nil {
+		return netip.Addr{}, netip.Addr{}, fmt.Errorf("invalid ip range %v: %w", r, err)
+	}
+	start, end = start.Unmap(), end.Unmap()
+	if start.Is4() != end.Is4() {
+		return netip.Addr{}, netip.Addr{}, fmt.Errorf("invalid ip range %v: addresses are of different families", r)
+	}
+	if end.Less(start) {
+		return netip.Addr{}, netip.Addr{}, fmt.Errorf("invalid ip range %v: start address is greater than end address", r)
+	}
+	return start, end, nil
+}
+
+// ipRangesOf reads ranges stored under key of record (e.g. [["10.0.0.1", "10.0.0.10"]]).
+// Missing key means no ranges.
+func ipRangesOf(record Record, key string) ([][2]string, error) {
+	raw, ok := record[key]
+	if !ok || raw == nil {
+		return nil, nil
+	}
+	items, ok := normalizeValue(raw).([]any)
+	if !ok {
+		return nil, &WrongTypeError{Key: key, Expected: "list of ip ranges", Value: raw}
+	}
+	ranges := make([][2]string, 0, len(items))
+	for _, item := range items {
+		pair, ok := item.([]any)
+		if !ok || len(pair) != 2 {
+			return nil, fmt.Errorf("invalid ip range %v in field %q", item, key)
+		}
+		start, startOk := pair[0].(string)
+		end, endOk := pair[1].(string)
+		if !startOk || !endOk {
+			return nil, fmt.Errorf("invalid ip range %v in field %q", item, key)
+		}
+		ranges = append(ranges, [2]string{start, end})
+	}
+	return ranges, nil
+}
+
+// ipRangesEqual reports whether two sets of ranges are equal regardless of order and address formatting.
+func ipRangesEqual(a, b [][2]string) bool {
+	a, errA := normalizeIpRanges(a)
+	b, errB := normalizeIpRanges(b)
+	if errA != nil || errB != nil || len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
+
+// expandIpRanges returns all addresses of ranges. Returns error if there are more than maxExpandedIPs addresses.
+func expandIpRanges(ranges [][2]string) ([]net.IP, error) {
+	var ips []net.IP
+	for _, r := range ranges {
+		start, end, err := parseIpRange(r)
+		if err != nil {
+			return nil, err
+		}
+		for addr := start; ; addr = addr.Next() {
+			if len(ips) == maxExpandedIPs {
+				return nil, fmt.Errorf("ip ranges contain more than %d addresses", maxExpandedIPs)
+			}
+			ips = append(ips, net.IP(addr.AsSlice()))
+			if addr == end {
+				break
+			}
+		}
+	}
+	return ips, nil
+}
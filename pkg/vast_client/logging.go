@@ -0,0 +1,79 @@
+package vast_client
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"log/slog"
+)
+
+// logRequestDone emits debug line for completed request (see VMSConfig.Logger).
+// Request and response bodies are logged (redacted) only if VMSConfig.LogBodies is set.
+func logRequestDone(ctx context.Context, config *VMSConfig, resourceType string, info ResponseInfo, body []byte, result Renderable) {
+	if config.Logger == nil {
+		return
+	}
+	attrs := requestLogAttrs(resourceType, info)
+	if config.LogBodies {
+		attrs = append(attrs, bodyLogAttrs(body)...)
+		if raw, err := json.Marshal(redact(result)); err == nil {
+			attrs = append(attrs, slog.String("response_body", truncateString(string(raw), getRenderLimits().MaxTotalSize)))
+		}
+	}
+	config.Logger.LogAttrs(ctx, slog.LevelDebug, "vast request", attrs...)
+}
+
+// logDryRun emits info line for mutating request which was not sent because of VMSConfig.DryRun.
+// Request body (redacted) is always logged.
+func logDryRun(ctx context.Context, config *VMSConfig, resourceType string, info ResponseInfo, body []byte) {
+	if config.Logger == nil {
+		return
+	}
+	attrs := append(requestLogAttrs(resourceType, info), bodyLogAttrs(body)...)
+	config.Logger.LogAttrs(ctx, slog.LevelInfo, "vast dry-run request (not sent)", attrs...)
+}
+
+// logRequestFailed emits error line for failed request (see VMSConfig.Logger).
+func logRequestFailed(ctx context.Context, config *VMSConfig, resourceType string, info ResponseInfo, body []byte, err error) {
+	if config.Logger == nil {
+		return
+	}
+	var apiErr *ApiError
+	if info.StatusCode == 0 && errors.As(err, &apiErr) {
+		info.StatusCode = apiErr.StatusCode
+	}
+	attrs := append(requestLogAttrs(resourceType, info), slog.String("error", err.Error()))
+	if config.LogBodies {
+		attrs = append(attrs, bodyLogAttrs(body)...)
+	}
+	config.Logger.LogAttrs(ctx, slog.LevelError, "vast request failed", attrs...)
+}
+
+// logWarn emits warn line (e.g. token refresh failures) if VMSConfig.Logger is set.
+func logWarn(config *VMSConfig, msg string, attrs ...slog.Attr) {
+	if config.Logger == nil {
+		return
+	}
+	config.Logger.LogAttrs(context.Background(), slog.LevelWarn, msg, attrs...)
+}
+
+func requestLogAttrs(resourceType string, info ResponseInfo) []slog.Attr {
+	attrs := []slog.Attr{
+		slog.String("resource", resourceType),
+		slog.String("verb", info.Verb),
+		slog.String("url", info.URL),
+		slog.Int("status", info.StatusCode),
+		slog.Duration("duration", info.Duration),
+	}
+	if info.RequestID != "" {
+		attrs = append(attrs, slog.String("request_id", info.RequestID))
+	}
+	return attrs
+}
+
+func bodyLogAttrs(body []byte) []slog.Attr {
+	if body == nil {
+		return nil
+	}
+	return []slog.Attr{slog.String("request_body", truncateString(string(redactJSON(body)), getRenderLimits().MaxTotalSize))}
+}
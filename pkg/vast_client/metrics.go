@@ -0,0 +1,142 @@
+package vast_client
+
+import (
+	"sync"
+	"time"
+)
+
+// MetricsRecorder receives metrics of client requests (see VMSConfig.Metrics).
+// Implementation must be safe for concurrent use. Adapting it to Prometheus (or any other metrics library)
+// usually takes a couple of counters and a histogram.
+type MetricsRecorder interface {
+	// ObserveRequest is called for every request sent to VMS. status is 0 if no response was received.
+	ObserveRequest(resource, verb string, status int, duration time.Duration)
+	// ObserveRetry is called before request is retried. attempt starts from 1 for first retry.
+	ObserveRetry(resource, verb string, attempt int)
+	// ObserveAuthRefresh is called after each token acquisition/refresh attempt.
+	ObserveAuthRefresh(success bool, duration time.Duration)
+	// ObserveInFlight is called when number of in-flight requests changes (see VMSConfig.MaxConcurrentRequests).
+	// inFlight == limit means client is saturated and new requests wait.
+	ObserveInFlight(inFlight, limit int)
+}
+
+// NopMetricsRecorder is MetricsRecorder which discards all metrics. Used when VMSConfig.Metrics is nil.
+type NopMetricsRecorder struct{}
+
+func (NopMetricsRecorder) ObserveRequest(string, string, int, time.Duration) {}
+func (NopMetricsRecorder) ObserveRetry(string, string, int)                  {}
+func (NopMetricsRecorder) ObserveAuthRefresh(bool, time.Duration)            {}
+func (NopMetricsRecorder) ObserveInFlight(int, int)                          {}
+
+// metricsRecorder returns MetricsRecorder from config or NopMetricsRecorder if not set.
+func metricsRecorder(config *VMSConfig) MetricsRecorder {
+	if config.Metrics == nil {
+		return NopMetricsRecorder{}
+	}
+	return config.Metrics
+}
+
+// RequestKey identifies series of request metrics in InMemoryMetrics.
+type RequestKey struct {
+	Resource string
+	Verb     string
+	Status   int
+}
+
+// InMemoryMetrics is simple MetricsRecorder which keeps counters in memory.
+// Useful in tests and for debugging.
+type InMemoryMetrics struct {
+	mu              sync.Mutex
+	requests        map[RequestKey]int
+	durations       map[RequestKey]time.Duration
+	retries         map[RequestKey]int // Status is always 0
+	authRefreshes   int
+	authRefreshFail int
+	inFlight        int
+	maxInFlight     int
+}
+
+// NewInMemoryMetrics creates empty InMemoryMetrics.
+func NewInMemoryMetrics() *InMemoryMetrics {
+	return &InMemoryMetrics{
+		requests:  make(map[RequestKey]int),
+		durations: make(map[RequestKey]time.Duration),
+		retries:   make(map[RequestKey]int),
+	}
+}
+
+func (m *InMemoryMetrics) ObserveRequest(resource, verb string, status int, duration time.Duration) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	key := RequestKey{Resource: resource, Verb: verb, Status: status}
+	m.requests[key]++
+	m.durations[key] += duration
+}
+
+func (m *InMemoryMetrics) ObserveRetry(resource, verb string, _ int) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.retries[RequestKey{Resource: resource, Verb: verb}]++
+}
+
+func (m *InMemoryMetrics) ObserveAuthRefresh(success bool, _ time.Duration) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.authRefreshes++
+	if !success {
+		m.authRefreshFail++
+	}
+}
+
+func (m *InMemoryMetrics) ObserveInFlight(inFlight, _ int) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.inFlight = inFlight
+	m.maxInFlight = max(m.maxInFlight, inFlight)
+}
+
+// InFlight returns current and max observed number of in-flight requests.
+func (m *InMemoryMetrics) InFlight() (current, peak int) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.inFlight, m.maxInFlight
+}
+
+// Requests returns number of observed requests per resource/verb/status.
+func (m *InMemoryMetrics) Requests() map[RequestKey]int {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	requests := make(map[RequestKey]int, len(m.requests))
+	for key, count := range m.requests {
+		requests[key] = count
+	}
+	return requests
+}
+
+// RequestCount returns number of observed requests for resource and verb with given status.
+func (m *InMemoryMetrics) RequestCount(resource, verb string, status int) int {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.requests[RequestKey{Resource: resource, Verb: verb, Status: status}]
+}
+
+// TotalDuration returns total duration of observed requests for resource and verb with given status.
+func (m *InMemoryMetrics) TotalDuration(resource, verb string, status int) time.Duration {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.durations[RequestKey{Resource: resource, Verb: verb, Status: status}]
+}
+
+// RetryCount returns number of observed retries for resource and verb.
+func (m *InMemoryMetrics) RetryCount(resource, verb string) int {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.retries[RequestKey{Resource: resource, Verb: verb}]
+}
+
+// AuthRefreshes returns total number of token refresh attempts and number of failed ones.
+func (m *InMemoryMetrics) AuthRefreshes() (total, failed int) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.authRefreshes, m.authRefreshFail
+}
@@ -0,0 +1,150 @@
+package vast_client
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+const (
+	oidcExpiryMargin         = 30 * time.Second // How long before expiration OIDC access token is requested again (at most half of token lifetime)
+	defaultOidcTokenLifetime = time.Minute      // Lifetime of access token if identity provider doesn't report expires_in
+)
+
+// OIDCConfig configures OAuth2 client-credentials authentication against external identity provider (e.g. Keycloak).
+type OIDCConfig struct {
+	// TokenURL is token endpoint of identity provider
+	// (e.g. "https://keycloak.local/realms/vast/protocol/openid-connect/token").
+	TokenURL     string
+	ClientID     string
+	ClientSecret string
+	Scopes       []string // Optional scopes requested with token
+}
+
+// validate checks that required OIDCConfig fields are set.
+func (c *OIDCConfig) validate() error {
+	var missing []string
+	if c.TokenURL == "" {
+		missing = append(missing, "TokenURL")
+	}
+	if c.ClientID == "" {
+		missing = append(missing, "ClientID")
+	}
+	if c.ClientSecret == "" {
+		missing = append(missing, "ClientSecret")
+	}
+	if len(missing) > 0 {
+		return fmt.Errorf("invalid OIDC config: %s must be set", strings.Join(missing, ", "))
+	}
+	if _, err := url.Parse(c.TokenURL); err != nil {
+		return fmt.Errorf("invalid OIDC token url %q: %w", c.TokenURL, err)
+	}
+	return nil
+}
+
+// OIDCAuthenticator authenticates requests with access token obtained from identity provider
+// with OAuth2 client-credentials grant. Token is cached until it expires.
+type OIDCAuthenticator struct {
+	Config *OIDCConfig
+
+	accessToken string
+	expiry      time.Time
+	lifetime    time.Duration // Lifetime of current access token
+	clock       clock         // Source of time for token expiry (realClock if nil)
+}
+
+type oidcTokenResponse struct {
+	AccessToken string `json:"access_token"`
+	TokenType   string `json:"token_type"`
+	ExpiresIn   int64  `json:"expires_in"` // Seconds
+}
+
+func (auth *OIDCAuthenticator) getClock() clock {
+	if auth.clock == nil {
+		return realClock{}
+	}
+	return auth.clock
+}
+
+// expiryMargin returns how long before expiration token is requested again. Margin is clamped to half
+// of token lifetime so short-lived tokens are still reused.
+func (auth *OIDCAuthenticator) expiryMargin() time.Duration {
+	return min(oidcExpiryMargin, auth.lifetime/2)
+}
+
+func (auth *OIDCAuthenticator) Authorize(s *VMSSession) error {
+	_, err := auth.token(s)
+	return err
+}
+
+// token makes sure access token is valid (requesting new one if needed) and returns it.
+// Token is read while session is locked because concurrent requests replace it on expiry.
+func (auth *OIDCAuthenticator) token(s *VMSSession) (string, error) {
+	s.Lock()
+	defer s.Unlock()
+	if auth.accessToken != "" && auth.getClock().Now().Before(auth.expiry.Add(-auth.expiryMargin())) {
+		return auth.accessToken, nil
+	}
+	started := time.Now()
+	err := auth.requestToken(s)
+	metricsRecorder(s.GetConfig()).ObserveAuthRefresh(err == nil, time.Since(started))
+	if err != nil {
+		return "", err
+	}
+	return auth.accessToken, nil
+}
+
+// requestToken exchanges client credentials for access token.
+// NOTE: session must be locked by caller.
+func (auth *OIDCAuthenticator) requestToken(s *VMSSession) error {
+	form := url.Values{"grant_type": {"client_credentials"}}
+	if len(auth.Config.Scopes) > 0 {
+		form.Set("scope", strings.Join(auth.Config.Scopes, " "))
+	}
+	req, err := http.NewRequest(http.MethodPost, auth.Config.TokenURL, strings.NewReader(form.Encode()))
+	if err != nil {
+		return err
+	}
+	req.SetBasicAuth(url.QueryEscape(auth.Config.ClientID), url.QueryEscape(auth.Config.ClientSecret))
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	req.Header.Set("Accept", ApplicationJson)
+	resp, err := s.tokenClient().Do(req)
+	if err != nil {
+		return fmt.Errorf("OIDC token request failed: %w", err)
+	}
+	defer resp.Body.Close()
+	if _, err = validateResponse(resp); err != nil {
+		return fmt.Errorf("OIDC token request failed: %w", err)
+	}
+	var token oidcTokenResponse
+	if err = json.NewDecoder(resp.Body).Decode(&token); err != nil {
+		return fmt.Errorf("failed to decode OIDC token response: %w", err)
+	}
+	if token.AccessToken == "" {
+		return errors.New("OIDC token response doesn't contain access_token")
+	}
+	if token.TokenType != "" && !strings.EqualFold(token.TokenType, "bearer") {
+		return fmt.Errorf("unsupported OIDC token type %q", token.TokenType)
+	}
+	auth.accessToken = token.AccessToken
+	lifetime := time.Duration(token.ExpiresIn) * time.Second
+	if lifetime <= 0 {
+		lifetime = defaultOidcTokenLifetime
+	}
+	auth.lifetime = lifetime
+	auth.expiry = auth.getClock().Now().Add(lifetime)
+	return nil
+}
+
+func (auth *OIDCAuthenticator) SetAuthHeader(s *VMSSession, headers *http.Header) error {
+	token, err := auth.token(s)
+	if err != nil {
+		return err
+	}
+	headers.Set("Authorization", "Bearer "+token)
+	return nil
+}
@@ -0,0 +1,220 @@
+package vast_client
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeIdP emulates token endpoint of identity provider issuing "oidc-N" access tokens with given lifetime.
+type fakeIdP struct {
+	*httptest.Server
+	requests atomic.Int32
+}
+
+func newFakeIdP(t *testing.T, expiresIn int64, respond func(w http.ResponseWriter, n int32) bool) *fakeIdP {
+	t.Helper()
+	idp := &fakeIdP{}
+	idp.Server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		n := idp.requests.Add(1)
+		clientID, clientSecret, ok := r.BasicAuth()
+		if !ok || clientID != "vast-client" || clientSecret != "s3cret" || r.FormValue("grant_type") != "client_credentials" {
+			writeTestJSON(w, http.StatusUnauthorized, map[string]string{"error": "invalid_client"})
+			return
+		}
+		if respond != nil && respond(w, n) {
+			return
+		}
+		writeTestJSON(w, http.StatusOK, map[string]any{
+			"access_token": fmt.Sprintf("oidc-%d", n), "token_type": "Bearer", "expires_in": expiresIn, "scope": r.FormValue("scope"),
+		})
+	}))
+	t.Cleanup(idp.Close)
+	return idp
+}
+
+// oidcConfig returns VMSConfig pointing to srv with OIDC authentication against idp.
+func oidcConfig(srv *testServer, idp *fakeIdP) *VMSConfig {
+	return &VMSConfig{
+		BaseURL: srv.URL,
+		OIDC:    &OIDCConfig{TokenURL: idp.URL, ClientID: "vast-client", ClientSecret: "s3cret", Scopes: []string{"vms"}},
+	}
+}
+
+func TestOIDCAuthenticator(t *testing.T) {
+	tests := []struct {
+		name              string
+		expiresIn         int64
+		advance           time.Duration // Time passing between requests
+		wantAuthorization []string
+	}{
+		{name: "token is cached", expiresIn: 300, wantAuthorization: []string{"Bearer oidc-1", "Bearer oidc-1", "Bearer oidc-1"}},
+		{name: "default lifetime", expiresIn: 0, wantAuthorization: []string{"Bearer oidc-1", "Bearer oidc-1", "Bearer oidc-1"}},
+		// Margin is clamped to half of lifetime so token shorter than oidcExpiryMargin is still reused
+		{name: "short lifetime is cached", expiresIn: 10, advance: 4 * time.Second, wantAuthorization: []string{"Bearer oidc-1", "Bearer oidc-1", "Bearer oidc-2"}},
+		{name: "refresh within margin", expiresIn: 300, advance: 135 * time.Second, wantAuthorization: []string{"Bearer oidc-1", "Bearer oidc-1", "Bearer oidc-2"}},
+		{name: "refresh on expiry", expiresIn: 10, advance: 10 * time.Second, wantAuthorization: []string{"Bearer oidc-1", "Bearer oidc-2", "Bearer oidc-3"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			srv := newTestServer(t, recordsHandler())
+			idp := newFakeIdP(t, tt.expiresIn, nil)
+			rest := newTestRest(t, oidcConfig(srv, idp))
+			clock := newFakeClock()
+			rest.Session.(*VMSSession).auth.(*OIDCAuthenticator).clock = clock
+
+			for range len(tt.wantAuthorization) {
+				_, err := rest.Views.List(context.Background(), nil)
+				require.NoError(t, err)
+				clock.Advance(tt.advance)
+			}
+
+			var authorization []string
+			for _, r := range srv.Requests() {
+				authorization = append(authorization, r.Header.Get("Authorization"))
+			}
+			assert.Equal(t, tt.wantAuthorization, authorization)
+			assert.Equal(t, 0, srv.TokenRequests(), "VMS token endpoint is not used")
+		})
+	}
+}
+
+func TestOIDCAuthenticatorErrors(t *testing.T) {
+	tests := []struct {
+		name    string
+		respond func(w http.ResponseWriter, n int32) bool
+		secret  string
+		wantErr string
+	}{
+		{name: "invalid client", secret: "wrong", wantErr: "OIDC token request failed"},
+		{
+			name: "missing access token",
+			respond: func(w http.ResponseWriter, _ int32) bool {
+				writeTestJSON(w, http.StatusOK, map[string]any{"token_type": "Bearer"})
+				return true
+			},
+			wantErr: "doesn't contain access_token",
+		},
+		{
+			name: "unsupported token type",
+			respond: func(w http.ResponseWriter, _ int32) bool {
+				writeTestJSON(w, http.StatusOK, map[string]any{"access_token": "mac", "token_type": "MAC"})
+				return true
+			},
+			wantErr: `unsupported OIDC token type "MAC"`,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			srv := newTestServer(t, recordsHandler())
+			idp := newFakeIdP(t, 300, tt.respond)
+			config := oidcConfig(srv, idp)
+			if tt.secret != "" {
+				config.OIDC.ClientSecret = tt.secret
+			}
+			rest := newTestRest(t, config)
+
+			_, err := rest.Views.List(context.Background(), nil)
+			assert.ErrorContains(t, err, tt.wantErr)
+			assert.Empty(t, srv.Requests())
+		})
+	}
+}
+
+func TestOIDCConfigValidation(t *testing.T) {
+	oidc := &OIDCConfig{TokenURL: "https://keycloak.local/token", ClientID: "vast-client", ClientSecret: "s3cret"}
+	tests := []struct {
+		name    string
+		config  *VMSConfig
+		wantErr string
+	}{
+		{name: "valid", config: &VMSConfig{Host: "vms.local", OIDC: oidc}},
+		{
+			name:    "combined with username",
+			config:  &VMSConfig{Host: "vms.local", OIDC: oidc, Username: "admin", Password: "123456"},
+			wantErr: "OIDC cannot be combined with other authentication methods",
+		},
+		{
+			name:    "combined with api token",
+			config:  &VMSConfig{Host: "vms.local", OIDC: oidc, ApiToken: testApiToken},
+			wantErr: "OIDC cannot be combined with other authentication methods",
+		},
+		{
+			name:    "missing fields",
+			config:  &VMSConfig{Host: "vms.local", OIDC: &OIDCConfig{TokenURL: "https://keycloak.local/token"}},
+			wantErr: "ClientID, ClientSecret must be set",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := newVMSRest(tt.config)
+			if tt.wantErr == "" {
+				require.NoError(t, err)
+				return
+			}
+			assert.ErrorContains(t, err, tt.wantErr)
+		})
+	}
+}
+
+// steppingClock is clock which moves forward by step on every Now call.
+type steppingClock struct {
+	ticks atomic.Int64
+	step  time.Duration
+}
+
+func (c *steppingClock) Now() time.Time {
+	return time.Unix(0, 0).Add(time.Duration(c.ticks.Add(1)) * c.step)
+}
+
+func (c *steppingClock) After(d time.Duration) <-chan time.Time {
+	return time.After(d)
+}
+
+func TestOIDCSetAuthHeaderConcurrent(t *testing.T) {
+	srv := newTestServer(t, recordsHandler())
+	idp := newFakeIdP(t, 1, nil)
+	rest := newTestRest(t, oidcConfig(srv, idp))
+	session := rest.Session.(*VMSSession)
+	auth := session.auth.(*OIDCAuthenticator)
+	auth.clock = &steppingClock{step: time.Second} // Token is expired on every call
+
+	var wg sync.WaitGroup
+	for range 8 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for range 10 {
+				headers := http.Header{}
+				if assert.NoError(t, auth.SetAuthHeader(session, &headers)) {
+					assert.True(t, strings.HasPrefix(headers.Get("Authorization"), "Bearer oidc-"))
+				}
+			}
+		}()
+	}
+	wg.Wait()
+
+	assert.Equal(t, int32(80), idp.requests.Load())
+}
+
+func TestOIDCShortLivedTokenIsReused(t *testing.T) {
+	srv := newTestServer(t, recordsHandler())
+	idp := newFakeIdP(t, 10, nil)
+	rest := newTestRest(t, oidcConfig(srv, idp))
+
+	for range 5 {
+		_, err := rest.Views.List(context.Background(), nil)
+		require.NoError(t, err)
+	}
+
+	assert.Equal(t, int32(1), idp.requests.Load(), "token living shorter than expiry margin is requested once")
+}
@@ -0,0 +1,127 @@
+package vast_client
+
+import (
+	"context"
+	"errors"
+	"fmt"
+)
+
+// Plan is ordered list of create steps applied as a unit: if any step fails, resources created by previous steps
+// are deleted in reverse order (best-effort).
+//
+// Example (NFS export = view policy + view + quota):
+//
+//	created, err := client.NewPlan().
+//		Create(rest.ViewPolicies, policyParams).
+//		CreateFn(rest.Views, func(created []client.Record) (client.Params, error) {
+//			return client.Params{"name": "export", "path": "/export", "policy_id": created[0]["id"]}, nil
+//		}).
+//		Create(rest.Quotas, quotaParams).
+//		Apply(ctx)
+type Plan struct {
+	steps []planStep
+}
+
+type planStep struct {
+	resource VastResource
+	body     func(created []Record) (Params, error)
+}
+
+// asyncCreator is implemented by all resources (see VastResourceEntry.CreateAsync).
+type asyncCreator interface {
+	CreateAsync(ctx context.Context, body Params, opts ...WaitTaskOptions) (Record, error)
+}
+
+// matchedDeleter is implemented by all resources (see VastResourceEntry.deleteMatched).
+type matchedDeleter interface {
+	deleteMatched(ctx context.Context, record Record, params Params, opts DeleteAllOptions) error
+}
+
+// PlanError is returned by Plan.Apply when step fails. Errors of rollback (if any) are reported alongside original error.
+type PlanError struct {
+	Step         int    // Index of failed step
+	Resource     string // Resource type of failed step
+	Err          error
+	RollbackErrs []error // Errors of deletion of resources created by previous steps
+}
+
+func (e *PlanError) Error() string {
+	msg := fmt.Sprintf("plan step %d (%s) failed: %v", e.Step, e.Resource, e.Err)
+	if len(e.RollbackErrs) > 0 {
+		msg += fmt.Sprintf("; rollback failed: %v", errors.Join(e.RollbackErrs...))
+	}
+	return msg
+}
+
+func (e *PlanError) Unwrap() error {
+	return e.Err
+}
+
+// NewPlan creates empty Plan.
+func NewPlan() *Plan {
+	return &Plan{}
+}
+
+// Create adds step which creates resource with given body.
+func (p *Plan) Create(resource VastResource, body Params) *Plan {
+	return p.CreateFn(resource, func([]Record) (Params, error) { return body, nil })
+}
+
+// CreateFn adds step which creates resource with body built by fn from records created by previous steps
+// (e.g. to reference id of created policy). Error returned by fn fails the step.
+func (p *Plan) CreateFn(resource VastResource, fn func(created []Record) (Params, error)) *Plan {
+	p.steps = append(p.steps, planStep{resource: resource, body: fn})
+	return p
+}
+
+// Apply runs steps in order and returns created records. If VMS creates resource asynchronously, creation task
+// is awaited (see CreateAsync). If step fails, resources created by previous steps are deleted in reverse order
+// and PlanError is returned. Rollback is performed even if ctx is canceled.
+func (p *Plan) Apply(ctx context.Context) ([]Record, error) {
+	created := make([]Record, 0, len(p.steps))
+	for i, step := range p.steps {
+		record, err := p.applyStep(ctx, step, created)
+		if err != nil {
+			return nil, &PlanError{
+				Step:         i,
+				Resource:     step.resource.GetResourceType(),
+				Err:          err,
+				RollbackErrs: p.rollback(context.WithoutCancel(ctx), created),
+			}
+		}
+		created = append(created, record)
+	}
+	return created, nil
+}
+
+func (p *Plan) applyStep(ctx context.Context, step planStep, created []Record) (Record, error) {
+	body, err := step.body(created)
+	if err != nil {
+		return nil, err
+	}
+	if creator, ok := step.resource.(asyncCreator); ok {
+		return creator.CreateAsync(ctx, body)
+	}
+	return step.resource.Create(ctx, body)
+}
+
+// rollback deletes created resources in reverse order and returns deletion errors.
+func (p *Plan) rollback(ctx context.Context, created []Record) []error {
+	var errs []error
+	for i := len(created) - 1; i >= 0; i-- {
+		resource := p.steps[i].resource
+		var err error
+		if deleter, ok := resource.(matchedDeleter); ok {
+			err = deleter.deleteMatched(ctx, created[i], nil, DeleteAllOptions{})
+		} else {
+			var id int64
+			if id, err = created[i].ID(); err == nil {
+				_, err = resource.DeleteById(ctx, id)
+			}
+		}
+		if err != nil {
+			errs = append(errs, fmt.Errorf("step %d (%s): %w", i, resource.GetResourceType(), err))
+		}
+	}
+	return errs
+}
@@ -0,0 +1,170 @@
+package vast_client
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// Default polling settings (see PollOptions)
+const (
+	defaultPollTimeout       = time.Minute * 2
+	defaultPollInterval      = time.Millisecond * 500
+	defaultPollMaxInterval   = time.Second * 5
+	defaultPollBackoffFactor = 1.5
+)
+
+// PollOptions controls how often and how long resource state is polled (see WaitForState, WaitTask).
+// Zero values are replaced with defaults.
+type PollOptions struct {
+	Timeout       time.Duration // Total time to wait. Default is VMSConfig.Timeouts.LongRunning or 2 minutes. Deadline of context is respected as well.
+	Interval      time.Duration // Initial interval between polls. Default is 500ms.
+	MaxInterval   time.Duration // Upper bound for interval between polls. Default is 5s.
+	BackoffFactor float64       // Multiplier applied to interval after each poll. Default is 1.5.
+}
+
+// withDefaults returns copy of options where zero values are replaced with defaults.
+func (o PollOptions) withDefaults() PollOptions {
+	if o.Timeout <= 0 {
+		o.Timeout = defaultPollTimeout
+	}
+	if o.Interval <= 0 {
+		o.Interval = defaultPollInterval
+	}
+	if o.MaxInterval <= 0 {
+		o.MaxInterval = defaultPollMaxInterval
+	}
+	if o.MaxInterval < o.Interval {
+		o.MaxInterval = o.Interval
+	}
+	if o.BackoffFactor < 1 {
+		o.BackoffFactor = defaultPollBackoffFactor
+	}
+	return o
+}
+
+// WaitTaskOptions controls polling of VTask state in WaitTask.
+type WaitTaskOptions = PollOptions
+
+// firstOrDefault returns first provided options or zero options (defaults).
+// Used by methods that accept options as optional variadic argument.
+func firstOrDefault[T any](opts []T) T {
+	var zero T
+	if len(opts) > 0 {
+		return opts[0]
+	}
+	return zero
+}
+
+// poll calls check until it reports done or returns an error.
+// Interval between calls grows by BackoffFactor up to MaxInterval.
+// If context is done (or Timeout is over) context error is returned.
+func poll(ctx context.Context, opts PollOptions, check func(context.Context) (bool, error)) error {
+	opts = opts.withDefaults()
+	ctx, cancel := context.WithTimeout(ctx, opts.Timeout)
+	defer cancel()
+
+	interval := opts.Interval
+	for {
+		done, err := check(ctx)
+		if err != nil {
+			if ctxErr := ctx.Err(); ctxErr != nil {
+				// Request failed because we are out of time.
+				return ctxErr
+			}
+			return err
+		}
+		if done {
+			return nil
+		}
+		timer := time.NewTimer(interval)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return ctx.Err()
+		case <-timer.C:
+		}
+		// Backoff logic
+		interval = min(time.Duration(float64(interval)*opts.BackoffFactor), opts.MaxInterval)
+	}
+}
+
+// ResourceStateError is returned by WaitForState when resource reaches one of failed states.
+type ResourceStateError struct {
+	Resource string
+	Id       int64
+	Field    string
+	State    string
+}
+
+func (e *ResourceStateError) Error() string {
+	return fmt.Sprintf("resource '%s' with id %d reached failed %s %q", e.Resource, e.Id, e.Field, e.State)
+}
+
+// WaitForState polls resource by id until value of field matches one of desired values.
+// Comparison is case-insensitive. If value matches one of failed values ResourceStateError is returned immediately.
+// If resource doesn't reach desired state in time, error wrapping context error is returned.
+// NOTE: Last fetched Record is returned along with error (if any) so callers can inspect it.
+func (e *VastResourceEntry) WaitForState(ctx context.Context, id int64, field string, desired []string, failed []string, opts PollOptions) (Record, error) {
+	return e.waitForState(ctx, id, field, desired, failed, opts, nil)
+}
+
+// waitForState implements WaitForState. onPoll (if not nil) is called with every fetched Record
+// before its state is checked (e.g. to report progress).
+func (e *VastResourceEntry) waitForState(ctx context.Context, id int64, field string, desired []string, failed []string, opts PollOptions, onPoll func(Record)) (Record, error) {
+	var (
+		record Record
+		state  string
+	)
+	if opts.Timeout <= 0 {
+		opts.Timeout = e.Session().GetConfig().Timeouts.LongRunning
+	}
+	err := poll(ctx, opts, func(ctx context.Context) (bool, error) {
+		var err error
+		if record, err = e.GetById(ctx, id); err != nil {
+			return false, err
+		}
+		if onPoll != nil {
+			onPoll(record)
+		}
+		state = fmt.Sprintf("%v", record[field])
+		if containsFold(failed, state) {
+			return false, &ResourceStateError{Resource: e.resourcePath, Id: id, Field: field, State: state}
+		}
+		return containsFold(desired, state), nil
+	})
+	if isContextErr(err) {
+		err = fmt.Errorf("resource '%s' with id %d did not reach %s %v (last %s: %q): %w", e.resourcePath, id, field, desired, field, state, err)
+	}
+	return record, err
+}
+
+// containsFold reports whether value is in values (case-insensitive).
+func containsFold(values []string, value string) bool {
+	for _, v := range values {
+		if strings.EqualFold(v, value) {
+			return true
+		}
+	}
+	return false
+}
+
+// WaitForDeletion polls resource by id until it is not found anymore.
+// Many VAST resources are deleted asynchronously so resource can still be returned for a while after DELETE.
+func (e *VastResourceEntry) WaitForDeletion(ctx context.Context, id int64, opts PollOptions) error {
+	if opts.Timeout <= 0 {
+		opts.Timeout = e.Session().GetConfig().Timeouts.LongRunning
+	}
+	err := poll(ctx, opts, func(ctx context.Context) (bool, error) {
+		_, err := e.GetById(ctx, id)
+		if isNotFoundErr(err) {
+			return true, nil
+		}
+		return false, err
+	})
+	if isContextErr(err) {
+		err = fmt.Errorf("resource '%s' with id %d still exists: %w", e.resourcePath, id, err)
+	}
+	return err
+}
@@ -0,0 +1,104 @@
+package vast_client
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCreatePreflight(t *testing.T) {
+	tests := []struct {
+		name        string
+		create      func(ctx context.Context, rest *VMSRest) error
+		disable     bool
+		wantMissing []string
+	}{
+		{
+			name: "view missing all required fields",
+			create: func(ctx context.Context, rest *VMSRest) error {
+				_, err := rest.Views.Create(ctx, Params{"name": "v"})
+				return err
+			},
+			wantMissing: []string{"path", "policy_id"},
+		},
+		{
+			name: "null value is missing",
+			create: func(ctx context.Context, rest *VMSRest) error {
+				_, err := rest.Quotas.Create(ctx, Params{"path": nil})
+				return err
+			},
+			wantMissing: []string{"path"},
+		},
+		{
+			name: "ensure merges search params",
+			create: func(ctx context.Context, rest *VMSRest) error {
+				_, err := rest.Users.Ensure(ctx, "user", Params{})
+				return err
+			},
+			wantMissing: []string{"uid"},
+		},
+		{
+			name: "complete body",
+			create: func(ctx context.Context, rest *VMSRest) error {
+				_, err := rest.Views.Create(ctx, Params{"path": "/v", "policy_id": 1})
+				return err
+			},
+		},
+		{
+			name: "resource without declaration",
+			create: func(ctx context.Context, rest *VMSRest) error {
+				_, err := rest.Nis.Create(ctx, Params{})
+				return err
+			},
+		},
+		{
+			name:    "disabled preflight",
+			disable: true,
+			create: func(ctx context.Context, rest *VMSRest) error {
+				_, err := rest.Views.Create(ctx, Params{"name": "v"})
+				return err
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			srv := newTestServer(t, recordsHandler())
+			config := srv.config()
+			config.DisableCreatePreflight = tt.disable
+			rest := newTestRest(t, config)
+
+			err := tt.create(context.Background(), rest)
+
+			if tt.wantMissing == nil {
+				require.NoError(t, err)
+				return
+			}
+			var validationErr *ValidationError
+			require.ErrorAs(t, err, &validationErr)
+			assert.Equal(t, tt.wantMissing, validationErr.Fields)
+			assert.Len(t, validationErr.Problems, len(tt.wantMissing))
+			for _, r := range srv.Requests() {
+				assert.NotEqual(t, "POST", r.Method, "no create request is sent on failed preflight")
+			}
+		})
+	}
+}
+
+func TestSetRequiredOnCreate(t *testing.T) {
+	srv := newTestServer(t, recordsHandler())
+	rest := newTestRest(t, srv.config())
+	ctx := context.Background()
+
+	rest.Nis.SetRequiredOnCreate("domain_name", "servers")
+	_, err := rest.Nis.Create(ctx, Params{"servers": []string{"10.0.0.1"}})
+	var validationErr *ValidationError
+	require.ErrorAs(t, err, &validationErr)
+	assert.Equal(t, []string{"domain_name"}, validationErr.Fields)
+
+	rest.Views.SetRequiredOnCreate()
+	_, err = rest.Views.Create(ctx, Params{})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"POST views"}, methodsAndPaths(srv.Requests()))
+}
@@ -0,0 +1,133 @@
+package vast_client
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"sort"
+	"strings"
+)
+
+//  ######################################################
+//              QUERY PARAMS HELPERS
+//  ######################################################
+
+// WithFields returns copy of params with "fields" query parameter which makes VMS return only selected
+// attributes of resources (e.g. WithFields(Params{"tenant_id": 1}, "id", "name", "path")).
+// Records filled into structs with Record.Fill leave fields missing in partial record with zero values.
+func WithFields(params Params, fields ...string) Params {
+	result := Params{}
+	result.Update(params, false)
+	if len(fields) > 0 {
+		result["fields"] = strings.Join(fields, ",")
+	}
+	return result
+}
+
+// ListFields retrieves all resources matching the given parameters but only with selected attributes (see WithFields).
+func (e *VastResourceEntry) ListFields(ctx context.Context, params Params, fields ...string) (RecordSet, error) {
+	return e.List(ctx, WithFields(params, fields...))
+}
+
+// Keys of params consumed by List (see Limit and Offset). They are never sent to VMS as is.
+const (
+	limitParam  = "limit"
+	offsetParam = "offset"
+)
+
+// OrderBy returns copy of params with ordering of results. Prefix field with "-" for descending order
+// (e.g. Params{}.OrderBy("-created")). Multiple fields are applied in order.
+func (pr Params) OrderBy(fields ...string) Params {
+	return pr.with("ordering", strings.Join(fields, ","))
+}
+
+// Limit returns copy of params which makes List return at most n resources.
+// Only pages required to collect n resources are fetched.
+func (pr Params) Limit(n int) Params {
+	return pr.with(limitParam, n)
+}
+
+// Offset returns copy of params which makes List skip first n resources.
+func (pr Params) Offset(n int) Params {
+	return pr.with(offsetParam, n)
+}
+
+func (pr Params) with(key string, value any) Params {
+	result := Params{}
+	result.Update(pr, false)
+	result[key] = value
+	return result
+}
+
+// extractLimitOffset removes limit and offset from params and validates them.
+// Returns copy of params without these keys, limit (-1 if not set) and offset.
+func (e *VastResourceEntry) extractLimitOffset(params Params) (Params, int, int, error) {
+	limit, offset := -1, 0
+	_, hasLimit := params[limitParam]
+	_, hasOffset := params[offsetParam]
+	if !hasLimit && !hasOffset {
+		return params, limit, offset, nil
+	}
+	query := Params{}
+	query.Update(params, false)
+	delete(query, limitParam)
+	delete(query, offsetParam)
+	var problems, fields []string
+	for key, target := range map[string]*int{limitParam: &limit, offsetParam: &offset} {
+		raw, ok := params[key]
+		if !ok {
+			continue
+		}
+		n, err := toIntIfString[int](raw)
+		if err == nil && n < 0 {
+			err = fmt.Errorf("must not be negative, got %d", n)
+		}
+		if err != nil {
+			fields = append(fields, key)
+			problems = append(problems, fmt.Sprintf("%s: %v", key, err))
+			continue
+		}
+		*target = n
+	}
+	if len(problems) > 0 {
+		sort.Strings(fields)
+		sort.Strings(problems)
+		return nil, 0, 0, &ValidationError{Resource: e.resourcePath, Fields: fields, Problems: problems}
+	}
+	return query, limit, offset, nil
+}
+
+// listLimited lists resources applying limit and offset on client side.
+// If limit is set only first page with size enough to cover offset+limit resources is requested.
+func (e *VastResourceEntry) listLimited(ctx context.Context, query Params, limit, offset int) (RecordSet, error) {
+	var (
+		result RecordSet
+		err    error
+	)
+	if limit >= 0 {
+		query["page_size"] = max(offset+limit, 1)
+		query["page"] = 1
+		result, err = request[RecordSet](ctx, e, http.MethodGet, e.resourcePath, e.apiVersion, query, nil)
+	} else {
+		result, err = e.listAllPages(ctx, query)
+	}
+	if err != nil {
+		return nil, err
+	}
+	if offset >= len(result) {
+		return RecordSet{}, nil
+	}
+	result = result[offset:]
+	if limit >= 0 && limit < len(result) {
+		result = result[:limit]
+	}
+	return result, nil
+}
+
+// ListLatest returns up to n resources with highest values of orderField (e.g. ListLatest(ctx, 10, "created")).
+func (e *VastResourceEntry) ListLatest(ctx context.Context, n int, orderField string) (RecordSet, error) {
+	if !strings.HasPrefix(orderField, "-") {
+		orderField = "-" + orderField
+	}
+	return e.List(ctx, Params{}.OrderBy(orderField).Limit(n))
+}
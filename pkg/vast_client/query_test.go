@@ -0,0 +1,124 @@
+package vast_client
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestListOrderingAndLimit(t *testing.T) {
+	records := []map[string]any{{"id": 1}, {"id": 2}, {"id": 3}, {"id": 4}, {"id": 5}}
+	tests := []struct {
+		name      string
+		list      func(ctx context.Context, e *VastResourceEntry) (RecordSet, error)
+		wantQuery string
+		wantIds   []any
+		wantErr   []string // Fields reported in ValidationError
+	}{
+		{
+			name: "order by",
+			list: func(ctx context.Context, e *VastResourceEntry) (RecordSet, error) {
+				return e.List(ctx, Params{}.OrderBy("-created", "name"))
+			},
+			wantQuery: "ordering=-created%2Cname",
+			wantIds:   []any{1.0, 2.0, 3.0, 4.0, 5.0},
+		},
+		{
+			name: "limit",
+			list: func(ctx context.Context, e *VastResourceEntry) (RecordSet, error) {
+				return e.List(ctx, Params{}.Limit(2))
+			},
+			wantQuery: "page=1&page_size=2",
+			wantIds:   []any{1.0, 2.0},
+		},
+		{
+			name: "limit and offset",
+			list: func(ctx context.Context, e *VastResourceEntry) (RecordSet, error) {
+				return e.List(ctx, Params{"tenant_id": 1}.OrderBy("id").Offset(2).Limit(2))
+			},
+			wantQuery: "ordering=id&page=1&page_size=4&tenant_id=1",
+			wantIds:   []any{3.0, 4.0},
+		},
+		{
+			name: "offset only",
+			list: func(ctx context.Context, e *VastResourceEntry) (RecordSet, error) {
+				return e.List(ctx, Params{}.Offset(3))
+			},
+			wantQuery: "",
+			wantIds:   []any{4.0, 5.0},
+		},
+		{
+			name: "offset beyond results",
+			list: func(ctx context.Context, e *VastResourceEntry) (RecordSet, error) {
+				return e.List(ctx, Params{}.Offset(10))
+			},
+			wantQuery: "",
+			wantIds:   []any{},
+		},
+		{
+			name: "string limit",
+			list: func(ctx context.Context, e *VastResourceEntry) (RecordSet, error) {
+				return e.List(ctx, Params{"limit": "1"})
+			},
+			wantQuery: "page=1&page_size=1",
+			wantIds:   []any{1.0},
+		},
+		{
+			name: "negative limit and offset",
+			list: func(ctx context.Context, e *VastResourceEntry) (RecordSet, error) {
+				return e.List(ctx, Params{}.Limit(-1).Offset(-5))
+			},
+			wantErr: []string{"limit", "offset"},
+		},
+		{
+			name: "latest",
+			list: func(ctx context.Context, e *VastResourceEntry) (RecordSet, error) {
+				return e.ListLatest(ctx, 3, "created")
+			},
+			wantQuery: "ordering=-created&page=1&page_size=3",
+			wantIds:   []any{1.0, 2.0, 3.0},
+		},
+		{
+			name:      "latest with explicit descending field",
+			list:      func(ctx context.Context, e *VastResourceEntry) (RecordSet, error) { return e.ListLatest(ctx, 1, "-id") },
+			wantQuery: "ordering=-id&page=1&page_size=1",
+			wantIds:   []any{1.0},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			srv := newTestServer(t, recordsHandler(records...))
+			rest := newTestRest(t, srv.config())
+
+			result, err := tt.list(context.Background(), rest.Snapshots.VastResourceEntry)
+
+			if tt.wantErr != nil {
+				var validationErr *ValidationError
+				require.ErrorAs(t, err, &validationErr)
+				assert.Equal(t, tt.wantErr, validationErr.Fields)
+				assert.Empty(t, srv.Requests())
+				return
+			}
+			require.NoError(t, err)
+			requests := srv.Requests()
+			require.Len(t, requests, 1)
+			assert.Equal(t, tt.wantQuery, requests[0].Query.Encode())
+			ids := []any{}
+			for _, r := range result {
+				ids = append(ids, r["id"])
+			}
+			assert.Equal(t, tt.wantIds, ids)
+		})
+	}
+}
+
+func TestParamsHelpersDoNotModifyReceiver(t *testing.T) {
+	params := Params{"name": "a"}
+
+	ordered := params.OrderBy("-id").Limit(1).Offset(2)
+
+	assert.Equal(t, Params{"name": "a"}, params)
+	assert.Equal(t, Params{"name": "a", "ordering": "-id", "limit": 1, "offset": 2}, ordered)
+}
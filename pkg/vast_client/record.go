@@ -0,0 +1,298 @@
+package vast_client
+
+import (
+	"cmp"
+	"encoding/json"
+	"fmt"
+	"math"
+	"sort"
+	"strings"
+)
+
+//  ######################################################
+//              RECORD ACCESSORS
+//  ######################################################
+
+// MissingKeyError is returned by Record getters when key is absent (or its value is null).
+type MissingKeyError struct {
+	Key string
+}
+
+func (e *MissingKeyError) Error() string {
+	return fmt.Sprintf("key %q not found in record", e.Key)
+}
+
+// WrongTypeError is returned by Record getters when value has unexpected type.
+type WrongTypeError struct {
+	Key      string
+	Expected string
+	Value    any
+}
+
+func (e *WrongTypeError) Error() string {
+	return fmt.Sprintf("key %q: expected %s, got %T (%v)", e.Key, e.Expected, e.Value, e.Value)
+}
+
+// lookup returns value of key or MissingKeyError.
+func (r Record) lookup(key string) (any, error) {
+	val, ok := r[key]
+	if !ok || val == nil {
+		return nil, &MissingKeyError{Key: key}
+	}
+	return val, nil
+}
+
+// GetString returns string value of key.
+func (r Record) GetString(key string) (string, error) {
+	val, err := r.lookup(key)
+	if err != nil {
+		return "", err
+	}
+	s, ok := val.(string)
+	if !ok {
+		return "", &WrongTypeError{Key: key, Expected: "string", Value: val}
+	}
+	return s, nil
+}
+
+// GetInt64 returns integer value of key. JSON numbers (float64) are accepted if they have no fraction part.
+func (r Record) GetInt64(key string) (int64, error) {
+	val, err := r.lookup(key)
+	if err != nil {
+		return 0, err
+	}
+	switch v := val.(type) {
+	case float64:
+		if v != math.Trunc(v) {
+			return 0, &WrongTypeError{Key: key, Expected: "integer", Value: val}
+		}
+		return int64(v), nil
+	case int:
+		return int64(v), nil
+	case int64:
+		return v, nil
+	default:
+		return 0, &WrongTypeError{Key: key, Expected: "integer", Value: val}
+	}
+}
+
+// GetFloat returns numeric value of key as float64.
+func (r Record) GetFloat(key string) (float64, error) {
+	val, err := r.lookup(key)
+	if err != nil {
+		return 0, err
+	}
+	switch v := val.(type) {
+	case float64:
+		return v, nil
+	case int:
+		return float64(v), nil
+	case int64:
+		return float64(v), nil
+	default:
+		return 0, &WrongTypeError{Key: key, Expected: "number", Value: val}
+	}
+}
+
+// GetBool returns boolean value of key.
+func (r Record) GetBool(key string) (bool, error) {
+	val, err := r.lookup(key)
+	if err != nil {
+		return false, err
+	}
+	b, ok := val.(bool)
+	if !ok {
+		return false, &WrongTypeError{Key: key, Expected: "bool", Value: val}
+	}
+	return b, nil
+}
+
+// GetSlice returns list value of key.
+func (r Record) GetSlice(key string) ([]any, error) {
+	val, err := r.lookup(key)
+	if err != nil {
+		return nil, err
+	}
+	switch v := val.(type) {
+	case []any:
+		return v, nil
+	case []string:
+		result := make([]any, len(v))
+		for i, s := range v {
+			result[i] = s
+		}
+		return result, nil
+	default:
+		return nil, &WrongTypeError{Key: key, Expected: "list", Value: val}
+	}
+}
+
+// GetRecord returns nested object of key as Record.
+func (r Record) GetRecord(key string) (Record, error) {
+	val, err := r.lookup(key)
+	if err != nil {
+		return nil, err
+	}
+	switch v := val.(type) {
+	case map[string]any:
+		return v, nil
+	case Record:
+		return v, nil
+	default:
+		return nil, &WrongTypeError{Key: key, Expected: "object", Value: val}
+	}
+}
+
+// ID returns value of "id" key.
+func (r Record) ID() (int64, error) {
+	return r.GetInt64("id")
+}
+
+// MustGetString is like GetString but panics on error.
+func (r Record) MustGetString(key string) string {
+	return must(r.GetString(key))
+}
+
+// MustGetInt64 is like GetInt64 but panics on error.
+func (r Record) MustGetInt64(key string) int64 {
+	return must(r.GetInt64(key))
+}
+
+// MustGetFloat is like GetFloat but panics on error.
+func (r Record) MustGetFloat(key string) float64 {
+	return must(r.GetFloat(key))
+}
+
+// MustGetBool is like GetBool but panics on error.
+func (r Record) MustGetBool(key string) bool {
+	return must(r.GetBool(key))
+}
+
+// MustGetSlice is like GetSlice but panics on error.
+func (r Record) MustGetSlice(key string) []any {
+	return must(r.GetSlice(key))
+}
+
+// MustGetRecord is like GetRecord but panics on error.
+func (r Record) MustGetRecord(key string) Record {
+	return must(r.GetRecord(key))
+}
+
+// MustID is like ID but panics on error.
+func (r Record) MustID() int64 {
+	return must(r.ID())
+}
+
+func must[T any](val T, err error) T {
+	if err != nil {
+		panic(err)
+	}
+	return val
+}
+
+//  ######################################################
+//              RECORDSET UTILITIES
+//  ######################################################
+
+// Filter returns records for which keep returns true.
+func (rs RecordSet) Filter(keep func(Record) bool) RecordSet {
+	result := RecordSet{}
+	for _, r := range rs {
+		if keep(r) {
+			result = append(result, r)
+		}
+	}
+	return result
+}
+
+// SortBy returns copy of RecordSet sorted (stable) by value of key.
+// Numbers are compared numerically (regardless of Go type), strings lexically and false < true.
+// Values of different kinds are ordered: missing/null < numbers < bools < strings < other values
+// (lists, objects) which are compared by their JSON representation.
+func (rs RecordSet) SortBy(key string, desc bool) RecordSet {
+	result := make(RecordSet, len(rs))
+	copy(result, rs)
+	sort.SliceStable(result, func(i, j int) bool {
+		if desc {
+			return compareValues(result[j][key], result[i][key]) < 0
+		}
+		return compareValues(result[i][key], result[j][key]) < 0
+	})
+	return result
+}
+
+// Pluck returns values of key for all records (nil for records without key).
+func (rs RecordSet) Pluck(key string) []any {
+	result := make([]any, len(rs))
+	for i, r := range rs {
+		result[i] = r[key]
+	}
+	return result
+}
+
+// GroupBy groups records by string representation of value of key.
+// Records without key are grouped under "<nil>".
+func (rs RecordSet) GroupBy(key string) map[string]RecordSet {
+	result := make(map[string]RecordSet)
+	for _, r := range rs {
+		group := fmt.Sprintf("%v", normalizeValue(r[key]))
+		result[group] = append(result[group], r)
+	}
+	return result
+}
+
+// Find returns first record whose value of key equals value (numbers are compared numerically).
+func (rs RecordSet) Find(key string, value any) (Record, bool) {
+	for _, r := range rs {
+		if actual, ok := r[key]; ok && valuesEqual(actual, value, false) {
+			return r, true
+		}
+	}
+	return nil, false
+}
+
+// valueKindOrder defines order of values of different kinds (see RecordSet.SortBy)
+func valueKindOrder(v any) int {
+	switch v.(type) {
+	case nil:
+		return 0
+	case float64:
+		return 1
+	case bool:
+		return 2
+	case string:
+		return 3
+	default:
+		return 4
+	}
+}
+
+// compareValues compares two JSON-like values. Returns negative number if a < b, zero if equal and positive if a > b.
+func compareValues(a, b any) int {
+	a, b = normalizeValue(a), normalizeValue(b)
+	if ka, kb := valueKindOrder(a), valueKindOrder(b); ka != kb {
+		return ka - kb
+	}
+	switch av := a.(type) {
+	case nil:
+		return 0
+	case float64:
+		return cmp.Compare(av, b.(float64))
+	case bool:
+		bv := b.(bool)
+		switch {
+		case av == bv:
+			return 0
+		case !av:
+			return -1
+		default:
+			return 1
+		}
+	case string:
+		return strings.Compare(av, b.(string))
+	default:
+		rawA, _ := json.Marshal(a)
+		rawB, _ := json.Marshal(b)
+		return strings.Compare(string(rawA), string(rawB))
+	}
+}
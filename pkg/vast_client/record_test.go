@@ -0,0 +1,89 @@
+package vast_client
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func testRecordSet() RecordSet {
+	return RecordSet{
+		{"id": float64(10), "name": "b", "tenant_id": float64(1), "size": float64(100)},
+		{"id": 2, "name": "a", "tenant_id": int64(2), "size": float64(9.5)},
+		{"id": int64(3), "name": "c", "tenant_id": float64(1)},
+	}
+}
+
+func TestRecordSetSortBy(t *testing.T) {
+	tests := []struct {
+		name    string
+		records RecordSet
+		key     string
+		desc    bool
+		want    []any
+	}{
+		{name: "numbers of mixed go types", records: testRecordSet(), key: "id", want: []any{2, int64(3), float64(10)}},
+		{name: "numbers descending", records: testRecordSet(), key: "id", desc: true, want: []any{float64(10), int64(3), 2}},
+		{name: "strings", records: testRecordSet(), key: "name", want: []any{"a", "b", "c"}},
+		{name: "missing values first", records: testRecordSet(), key: "size", want: []any{nil, float64(9.5), float64(100)}},
+		{
+			name:    "mixed kinds",
+			records: RecordSet{{"v": "x"}, {"v": true}, {"v": []any{1}}, {"v": 1.0}, {"v": nil}, {"v": false}},
+			key:     "v",
+			want:    []any{nil, 1.0, false, true, "x", []any{1}},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			original := append(RecordSet(nil), tt.records...)
+
+			sorted := tt.records.SortBy(tt.key, tt.desc)
+
+			assert.Equal(t, tt.want, sorted.Pluck(tt.key))
+			assert.Equal(t, original, tt.records, "receiver is not modified")
+		})
+	}
+
+	stable := RecordSet{{"k": 1, "v": "first"}, {"k": 1, "v": "second"}, {"k": 0, "v": "third"}}.SortBy("k", false)
+	assert.Equal(t, []any{"third", "first", "second"}, stable.Pluck("v"), "sorting is stable")
+}
+
+func TestRecordSetFilterAndFind(t *testing.T) {
+	rs := testRecordSet()
+
+	filtered := rs.Filter(func(r Record) bool { return r["tenant_id"] == float64(1) })
+	assert.Equal(t, []any{"b", "c"}, filtered.Pluck("name"))
+	assert.Equal(t, RecordSet{}, rs.Filter(func(Record) bool { return false }))
+
+	tests := []struct {
+		key    string
+		value  any
+		wantOk bool
+		want   any
+	}{
+		{key: "id", value: 3, wantOk: true, want: "c"},
+		{key: "id", value: float64(2), wantOk: true, want: "a"},
+		{key: "tenant_id", value: 1, wantOk: true, want: "b"},
+		{key: "name", value: "z"},
+		{key: "missing", value: nil},
+	}
+	for _, tt := range tests {
+		record, ok := rs.Find(tt.key, tt.value)
+		assert.Equal(t, tt.wantOk, ok, "%s=%v", tt.key, tt.value)
+		if tt.wantOk {
+			assert.Equal(t, tt.want, record["name"])
+		}
+	}
+}
+
+func TestRecordSetGroupByAndPluck(t *testing.T) {
+	rs := testRecordSet()
+
+	groups := rs.GroupBy("tenant_id")
+	assert.Len(t, groups, 2)
+	assert.Equal(t, []any{"b", "c"}, groups["1"].Pluck("name"))
+	assert.Equal(t, []any{"a"}, groups["2"].Pluck("name"))
+
+	assert.Equal(t, []any{"b", "a", "c"}, rs.GroupBy("missing")["<nil>"].Pluck("name"))
+	assert.Equal(t, []any{float64(100), float64(9.5), nil}, rs.Pluck("size"))
+}
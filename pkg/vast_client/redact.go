@@ -0,0 +1,111 @@
+package vast_client
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"sync/atomic"
+)
+
+// RedactedValue replaces values of sensitive fields in Render output, logged payloads
+// and request body copies handed to before request interceptors.
+const RedactedValue = "••••"
+
+// DefaultSensitiveKeys are names of fields whose values are redacted unless changed with SetSensitiveKeys.
+// Fields containing "password" or "secret" in name are always redacted.
+var DefaultSensitiveKeys = []string{
+	"password",
+	"bindpw",
+	"secret_key",
+	"access_key",
+	"token",
+	"api_token",
+	"access_token",
+	"refresh_token",
+	"private_key",
+	"passphrase",
+}
+
+var sensitiveKeys atomic.Pointer[map[string]bool]
+
+// SetSensitiveKeys replaces set of field names whose values are redacted (see DefaultSensitiveKeys).
+// Names are matched case-insensitively.
+func SetSensitiveKeys(keys ...string) {
+	set := make(map[string]bool, len(keys))
+	for _, key := range keys {
+		set[strings.ToLower(key)] = true
+	}
+	sensitiveKeys.Store(&set)
+}
+
+func init() {
+	SetSensitiveKeys(DefaultSensitiveKeys...)
+}
+
+// isSensitiveKey reports whether value of field must be redacted.
+func isSensitiveKey(key string) bool {
+	key = strings.ToLower(key)
+	return (*sensitiveKeys.Load())[key] || strings.Contains(key, "password") || strings.Contains(key, "secret")
+}
+
+// redact returns copy of JSON-like value with values of sensitive fields replaced with RedactedValue.
+// Original value is never modified.
+func redact(v any) any {
+	switch typed := v.(type) {
+	case Record:
+		return Record(redactMap(typed))
+	case Params:
+		return Params(redactMap(typed))
+	case map[string]any:
+		return redactMap(typed)
+	case RecordSet:
+		redacted := make(RecordSet, len(typed))
+		for i, r := range typed {
+			redacted[i] = redactMap(r)
+		}
+		return redacted
+	case []any:
+		redacted := make([]any, len(typed))
+		for i, item := range typed {
+			redacted[i] = redact(item)
+		}
+		return redacted
+	default:
+		return v
+	}
+}
+
+func redactMap(m map[string]any) map[string]any {
+	redacted := make(map[string]any, len(m))
+	for key, value := range m {
+		if isSensitiveKey(key) && value != nil {
+			redacted[key] = RedactedValue
+		} else {
+			redacted[key] = redact(value)
+		}
+	}
+	return redacted
+}
+
+// redactJSON redacts sensitive fields in JSON payload. Payload which is not valid JSON is returned as is.
+func redactJSON(raw []byte) []byte {
+	var v any
+	if err := json.Unmarshal(raw, &v); err != nil {
+		// Non-JSON bodies (e.g. multipart uploads) may carry secrets which cannot be located, so they are never exposed.
+		return []byte(fmt.Sprintf("<%d bytes of non-JSON body>", len(raw)))
+	}
+	redacted, err := json.Marshal(redact(v))
+	if err != nil {
+		return raw
+	}
+	return redacted
+}
+
+// redactApiError redacts sensitive fields in body of ApiError wrapped by err (see VMSConfig.RedactErrorBodies).
+func redactApiError(err error) {
+	var apiErr *ApiError
+	if errors.As(err, &apiErr) && apiErr.Body != "" {
+		apiErr.Body = string(redactJSON([]byte(apiErr.Body)))
+	}
+}
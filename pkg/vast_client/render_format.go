@@ -0,0 +1,211 @@
+package vast_client
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+	"sync/atomic"
+)
+
+// RenderFormat defines output format of Renderable.Render
+type RenderFormat string
+
+const (
+	RenderFormatTable RenderFormat = "table" // Human-readable gotabulate grid (default)
+	RenderFormatJSON  RenderFormat = "json"  // Indented JSON
+	RenderFormatYAML  RenderFormat = "yaml"  // Block style YAML
+)
+
+var renderFormat atomic.Pointer[RenderFormat]
+
+// SetDefaultRenderFormat sets format used by Render of Record, RecordSet and EmptyRecord.
+// It affects all Renderable consumers (e.g. AfterRequestFn loggers) without code changes.
+func SetDefaultRenderFormat(format RenderFormat) error {
+	switch format {
+	case RenderFormatTable, RenderFormatJSON, RenderFormatYAML:
+		renderFormat.Store(&format)
+		return nil
+	default:
+		return fmt.Errorf("unknown render format %q, expected one of %q, %q, %q", format, RenderFormatTable, RenderFormatJSON, RenderFormatYAML)
+	}
+}
+
+// getRenderFormat returns current render format.
+func getRenderFormat() RenderFormat {
+	if format := renderFormat.Load(); format != nil {
+		return *format
+	}
+	return RenderFormatTable
+}
+
+// RenderJSON prints Record as JSON without @resourceType key.
+// Values of sensitive fields are redacted the same way as in Render. RenderLimits are not applied
+// so output is always valid JSON.
+func (r Record) RenderJSON(indent bool) string {
+	return marshalRenderJSON(machineReadable(redactMap(r)), indent)
+}
+
+// RenderYAML prints Record as YAML without @resourceType key.
+// Values of sensitive fields are redacted the same way as in Render.
+func (r Record) RenderYAML() string {
+	return marshalRenderYAML(machineReadable(redactMap(r)))
+}
+
+// RenderJSON prints RecordSet as JSON array (see Record.RenderJSON)
+func (rs RecordSet) RenderJSON(indent bool) string {
+	return marshalRenderJSON(rs.machineReadable(), indent)
+}
+
+// RenderYAML prints RecordSet as YAML list (see Record.RenderYAML)
+func (rs RecordSet) RenderYAML() string {
+	return marshalRenderYAML(rs.machineReadable())
+}
+
+// RenderJSON EmptyRecord
+func (er EmptyRecord) RenderJSON(bool) string {
+	return "{}"
+}
+
+// RenderYAML EmptyRecord
+func (er EmptyRecord) RenderYAML() string {
+	return "{}"
+}
+
+func (rs RecordSet) machineReadable() []map[string]any {
+	result := make([]map[string]any, len(rs))
+	for i, r := range rs {
+		result[i] = machineReadable(redactMap(r))
+	}
+	return result
+}
+
+// machineReadable returns copy of Record without internal keys.
+func machineReadable(r Record) map[string]any {
+	result := make(map[string]any, len(r))
+	for key, value := range r {
+		if key == resourceTypeKey {
+			continue
+		}
+		result[key] = value
+	}
+	return result
+}
+
+func marshalRenderJSON(v any, indent bool) string {
+	var buf bytes.Buffer
+	encoder := json.NewEncoder(&buf)
+	encoder.SetEscapeHTML(false)
+	if indent {
+		encoder.SetIndent("", "  ")
+	}
+	if err := encoder.Encode(v); err != nil {
+		return fmt.Sprintf("<failed to render json: %v>", err)
+	}
+	return strings.TrimSuffix(buf.String(), "\n")
+}
+
+func marshalRenderYAML(v any) string {
+	// Normalize arbitrary Go values (structs, typed slices etc.) to JSON types first.
+	raw, err := json.Marshal(v)
+	if err != nil {
+		return fmt.Sprintf("<failed to render yaml: %v>", err)
+	}
+	var normalized any
+	decoder := json.NewDecoder(bytes.NewReader(raw))
+	decoder.UseNumber()
+	if err = decoder.Decode(&normalized); err != nil {
+		return fmt.Sprintf("<failed to render yaml: %v>", err)
+	}
+	var out strings.Builder
+	writeYAML(&out, normalized, 0)
+	return strings.TrimSuffix(out.String(), "\n")
+}
+
+// writeYAML writes block style YAML representation of JSON value to out.
+func writeYAML(out *strings.Builder, v any, level int) {
+	pad := strings.Repeat("  ", level)
+	switch typed := v.(type) {
+	case map[string]any:
+		if len(typed) == 0 {
+			out.WriteString(pad + "{}\n")
+			return
+		}
+		keys := make([]string, 0, len(typed))
+		for key := range typed {
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+		for _, key := range keys {
+			out.WriteString(pad + yamlScalar(key) + ":")
+			writeYAMLValue(out, typed[key], level+1)
+		}
+	case []any:
+		if len(typed) == 0 {
+			out.WriteString(pad + "[]\n")
+			return
+		}
+		for _, item := range typed {
+			out.WriteString(pad + "-")
+			if m, ok := item.(map[string]any); ok && len(m) > 0 {
+				// Render first key inline with the dash to keep idiomatic YAML list of mappings.
+				var nested strings.Builder
+				writeYAML(&nested, m, level+1)
+				out.WriteString(" " + strings.TrimPrefix(nested.String(), pad+"  "))
+				continue
+			}
+			writeYAMLValue(out, item, level+1)
+		}
+	default:
+		out.WriteString(pad + yamlScalar(v) + "\n")
+	}
+}
+
+// writeYAMLValue writes value which follows "key:" or "-" marker.
+func writeYAMLValue(out *strings.Builder, v any, level int) {
+	switch typed := v.(type) {
+	case map[string]any:
+		if len(typed) == 0 {
+			out.WriteString(" {}\n")
+			return
+		}
+	case []any:
+		if len(typed) == 0 {
+			out.WriteString(" []\n")
+			return
+		}
+	default:
+		out.WriteString(" " + yamlScalar(v) + "\n")
+		return
+	}
+	out.WriteString("\n")
+	writeYAML(out, v, level)
+}
+
+var yamlPlainString = regexp.MustCompile(`^[A-Za-z_/][A-Za-z0-9_./@-]*$`)
+
+// yamlScalar returns YAML representation of scalar JSON value.
+// Strings which may be misinterpreted (numbers, booleans, special chars) are double-quoted.
+func yamlScalar(v any) string {
+	switch typed := v.(type) {
+	case nil:
+		return "null"
+	case bool:
+		return fmt.Sprintf("%t", typed)
+	case json.Number:
+		return typed.String()
+	case string:
+		switch strings.ToLower(typed) {
+		case "true", "false", "yes", "no", "on", "off", "null", "y", "n", "~":
+			return marshalRenderJSON(typed, false)
+		}
+		if yamlPlainString.MatchString(typed) {
+			return typed
+		}
+		return marshalRenderJSON(typed, false)
+	default:
+		return marshalRenderJSON(typed, false)
+	}
+}
@@ -3,9 +3,13 @@ package vast_client
 import (
 	"fmt"
 	version "github.com/hashicorp/go-version"
-	"net/url"
+	"io"
+	"maps"
+	"net/http"
 	"reflect"
+	"slices"
 	"strings"
+	"sync"
 	"time"
 )
 
@@ -15,6 +19,10 @@ type VMSRest struct {
 	Session     RESTSession
 	resourceMap map[string]VastResource // Map to store resources by resourceType
 
+	clusterVersion *version.Version // Cached core version of VAST cluster (see Version.GetVersion)
+	versionMu      sync.Mutex
+	tenantId       *int64 // Tenant the client is scoped to (see ForTenant)
+
 	Versions              *Version
 	VTasks                *VTask
 	Quotas                *Quota
@@ -29,7 +37,7 @@ type VMSRest struct {
 	Cnodes                *Cnode
 	QosPolicies           *QosPolicy
 	Dns                   *Dns
-	ViewPolies            *ViewPolicy
+	ViewPolicies          *ViewPolicy
 	Groups                *Group
 	Nis                   *Nis
 	Tenants               *Tenant
@@ -41,27 +49,152 @@ type VMSRest struct {
 	GlobalSnapshotStreams *GlobalSnapshotStream
 	ReplicationPeers      *ReplicationPeers
 	ProtectionPolicies    *ProtectionPolicy
-	S3replicationPeers    *S3replicationPeers
+	S3ReplicationPeers    *S3ReplicationPeers
 	Realms                *Realm
 	Roles                 *Role
+	Alarms                *Alarm
+	Events                *Event
+	EventDefinitions      *EventDefinition
+	Clusters              *Cluster
+	Dnodes                *Dnode
+	Nics                  *Nic
+	Subnets               *Subnet
+	Managers              *Manager
+	ApiTokens             *ApiToken
+	Certificates          *Certificate
+	Folders               *Folder
+	Monitors              *Monitor
+	Buckets               *Bucket
+	NonLocalUsers         *NonLocalUser
+	NonLocalGroups        *NonLocalGroup
+	Vms                   *Vms
+
+	// Deprecated: use ViewPolicies. Points to the same resource.
+	ViewPolies *ViewPolicy
+	// Deprecated: use S3ReplicationPeers. Points to the same resource.
+	S3replicationPeers *S3ReplicationPeers
 }
 
+// deprecatedFields maps deprecated VMSRest fields to fields which replaced them.
+// Deprecated fields are set to the same resources as their replacements by initResources
+// so renaming a field only requires adding it here.
+var deprecatedFields = map[string]string{
+	"ViewPolies":         "ViewPolicies",
+	"S3replicationPeers": "S3ReplicationPeers",
+}
+
+// NewVMSRest creates VMSRest from config. Missing config values are filled with defaults.
+// Panics if config is invalid.
 func NewVMSRest(config *VMSConfig) *VMSRest {
-	config.Validate(
+	rest, err := newVMSRest(config)
+	if err != nil {
+		panic(err)
+	}
+	return rest
+}
+
+// automationRetryPolicy is default retry policy of clients created with NewForAutomation.
+var automationRetryPolicy = RetryPolicy{MaxAttempts: 3, Backoff: 500 * time.Millisecond, MaxBackoff: 10 * time.Second}
+
+// automationTimeouts are default per-request timeouts of clients created with NewForAutomation.
+var automationTimeouts = Timeouts{Read: 30 * time.Second, Write: 2 * time.Minute, LongRunning: 30 * time.Minute}
+
+// NewForAutomation creates VMSRest for long-running automation (controllers, operators, CI pipelines)
+// authenticated with credentials (see StaticCredentials, FileCredentials).
+// On top of regular defaults it enables background token refresh, retries of failed GET requests
+// (3 attempts, see RetryPolicy), client-side rate limiting (20 requests per second with burst of 40),
+// redaction of sensitive fields in error response bodies (see VMSConfig.RedactErrorBodies), raises connections limit
+// and limits requests by timeouts (30 seconds for reads, 2 minutes for writes, 30 minutes for long-running requests).
+// Provided opts (e.g. WithRetry, WithTimeouts, WithConfig) are applied after preset so any preset value can be overridden.
+//
+// Example:
+//
+//	rest, err := client.NewForAutomation("10.27.40.1", client.StaticCredentials{ApiToken: token})
+func NewForAutomation(host string, credentials CredentialsProvider, opts ...VMSConfigFunc) (*VMSRest, error) {
+	preset := []VMSConfigFunc{
+		withTokenPreRefresh,
+		withDefaultTimeouts(automationTimeouts),
+		withRequestTimeout(time.Minute),
+		withDefaultMaxConnections(20),
+		withDefaultRateLimit(20, 40),
+		withDefaultRetry(automationRetryPolicy),
+		withRedactErrorBodies,
+	}
+	return newVMSRest(&VMSConfig{Host: host, Credentials: credentials}, append(preset, opts...)...)
+}
+
+// FakeServer is fake VMS server which NewForTests connects to (e.g. *vastclienttest.Server).
+type FakeServer interface {
+	// Config returns VMSConfig pointing to the server.
+	Config() *VMSConfig
+}
+
+// NewForTests creates VMSRest for tests against fake VMS server.
+// Requests fail fast: retries are disabled and every request is limited by 10 seconds timeout.
+// Provided opts are applied after preset.
+//
+// Example:
+//
+//	srv := vastclienttest.NewServer()
+//	defer srv.Close()
+//	rest, err := client.NewForTests(srv)
+func NewForTests(fake FakeServer, opts ...VMSConfigFunc) (*VMSRest, error) {
+	preset := []VMSConfigFunc{
+		withDefaultRetry(NoRetry),
+		withRequestTimeout(10 * time.Second),
+	}
+	return newVMSRest(fake.Config(), append(preset, opts...)...)
+}
+
+// NewReadOnly creates VMSRest for auditing and reporting tools.
+// All mutating requests (POST, PUT, PATCH, DELETE) are rejected by client before they reach VMS.
+// Provided opts are applied after preset.
+//
+// Example:
+//
+//	rest, err := client.NewReadOnly(&client.VMSConfig{Host: "10.27.40.1", Username: "auditor", Password: pass})
+func NewReadOnly(config *VMSConfig, opts ...VMSConfigFunc) (*VMSRest, error) {
+	return newVMSRest(config, append([]VMSConfigFunc{withReadOnly}, opts...)...)
+}
+
+// NewVMSRestWithSession creates VMSRest which sends all requests through provided session.
+// Config of the session is used as is (validators are not applied). It is intended for unit tests
+// with fake sessions (see vastclienttest package) or custom transports.
+func NewVMSRestWithSession(session RESTSession) *VMSRest {
+	rest := &VMSRest{
+		Session:     session,
+		resourceMap: make(map[string]VastResource),
+	}
+	initResources(rest)
+	return rest
+}
+
+// newVMSRest creates VMSRest. Provided validators are applied before default ones
+// so they can set own defaults for fields user left empty.
+func newVMSRest(config *VMSConfig, validators ...VMSConfigFunc) (*VMSRest, error) {
+	validators = append(validators,
 		withAuth,
 		withHost,
 		withUserAgent,
-		witApiVersion("v5"),
+		witApiVersion(defaultApiVersion),
 		withTimeout(time.Second*30),
-		withMaxConnections(10),
+		withIdleConnTimeout,
+		withRequestTimeout(5*time.Minute),
+		withDefaultMaxConnections(10),
 		withPort(443),
+		withTokenRefreshMargin(time.Minute),
+		withDefaultRateLimit(0, 0),
+		withDefaultHeaders,
+		withProxyURL,
 	)
-	session := NewVMSSession(config)
-	rest := &VMSRest{
-		Session:     session,
-		resourceMap: make(map[string]VastResource),
+	if err := config.validate(validators...); err != nil {
+		return nil, err
 	}
-	// Fill in each resource, pointing back to the same rest
+	return NewVMSRestWithSession(NewVMSSession(config)), nil
+}
+
+// initResources fills in each resource, pointing back to the same rest
+func initResources(rest *VMSRest) {
 	// NOTE: to add new type you need to update VastResourceType generic
 	rest.Versions = newResource[Version](rest, "versions", dummyClusterVersion)
 	rest.VTasks = newResource[VTask](rest, "vtasks", dummyClusterVersion)
@@ -70,14 +203,14 @@ func NewVMSRest(config *VMSConfig) *VMSRest {
 	rest.VipPools = newResource[VipPool](rest, "vippools", dummyClusterVersion)
 	rest.Users = newResource[User](rest, "users", dummyClusterVersion)
 	rest.UserKeys = newResource[UserKey](rest, "users/%d/access_keys", dummyClusterVersion)
-	rest.Snapshots = newResource[Snapshot](rest, "snapshots", dummyClusterVersion)
+	rest.Snapshots = newResource[Snapshot](rest, "snapshots", dummyClusterVersion, withTenantFilter("tenant__id"))
 	rest.BlockHosts = newResource[BlockHost](rest, "blockhosts", "5.3.0")
 	rest.Volumes = newResource[Volume](rest, "volumes", "5.3.0")
 	rest.BlockHostMappings = newResource[BlockHostMapping](rest, "blockhostvolumes", "5.3.0")
 	rest.Cnodes = newResource[Cnode](rest, "cnodes", dummyClusterVersion)
 	rest.QosPolicies = newResource[QosPolicy](rest, "qospolicies", dummyClusterVersion)
 	rest.Dns = newResource[Dns](rest, "dns", dummyClusterVersion)
-	rest.ViewPolies = newResource[ViewPolicy](rest, "viewpolicies", dummyClusterVersion)
+	rest.ViewPolicies = newResource[ViewPolicy](rest, "viewpolicies", dummyClusterVersion)
 	rest.Groups = newResource[Group](rest, "groups", dummyClusterVersion)
 	rest.Nis = newResource[Nis](rest, "nis", dummyClusterVersion)
 	rest.Tenants = newResource[Tenant](rest, "tenants", dummyClusterVersion)
@@ -85,15 +218,170 @@ func NewVMSRest(config *VMSConfig) *VMSRest {
 	rest.S3LifeCycleRules = newResource[S3LifeCycleRule](rest, "s3lifecyclerules", dummyClusterVersion)
 	rest.ActiveDirectories = newResource[ActiveDirectory](rest, "activedirectory", dummyClusterVersion)
 	rest.S3Policies = newResource[S3Policy](rest, "s3userpolicies", dummyClusterVersion)
-	rest.ProtectedPaths = newResource[ProtectedPath](rest, "protectedpaths", dummyClusterVersion)
+	rest.ProtectedPaths = newResource[ProtectedPath](rest, "protectedpaths", dummyClusterVersion, withTenantFilter("tenant__id"))
 	rest.GlobalSnapshotStreams = newResource[GlobalSnapshotStream](rest, "globalsnapstreams", dummyClusterVersion)
 	rest.ReplicationPeers = newResource[ReplicationPeers](rest, "nativereplicationremotetargets", dummyClusterVersion)
 	rest.ProtectionPolicies = newResource[ProtectionPolicy](rest, "protectionpolicies", dummyClusterVersion)
-	rest.S3replicationPeers = newResource[S3replicationPeers](rest, "replicationtargets", dummyClusterVersion)
+	rest.S3ReplicationPeers = newResource[S3ReplicationPeers](rest, "replicationtargets", dummyClusterVersion)
 	rest.Realms = newResource[Realm](rest, "realms", dummyClusterVersion)
 	rest.Roles = newResource[Role](rest, "roles", dummyClusterVersion)
+	rest.Alarms = newResource[Alarm](rest, "alarms", dummyClusterVersion)
+	rest.Events = newResource[Event](rest, "events", dummyClusterVersion)
+	rest.EventDefinitions = newResource[EventDefinition](rest, "eventdefinitions", dummyClusterVersion)
+	rest.Clusters = newResource[Cluster](rest, "clusters", dummyClusterVersion)
+	rest.Dnodes = newResource[Dnode](rest, "dnodes", dummyClusterVersion)
+	rest.Nics = newResource[Nic](rest, "nics", dummyClusterVersion)
+	rest.Subnets = newResource[Subnet](rest, "subnets", dummyClusterVersion)
+	rest.Managers = newResource[Manager](rest, "managers", dummyClusterVersion)
+	rest.ApiTokens = newResource[ApiToken](rest, "apitokens", dummyClusterVersion)
+	rest.Certificates = newResource[Certificate](rest, "certificates", dummyClusterVersion)
+	rest.Folders = newResource[Folder](rest, "folders", dummyClusterVersion)
+	rest.Monitors = newResource[Monitor](rest, "monitors", dummyClusterVersion)
+	rest.Buckets = newResource[Bucket](rest, "buckets", dummyClusterVersion)
+	rest.NonLocalUsers = newResource[NonLocalUser](rest, "users", dummyClusterVersion)
+	rest.NonLocalGroups = newResource[NonLocalGroup](rest, "groups", dummyClusterVersion)
+	rest.Vms = newResource[Vms](rest, "vms", dummyClusterVersion)
+	setDeprecatedFields(rest)
+}
 
-	return rest
+// setDeprecatedFields points deprecated fields of VMSRest to resources of fields which replaced them (see deprecatedFields).
+// Panics if deprecatedFields refers to missing or incompatible field: this is programming error caught on first client creation.
+func setDeprecatedFields(rest *VMSRest) {
+	value := reflect.ValueOf(rest).Elem()
+	for deprecated, replacement := range deprecatedFields {
+		oldField, newField := value.FieldByName(deprecated), value.FieldByName(replacement)
+		if !oldField.IsValid() || !newField.IsValid() || oldField.Type() != newField.Type() {
+			panic(fmt.Sprintf("invalid deprecated VMSRest field %s (replaced by %s)", deprecated, replacement))
+		}
+		oldField.Set(newField)
+	}
+}
+
+// ForTenant returns client scoped to tenant. It shares session (and token) with parent client
+// but has own set of resources which inject "tenant_id" into query params of GET/DELETE requests
+// and into bodies of POST requests unless tenant_id is set explicitly by caller.
+// Parent client is not modified. Per-resource overrides made on parent (SetIdField, SetTenantFilterKey,
+// SetApiVersion, SetRequiredOnCreate, SetRetryPolicy) are inherited. Resources added with RegisterResource are not.
+func (rest *VMSRest) ForTenant(tenantId int64) *VMSRest {
+	scoped := &VMSRest{
+		Session:     rest.Session,
+		resourceMap: make(map[string]VastResource),
+		tenantId:    &tenantId,
+	}
+	initResources(scoped)
+	for resourceType, resource := range scoped.resourceMap {
+		parent, ok := rest.resourceMap[resourceType].(resourceEntry)
+		if !ok {
+			continue
+		}
+		if child, ok := resource.(resourceEntry); ok {
+			child.entry().inheritOverrides(parent.entry())
+		}
+	}
+	return scoped
+}
+
+// tenantAgnosticResources are resources which are not scoped to tenant (see ForTenant).
+var tenantAgnosticResources = map[string]bool{
+	"Version":         true,
+	"VTask":           true,
+	"Tenant":          true,
+	"Alarm":           true,
+	"Event":           true,
+	"EventDefinition": true,
+	"Cluster":         true,
+	"Cnode":           true,
+	"Dnode":           true,
+	"Nic":             true,
+	"Certificate":     true,
+	"Monitor":         true,
+	"Vms":             true,
+}
+
+// tenantScopedResource is implemented by resources which can be scoped to tenant (see ForTenant).
+type tenantScopedResource interface {
+	withTenantScope(verb string, params, body Params) (Params, Params)
+}
+
+// withTenantScope injects tenant_id of tenant-scoped client (see ForTenant) into query params or body.
+// Params and body provided by caller are never modified.
+func (e *VastResourceEntry) withTenantScope(verb string, params, body Params) (Params, Params) {
+	if e.rest.tenantId == nil || tenantAgnosticResources[e.resourceType] {
+		return params, body
+	}
+	inject := func(p Params, key string) Params {
+		if _, ok := p[key]; ok {
+			return p
+		}
+		scoped := Params{key: *e.rest.tenantId}
+		scoped.Update(p, false)
+		return scoped
+	}
+	switch verb {
+	case http.MethodGet:
+		params = inject(params, e.getTenantFilterKey())
+	case http.MethodDelete:
+		params = inject(params, "tenant_id")
+	case http.MethodPost:
+		body = inject(body, "tenant_id")
+	}
+	return params, body
+}
+
+// Close releases resources of the client: stops background token refresh, closes idle connections
+// and clears cached cluster version. If VMSConfig.RevokeTokenOnClose is set JWT refresh token is revoked.
+// After Close further requests fail fast with ErrClientClosed.
+// NOTE: clients created with ForTenant share session with parent so closing any of them closes all.
+func (rest *VMSRest) Close() error {
+	rest.Versions.InvalidateCache()
+	if closer, ok := rest.Session.(io.Closer); ok {
+		return closer.Close()
+	}
+	return nil
+}
+
+// Resource returns resource by name for generic tooling (e.g. CLI taking resource name as argument).
+// Name may be resource type (e.g. "ViewPolicy") or name of VMSRest field (e.g. "ViewPolicies", "views").
+// Lookup is case-insensitive and ignores "_" and "-" (e.g. "view_policies").
+func (rest *VMSRest) Resource(name string) (VastResource, error) {
+	normalized := normalizeResourceName(name)
+	for resourceType, resource := range rest.resourceMap {
+		if normalizeResourceName(resourceType) == normalized {
+			return resource, nil
+		}
+	}
+	value := reflect.ValueOf(rest).Elem()
+	for i := range value.NumField() {
+		field := value.Type().Field(i)
+		if !field.IsExported() || normalizeResourceName(field.Name) != normalized {
+			continue
+		}
+		if resource, ok := value.Field(i).Interface().(VastResource); ok && !value.Field(i).IsNil() {
+			return resource, nil
+		}
+	}
+	return nil, fmt.Errorf("unknown resource %q", name)
+}
+
+// ResourceNames returns sorted resource types available through Resource.
+func (rest *VMSRest) ResourceNames() []string {
+	return slices.Sorted(maps.Keys(rest.resourceMap))
+}
+
+// RegisterResource makes resource available through Resource and ResourceNames (and Export/Import) under name,
+// e.g. custom VastResource implementation for endpoint which is not covered by client.
+// Registering under name of existing resource type replaces it in these lookups.
+// Resources should be registered before client is used concurrently. Panics if name is empty or resource is nil.
+func (rest *VMSRest) RegisterResource(name string, resource VastResource) {
+	if strings.TrimSpace(name) == "" || resource == nil {
+		panic("resource name and resource must be provided")
+	}
+	rest.resourceMap[name] = resource
+}
+
+// normalizeResourceName lowercases name and strips "_" and "-" separators.
+func normalizeResourceName(name string) string {
+	return strings.NewReplacer("_", "", "-", "").Replace(strings.ToLower(strings.TrimSpace(name)))
 }
 
 // BuildUrl Helper method to build full URL from path, query and api version.
@@ -102,7 +390,22 @@ func (rest *VMSRest) BuildUrl(path, query, apiVer string) (string, error) {
 	return buildUrl(rest.Session, path, query, apiVer)
 }
 
-func newResource[T VastResourceType](rest *VMSRest, resourcePath, availableFromVersion string) *T {
+// requiredOnCreate declares fields required by VMS on create for core resources.
+// Can be overridden per resource with SetRequiredOnCreate.
+var requiredOnCreate = map[string][]string{
+	"View":       {"path", "policy_id"},
+	"Quota":      {"path"},
+	"Snapshot":   {"name", "path"},
+	"Volume":     {"name", "view_id"},
+	"BlockHost":  {"name", "nqn"},
+	"User":       {"name", "uid"},
+	"Group":      {"name", "gid"},
+	"VipPool":    {"name"},
+	"ViewPolicy": {"name"},
+	"Tenant":     {"name"},
+}
+
+func newResource[T VastResourceType](rest *VMSRest, resourcePath, availableFromVersion string, opts ...resourceOption) *T {
 	var availableFrom *version.Version
 	if availableFromVersion == dummyClusterVersion {
 		availableFrom = nil
@@ -116,8 +419,12 @@ func newResource[T VastResourceType](rest *VMSRest, resourcePath, availableFromV
 			resourceType:         resourceType,
 			rest:                 rest,
 			availableFromVersion: availableFrom,
+			requiredOnCreate:     requiredOnCreate[resourceType],
 		},
 	}
+	for _, opt := range opts {
+		opt(any(resource).(resourceEntry).entry())
+	}
 	if res, ok := any(resource).(VastResource); ok {
 		rest.resourceMap[resourceType] = res
 	} else {
@@ -127,19 +434,14 @@ func newResource[T VastResourceType](rest *VMSRest, resourcePath, availableFromV
 }
 
 func buildUrl(s RESTSession, path, query, apiVer string) (string, error) {
-	var err error
 	config := s.GetConfig()
-	if apiVer != "" {
-		apiVer = config.ApiVersion
+	if apiVer == "" {
+		apiVer = configuredApiVersion(s)
 	}
-	if path, err = url.JoinPath("api", apiVer, strings.Trim(path, "/")); err != nil {
+	_url, err := endpointUrl(config, "api", apiVer, strings.Trim(path, "/"))
+	if err != nil {
 		return "", err
 	}
-	_url := url.URL{
-		Scheme: "https",
-		Host:   fmt.Sprintf("%s:%v", config.Host, config.Port),
-		Path:   path,
-	}
 	if query != "" {
 		_url.RawQuery = query
 	}
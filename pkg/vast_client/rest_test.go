@@ -0,0 +1,469 @@
+package vast_client
+
+import (
+	"context"
+	"net/http"
+	"reflect"
+	"slices"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// configServer is FakeServer which returns fixed config.
+type configServer struct {
+	config *VMSConfig
+}
+
+func (s configServer) Config() *VMSConfig {
+	return s.config
+}
+
+func TestPresetDefaults(t *testing.T) {
+	tests := []struct {
+		name  string
+		new   func(*VMSConfig) (*VMSRest, error)
+		check func(t *testing.T, config *VMSConfig)
+	}{
+		{
+			name: "automation",
+			new: func(config *VMSConfig) (*VMSRest, error) {
+				return NewForAutomation(config.Host, StaticCredentials{ApiToken: config.ApiToken})
+			},
+			check: func(t *testing.T, config *VMSConfig) {
+				assert.True(t, config.TokenPreRefresh)
+				assert.True(t, config.RedactErrorBodies)
+				assert.Equal(t, float64(20), config.RequestsPerSecond)
+				assert.Equal(t, 40, config.Burst)
+				assert.Equal(t, 20, config.MaxConnections)
+				require.NotNil(t, config.Retry)
+				assert.Equal(t, automationRetryPolicy, *config.Retry)
+				assert.Equal(t, automationTimeouts, config.Timeouts)
+				assert.Equal(t, time.Minute, config.RequestTimeout)
+				assert.False(t, config.ReadOnly)
+			},
+		},
+		{
+			name: "read-only",
+			new: func(config *VMSConfig) (*VMSRest, error) {
+				return NewReadOnly(config)
+			},
+			check: func(t *testing.T, config *VMSConfig) {
+				assert.True(t, config.ReadOnly)
+				assert.Nil(t, config.Retry)
+			},
+		},
+		{
+			name: "tests",
+			new: func(config *VMSConfig) (*VMSRest, error) {
+				return NewForTests(configServer{config})
+			},
+			check: func(t *testing.T, config *VMSConfig) {
+				require.NotNil(t, config.Retry)
+				assert.Equal(t, NoRetry, *config.Retry)
+				assert.Equal(t, 10*time.Second, config.RequestTimeout)
+				assert.False(t, config.TokenPreRefresh)
+				assert.Zero(t, config.RequestsPerSecond)
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			config := &VMSConfig{Host: "vms.local", ApiToken: testApiToken}
+			rest, err := tt.new(config)
+			require.NoError(t, err)
+			defer rest.Close()
+			tt.check(t, rest.Session.GetConfig())
+		})
+	}
+}
+
+func TestAutomationPresetRequestDeadline(t *testing.T) {
+	rest, err := NewForAutomation("vms.local", StaticCredentials{ApiToken: testApiToken})
+	require.NoError(t, err)
+	defer rest.Close()
+	config := rest.Session.GetConfig()
+
+	tests := []struct {
+		name string
+		ctx  context.Context
+		verb string
+		want time.Duration
+	}{
+		{name: "read", ctx: context.Background(), verb: http.MethodGet, want: 30 * time.Second},
+		{name: "write", ctx: context.Background(), verb: http.MethodPost, want: 2 * time.Minute},
+		{name: "long-running", ctx: AsLongRunning(context.Background()), verb: http.MethodPost, want: 30 * time.Minute},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			start := time.Now()
+			ctx, cancel, timeout := withVerbTimeout(tt.ctx, config, tt.verb)
+			defer cancel()
+
+			assert.Equal(t, tt.want, timeout)
+			deadline, ok := ctx.Deadline()
+			require.True(t, ok)
+			assert.WithinDuration(t, start.Add(tt.want), deadline, time.Second)
+		})
+	}
+}
+
+func TestAutomationPresetTimeoutOverride(t *testing.T) {
+	srv := newTestServer(t, func(w http.ResponseWriter, r *http.Request) {
+		select {
+		case <-time.After(time.Second):
+		case <-r.Context().Done():
+		}
+		writeTestJSON(w, http.StatusOK, []map[string]any{})
+	})
+	rest, err := NewForAutomation("", StaticCredentials{ApiToken: testApiToken},
+		WithConfig(func(config *VMSConfig) { config.BaseURL = srv.URL }),
+		WithTimeouts(Timeouts{Read: 50 * time.Millisecond}),
+		WithRetry(NoRetry),
+	)
+	require.NoError(t, err)
+	defer rest.Close()
+
+	_, err = rest.Views.List(context.Background(), nil)
+	var timeoutErr *ClientTimeoutError
+	require.ErrorAs(t, err, &timeoutErr)
+	assert.Equal(t, 50*time.Millisecond, timeoutErr.Timeout)
+}
+
+func TestPresetOverrides(t *testing.T) {
+	retry := RetryPolicy{MaxAttempts: 5}
+	rest, err := NewForAutomation("vms.local", StaticCredentials{ApiToken: testApiToken},
+		WithRetry(retry),
+		WithRateLimit(5, 10),
+		WithMaxConnections(2),
+		WithConfig(func(config *VMSConfig) { config.TokenPreRefresh = false }),
+	)
+	require.NoError(t, err)
+	defer rest.Close()
+
+	config := rest.Session.GetConfig()
+	assert.Equal(t, retry, *config.Retry)
+	assert.Equal(t, float64(5), config.RequestsPerSecond)
+	assert.Equal(t, 10, config.Burst)
+	assert.Equal(t, 2, config.MaxConnections)
+	assert.False(t, config.TokenPreRefresh)
+}
+
+func TestPresetRetries(t *testing.T) {
+	tests := []struct {
+		name         string
+		new          func(config *VMSConfig, opts ...VMSConfigFunc) (*VMSRest, error)
+		wantErr      bool
+		wantAttempts int32
+	}{
+		{
+			name: "automation",
+			new: func(config *VMSConfig, opts ...VMSConfigFunc) (*VMSRest, error) {
+				opts = append(opts, WithConfig(func(c *VMSConfig) { c.BaseURL = config.BaseURL }))
+				return NewForAutomation("", StaticCredentials{ApiToken: config.ApiToken}, opts...)
+			},
+			wantAttempts: 3,
+		},
+		{
+			name: "tests",
+			new: func(config *VMSConfig, opts ...VMSConfigFunc) (*VMSRest, error) {
+				return NewForTests(configServer{config}, opts...)
+			},
+			wantErr:      true,
+			wantAttempts: 1,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var attempts atomic.Int32
+			srv := newTestServer(t, func(w http.ResponseWriter, r *http.Request) {
+				if attempts.Add(1) < 3 {
+					writeTestJSON(w, http.StatusServiceUnavailable, map[string]string{"detail": "busy"})
+					return
+				}
+				writeTestJSON(w, http.StatusOK, []map[string]any{})
+			})
+			var opts []VMSConfigFunc
+			if tt.name == "automation" {
+				// Same number of attempts as in preset but without long backoff
+				opts = append(opts, WithRetry(RetryPolicy{MaxAttempts: automationRetryPolicy.MaxAttempts, Backoff: time.Millisecond}))
+			}
+			rest, err := tt.new(srv.config(), opts...)
+			require.NoError(t, err)
+			defer rest.Close()
+
+			_, err = rest.Views.List(context.Background(), nil)
+			if tt.wantErr {
+				assert.True(t, isApiErrWithStatus(err, http.StatusServiceUnavailable))
+			} else {
+				assert.NoError(t, err)
+			}
+			assert.Equal(t, tt.wantAttempts, attempts.Load())
+		})
+	}
+}
+
+func TestReadOnlyPresetRejectsMutations(t *testing.T) {
+	srv := newTestServer(t, recordsHandler())
+	rest, err := NewReadOnly(srv.config())
+	require.NoError(t, err)
+	defer rest.Close()
+	ctx := context.Background()
+
+	_, err = rest.Views.List(ctx, nil)
+	require.NoError(t, err)
+	_, err = rest.Views.Create(ctx, Params{"path": "/a", "policy_id": 1})
+	assert.ErrorIs(t, err, ErrReadOnly)
+	_, err = rest.Views.DeleteById(ctx, 1)
+	assert.ErrorIs(t, err, ErrReadOnly)
+	assert.Equal(t, []string{"GET views"}, methodsAndPaths(srv.Requests()))
+}
+
+func TestRedactErrorBodies(t *testing.T) {
+	for _, redacted := range []bool{false, true} {
+		srv := newTestServer(t, func(w http.ResponseWriter, r *http.Request) {
+			writeTestJSON(w, http.StatusBadRequest, map[string]string{"detail": "invalid bind", "bindpw": "s3cr3t"})
+		})
+		config := srv.config()
+		config.RedactErrorBodies = redacted
+		rest := newTestRest(t, config)
+
+		_, err := rest.Ldaps.Create(context.Background(), Params{"bindpw": "s3cr3t"})
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "invalid bind")
+		assert.Equal(t, !redacted, containsAll(err.Error(), "s3cr3t"), "redacted=%v: %v", redacted, err)
+	}
+}
+
+func TestForTenant(t *testing.T) {
+	tests := []struct {
+		name      string
+		call      func(ctx context.Context, rest *VMSRest) error
+		wantQuery map[string]string
+		wantBody  string
+	}{
+		{
+			name: "get query",
+			call: func(ctx context.Context, rest *VMSRest) error {
+				_, err := rest.Views.List(ctx, Params{"name": "v"})
+				return err
+			},
+			wantQuery: map[string]string{"tenant_id": "5", "name": "v"},
+		},
+		{
+			name: "explicit tenant wins",
+			call: func(ctx context.Context, rest *VMSRest) error {
+				_, err := rest.Views.List(ctx, Params{"tenant_id": 1})
+				return err
+			},
+			wantQuery: map[string]string{"tenant_id": "1"},
+		},
+		{
+			name: "resource specific filter key",
+			call: func(ctx context.Context, rest *VMSRest) error {
+				_, err := rest.Snapshots.List(ctx, nil)
+				return err
+			},
+			wantQuery: map[string]string{"tenant__id": "5", "tenant_id": ""},
+		},
+		{
+			name: "tenant agnostic resource",
+			call: func(ctx context.Context, rest *VMSRest) error {
+				_, err := rest.Cnodes.List(ctx, nil)
+				return err
+			},
+			wantQuery: map[string]string{"tenant_id": ""},
+		},
+		{
+			name: "post body",
+			call: func(ctx context.Context, rest *VMSRest) error {
+				_, err := rest.Views.Create(ctx, Params{"path": "/v", "policy_id": 1})
+				return err
+			},
+			wantQuery: map[string]string{"tenant_id": ""},
+			wantBody:  `{"path": "/v", "policy_id": 1, "tenant_id": 5}`,
+		},
+		{
+			name: "post body with explicit tenant",
+			call: func(ctx context.Context, rest *VMSRest) error {
+				_, err := rest.Views.Create(ctx, Params{"path": "/v", "policy_id": 1, "tenant_id": 2})
+				return err
+			},
+			wantBody: `{"path": "/v", "policy_id": 1, "tenant_id": 2}`,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			srv := newTestServer(t, recordsHandler())
+			parent := newTestRest(t, srv.config())
+			scoped := parent.ForTenant(5)
+
+			require.NoError(t, tt.call(context.Background(), scoped))
+			require.NoError(t, tt.call(context.Background(), parent))
+
+			requests := srv.Requests()
+			require.Len(t, requests, 2)
+			for key, want := range tt.wantQuery {
+				assert.Equal(t, want, requests[0].Query.Get(key), key)
+			}
+			if tt.wantBody != "" {
+				assert.JSONEq(t, tt.wantBody, string(requests[0].Body))
+			}
+			assert.NotEqual(t, "5", requests[1].Query.Get("tenant_id"), "parent client is not scoped")
+			assert.NotContains(t, string(requests[1].Body), `"tenant_id":5`, "parent client is not scoped")
+		})
+	}
+}
+
+func TestForTenantSharesSession(t *testing.T) {
+	srv := newTestServer(t, recordsHandler())
+	parent := newTestRest(t, &VMSConfig{BaseURL: srv.URL, Username: "admin", Password: "pass"})
+	scoped := parent.ForTenant(5)
+
+	_, err := parent.Views.List(context.Background(), nil)
+	require.NoError(t, err)
+	_, err = scoped.Views.List(context.Background(), nil)
+	require.NoError(t, err)
+
+	assert.Same(t, parent.Session, scoped.Session)
+	assert.Equal(t, 1, srv.TokenRequests(), "token is shared")
+}
+
+func TestForTenantInheritsOverrides(t *testing.T) {
+	var mu sync.Mutex
+	var paths []string
+	records := recordsHandler(map[string]any{"guid": 7, "name": "v"})
+	srv := newTestServer(t, func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		paths = append(paths, r.Method+" "+r.URL.Path)
+		mu.Unlock()
+		records(w, r)
+	})
+	parent := newTestRest(t, srv.config())
+	parent.Views.SetIdField("guid")
+	parent.Views.SetTenantFilterKey("tenant__id")
+	parent.Views.SetApiVersion("v6")
+	parent.Views.SetRequiredOnCreate("name")
+	parent.Views.SetRetryPolicy(NoRetry)
+	scoped := parent.ForTenant(5)
+	ctx := context.Background()
+
+	_, err := scoped.Views.Create(ctx, Params{"path": "/v"})
+	var validationErr *ValidationError
+	require.ErrorAs(t, err, &validationErr)
+	assert.Equal(t, []string{"name"}, validationErr.Fields)
+
+	_, err = scoped.Views.Delete(ctx, Params{"name": "v"})
+	require.NoError(t, err)
+
+	requests := srv.Requests()
+	assert.Equal(t, []string{"GET views", "DELETE views/7"}, methodsAndPaths(requests))
+	assert.Equal(t, "5", requests[0].Query.Get("tenant__id"))
+	assert.Empty(t, requests[0].Query.Get("tenant_id"))
+	assert.Equal(t, []string{"GET /api/v6/views", "DELETE /api/v6/views/7"}, paths)
+	require.NotNil(t, scoped.Views.retryPolicy)
+	assert.Equal(t, NoRetry, *scoped.Views.retryPolicy)
+
+	scoped.Views.SetIdField("uuid")
+	assert.Equal(t, "guid", parent.Views.getIdField(), "parent is not modified by scoped client")
+}
+
+func TestResourceByName(t *testing.T) {
+	srv := newTestServer(t, recordsHandler())
+	rest := newTestRest(t, srv.config())
+	tests := []struct {
+		name string
+		want VastResource
+	}{
+		{name: "views", want: rest.Views},
+		{name: "Views", want: rest.Views},
+		{name: "VIEW", want: rest.Views},
+		{name: " quotas ", want: rest.Quotas},
+		{name: "ViewPolicy", want: rest.ViewPolicies},
+		{name: "view_policies", want: rest.ViewPolicies},
+		{name: "view-policy", want: rest.ViewPolicies},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			resource, err := rest.Resource(tt.name)
+			require.NoError(t, err)
+			assert.Same(t, tt.want, resource)
+		})
+	}
+
+	_, err := rest.Resource("no_such_resource")
+	assert.EqualError(t, err, `unknown resource "no_such_resource"`)
+
+	resource, err := rest.Resource("views")
+	require.NoError(t, err)
+	_, err = resource.List(context.Background(), nil)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"GET views"}, methodsAndPaths(srv.Requests()))
+}
+
+// customResource is VastResource implemented outside of the client (see RegisterResource).
+type customResource struct {
+	VastResource
+}
+
+func (customResource) GetResourceType() string {
+	return "AuditedView"
+}
+
+func TestRegisterResource(t *testing.T) {
+	srv := newTestServer(t, recordsHandler())
+	rest := newTestRest(t, srv.config())
+	custom := customResource{rest.Views}
+
+	rest.RegisterResource("AuditedView", custom)
+
+	for _, name := range []string{"AuditedView", "audited_view", "auditedview"} {
+		resource, err := rest.Resource(name)
+		require.NoError(t, err, name)
+		assert.Equal(t, custom, resource, name)
+	}
+	assert.Contains(t, rest.ResourceNames(), "AuditedView")
+	assert.True(t, slices.IsSorted(rest.ResourceNames()))
+
+	resource, err := rest.Resource("audited_view")
+	require.NoError(t, err)
+	_, err = resource.List(context.Background(), nil)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"GET views"}, methodsAndPaths(srv.Requests()))
+
+	assert.Panics(t, func() { rest.RegisterResource("", custom) })
+	assert.Panics(t, func() { rest.RegisterResource("Nothing", nil) })
+}
+
+func TestResourceEveryFieldIsReachable(t *testing.T) {
+	srv := newTestServer(t, recordsHandler())
+	rest := newTestRest(t, srv.config())
+
+	var resourceTypes []string
+	value := reflect.ValueOf(rest).Elem()
+	for i := range value.NumField() {
+		field := value.Type().Field(i)
+		if !field.IsExported() {
+			continue
+		}
+		resource, ok := value.Field(i).Interface().(VastResource)
+		if !ok {
+			continue
+		}
+		resourceTypes = append(resourceTypes, resource.GetResourceType())
+		for _, name := range []string{field.Name, strings.ToLower(field.Name), resource.GetResourceType()} {
+			found, err := rest.Resource(name)
+			if assert.NoError(t, err, name) {
+				assert.Same(t, resource, found, name)
+			}
+		}
+	}
+	slices.Sort(resourceTypes)
+	resourceTypes = slices.Compact(resourceTypes) // Deprecated aliases point to the same resource
+	assert.Equal(t, resourceTypes, rest.ResourceNames())
+}
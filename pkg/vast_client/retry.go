@@ -0,0 +1,96 @@
+package vast_client
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"time"
+)
+
+// Defaults of RetryPolicy
+const (
+	defaultRetryBackoff    = 200 * time.Millisecond
+	defaultRetryMaxBackoff = 5 * time.Second
+)
+
+// RetryPolicy controls retries of failed GET requests. Mutating requests are never retried by policy
+// because they may have been applied on server side (see CreateIdempotent).
+// Requests are retried on timeouts, gateway errors (502, 503, 504) and 429 Too Many Requests.
+//
+// Policy is resolved with precedence: WithRetryPolicy context > SetRetryPolicy of resource > VMSConfig.Retry.
+type RetryPolicy struct {
+	MaxAttempts int           // Total number of attempts including the first one. Values below 2 disable retries.
+	Backoff     time.Duration // Delay before first retry doubled for every next retry. Default is 200ms.
+	MaxBackoff  time.Duration // Upper bound of delay between retries. Default is 5s.
+}
+
+// NoRetry is RetryPolicy which disables retries (e.g. for interactive calls which should fail fast).
+var NoRetry = RetryPolicy{MaxAttempts: 1}
+
+// shouldRetry reports whether request which failed with err on given attempt (starting from 1) should be retried.
+func (p RetryPolicy) shouldRetry(ctx context.Context, verb string, attempt int, err error) bool {
+	if verb != http.MethodGet || attempt >= p.MaxAttempts || ctx.Err() != nil {
+		return false
+	}
+	if errors.Is(err, ErrClientClosed) {
+		return false
+	}
+	return isTimeoutErr(err) || isApiErrWithStatus(err, http.StatusTooManyRequests)
+}
+
+// delay returns backoff before given retry (starting from 1).
+func (p RetryPolicy) delay(retry int) time.Duration {
+	backoff, maxBackoff := p.Backoff, p.MaxBackoff
+	if backoff <= 0 {
+		backoff = defaultRetryBackoff
+	}
+	if maxBackoff <= 0 {
+		maxBackoff = defaultRetryMaxBackoff
+	}
+	for i := 1; i < retry && backoff < maxBackoff; i++ {
+		backoff *= 2
+	}
+	return min(backoff, maxBackoff)
+}
+
+// SetRetryPolicy overrides retry policy of the resource (see RetryPolicy for precedence).
+func (e *VastResourceEntry) SetRetryPolicy(policy RetryPolicy) {
+	e.retryPolicy = &policy
+}
+
+func (e *VastResourceEntry) getRetryPolicy() *RetryPolicy {
+	return e.retryPolicy
+}
+
+// retryPolicyProvider is implemented by resources with own retry policy (see SetRetryPolicy).
+type retryPolicyProvider interface {
+	getRetryPolicy() *RetryPolicy
+}
+
+// resolveRetryPolicy returns retry policy of request according to precedence described in RetryPolicy.
+func resolveRetryPolicy(ctx context.Context, r VastResource, config *VMSConfig) RetryPolicy {
+	if policy, ok := retryPolicyFromContext(ctx); ok {
+		return policy
+	}
+	if provider, ok := r.(retryPolicyProvider); ok {
+		if policy := provider.getRetryPolicy(); policy != nil {
+			return *policy
+		}
+	}
+	if config.Retry != nil {
+		return *config.Retry
+	}
+	return NoRetry
+}
+
+// waitRetry sleeps before retry. Returns false if context is done earlier.
+func waitRetry(ctx context.Context, delay time.Duration) bool {
+	timer := time.NewTimer(delay)
+	defer timer.Stop()
+	select {
+	case <-timer.C:
+		return true
+	case <-ctx.Done():
+		return false
+	}
+}
@@ -0,0 +1,182 @@
+package vast_client
+
+import (
+	"context"
+	"net/http"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// unavailableHandler responds with 503 Service Unavailable to every request.
+func unavailableHandler(w http.ResponseWriter, _ *http.Request) {
+	writeTestJSON(w, http.StatusServiceUnavailable, map[string]string{"detail": "unavailable"})
+}
+
+// countCallerRequests returns number of requests made with "caller" query param equal to caller.
+func countCallerRequests(requests []recordedRequest, caller string) int {
+	var n int
+	for _, r := range requests {
+		if r.Query.Get("caller") == caller {
+			n++
+		}
+	}
+	return n
+}
+
+func TestRetryPolicyPrecedence(t *testing.T) {
+	fast := func(attempts int) *RetryPolicy { return &RetryPolicy{MaxAttempts: attempts, Backoff: time.Millisecond} }
+	tests := []struct {
+		name         string
+		config       *RetryPolicy
+		resource     *RetryPolicy
+		ctx          *RetryPolicy
+		verb         string
+		wantRequests int
+	}{
+		{name: "no retries by default", verb: http.MethodGet, wantRequests: 1},
+		{name: "config", config: fast(2), verb: http.MethodGet, wantRequests: 2},
+		{name: "resource over config", config: fast(2), resource: fast(3), verb: http.MethodGet, wantRequests: 3},
+		{name: "context over resource", config: fast(2), resource: fast(3), ctx: fast(4), verb: http.MethodGet, wantRequests: 4},
+		{name: "context disables retries", config: fast(2), ctx: &NoRetry, verb: http.MethodGet, wantRequests: 1},
+		{name: "mutations are not retried", config: fast(2), ctx: fast(4), verb: http.MethodPost, wantRequests: 1},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			srv := newTestServer(t, unavailableHandler)
+			config := srv.config()
+			config.Retry = tt.config
+			rest := newTestRest(t, config)
+			if tt.resource != nil {
+				rest.Views.SetRetryPolicy(*tt.resource)
+			}
+			ctx := context.Background()
+			if tt.ctx != nil {
+				ctx = WithRetryPolicy(ctx, *tt.ctx)
+			}
+
+			var err error
+			if tt.verb == http.MethodGet {
+				_, err = rest.Views.List(ctx, nil)
+			} else {
+				_, err = rest.Views.Create(ctx, Params{"path": "/v", "policy_id": 1})
+			}
+
+			assert.True(t, isApiErrWithStatus(err, http.StatusServiceUnavailable), "got %v", err)
+			assert.Len(t, srv.Requests(), tt.wantRequests)
+		})
+	}
+}
+
+func TestRetryPolicyPerCallConcurrent(t *testing.T) {
+	srv := newTestServer(t, unavailableHandler)
+	config := srv.config()
+	config.Retry = &RetryPolicy{MaxAttempts: 2, Backoff: time.Millisecond}
+	rest := newTestRest(t, config)
+	callers := map[string]RetryPolicy{
+		"reconciler": {MaxAttempts: 5, Backoff: time.Millisecond},
+		"cli":        NoRetry,
+	}
+
+	var wg sync.WaitGroup
+	for caller, policy := range callers {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			_, err := rest.Views.List(WithRetryPolicy(context.Background(), policy), Params{"caller": caller})
+			assert.Error(t, err)
+		}()
+	}
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		_, err := rest.Views.List(context.Background(), Params{"caller": "default"})
+		assert.Error(t, err)
+	}()
+	wg.Wait()
+
+	requests := srv.Requests()
+	assert.Equal(t, 5, countCallerRequests(requests, "reconciler"))
+	assert.Equal(t, 1, countCallerRequests(requests, "cli"))
+	assert.Equal(t, 2, countCallerRequests(requests, "default"))
+}
+
+func TestRetryPolicyDelay(t *testing.T) {
+	policy := RetryPolicy{MaxAttempts: 10, Backoff: 100 * time.Millisecond, MaxBackoff: time.Second}
+	var delays []time.Duration
+	for retry := 1; retry <= 6; retry++ {
+		delays = append(delays, policy.delay(retry))
+	}
+	assert.Equal(t, []time.Duration{
+		100 * time.Millisecond, 200 * time.Millisecond, 400 * time.Millisecond, 800 * time.Millisecond, time.Second, time.Second,
+	}, delays)
+	assert.Equal(t, defaultRetryBackoff, RetryPolicy{}.delay(1))
+	assert.Equal(t, defaultRetryMaxBackoff, RetryPolicy{}.delay(100))
+}
+
+func TestRetryStopsWhenContextIsDone(t *testing.T) {
+	srv := newTestServer(t, unavailableHandler)
+	rest := newTestRest(t, srv.config())
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel()
+
+	started := time.Now()
+	_, err := rest.Views.List(WithRetryPolicy(ctx, RetryPolicy{MaxAttempts: 10, Backoff: time.Minute}), nil)
+
+	require.Error(t, err)
+	assert.Less(t, time.Since(started), 5*time.Second)
+	assert.Len(t, srv.Requests(), 1)
+}
+
+func TestWithApiVersionPerCallConcurrent(t *testing.T) {
+	var (
+		mu       sync.Mutex
+		versions = map[string][]string{}
+	)
+	srv := newTestServer(t, func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		caller := r.URL.Query().Get("caller")
+		versions[caller] = append(versions[caller], strings.Split(strings.Trim(r.URL.Path, "/"), "/")[1])
+		mu.Unlock()
+		writeTestJSON(w, http.StatusOK, []map[string]any{})
+	})
+	config := srv.config()
+	config.ApiVersion = "v5"
+	rest := newTestRest(t, config)
+	rest.Quotas.SetApiVersion("v4")
+	ctx := context.Background()
+
+	var wg sync.WaitGroup
+	for range 5 {
+		wg.Add(3)
+		go func() {
+			defer wg.Done()
+			_, err := rest.Views.List(WithApiVersion(ctx, "v6"), Params{"caller": "context"})
+			assert.NoError(t, err)
+		}()
+		go func() {
+			defer wg.Done()
+			_, err := rest.Views.List(ctx, Params{"caller": "config"})
+			assert.NoError(t, err)
+		}()
+		go func() {
+			defer wg.Done()
+			_, err := rest.Quotas.List(WithApiVersion(ctx, "v6"), Params{"caller": "context over resource"})
+			assert.NoError(t, err)
+		}()
+	}
+	wg.Wait()
+	_, err := rest.Quotas.List(ctx, Params{"caller": "resource"})
+	require.NoError(t, err)
+
+	assert.Equal(t, map[string][]string{
+		"context":               {"v6", "v6", "v6", "v6", "v6"},
+		"config":                {"v5", "v5", "v5", "v5", "v5"},
+		"context over resource": {"v6", "v6", "v6", "v6", "v6"},
+		"resource":              {"v4"},
+	}, versions)
+}
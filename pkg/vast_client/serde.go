@@ -6,10 +6,13 @@ import (
 	"fmt"
 	"github.com/bndr/gotabulate"
 	"io"
+	"mime/multipart"
 	"net/http"
 	"reflect"
 	"sort"
 	"strings"
+	"sync/atomic"
+	"unicode/utf8"
 )
 
 const resourceTypeKey = "@resourceType"
@@ -30,6 +33,11 @@ var printableAttrs = map[string]struct{}{
 	"block_host":     empty,
 	"volume":         empty,
 	"state":          empty,
+	"hostname":       empty,
+	"ip":             empty,
+	"box":            empty,
+	"box_id":         empty,
+	"enclosure_id":   empty,
 }
 
 //  ######################################################
@@ -56,6 +64,61 @@ func (pr *Params) ToBody() (io.Reader, error) {
 	return bytes.NewReader(buffer), nil
 }
 
+// FormFile is value of Params sent as file part of multipart/form-data body (see ToMultipartBody).
+type FormFile struct {
+	Filename string
+	Content  []byte
+}
+
+// ToMultipartBody serializes the Params into multipart/form-data body suitable for upload endpoints.
+// FormFile values are sent as file parts, strings and other scalars as form fields and
+// lists/objects as JSON encoded form fields. Returns body along with Content-Type header value (including boundary).
+func (pr *Params) ToMultipartBody() (io.Reader, string, error) {
+	raw, contentType, err := pr.multipartBytes()
+	if err != nil {
+		return nil, "", err
+	}
+	return bytes.NewReader(raw), contentType, nil
+}
+
+func (pr *Params) multipartBytes() ([]byte, string, error) {
+	var buffer bytes.Buffer
+	writer := multipart.NewWriter(&buffer)
+	for _, key := range sortedKeys(*pr) {
+		var err error
+		switch value := (*pr)[key].(type) {
+		case FormFile:
+			var part io.Writer
+			if part, err = writer.CreateFormFile(key, value.Filename); err == nil {
+				_, err = part.Write(value.Content)
+			}
+		case *FormFile:
+			var part io.Writer
+			if part, err = writer.CreateFormFile(key, value.Filename); err == nil {
+				_, err = part.Write(value.Content)
+			}
+		case string:
+			err = writer.WriteField(key, value)
+		case nil:
+			continue
+		case []any, map[string]any, []string, Params:
+			var encoded []byte
+			if encoded, err = json.Marshal(value); err == nil {
+				err = writer.WriteField(key, string(encoded))
+			}
+		default:
+			err = writer.WriteField(key, fmt.Sprintf("%v", value))
+		}
+		if err != nil {
+			return nil, "", fmt.Errorf("failed to encode multipart field %q: %w", key, err)
+		}
+	}
+	if err := writer.Close(); err != nil {
+		return nil, "", err
+	}
+	return buffer.Bytes(), writer.FormDataContentType(), nil
+}
+
 // Update merges another Params map into the original Params.
 // If a key already exists and `override` is true, its value is skipped.
 // If a key doesn't exist, the key-value pair is added.
@@ -126,7 +189,8 @@ type RecordUnion interface {
 //   - As a fallback, it attempts to marshal/unmarshal the value via JSON to fit the expected type.
 //
 // Fields that are not exported (i.e., unexported lowercase names) cannot be set
-// and will cause an error if matched.
+// and will cause an error if matched. Fields missing in Record (e.g. partial record fetched with WithFields)
+// are left with zero values.
 //
 // Returns an error if the container is not a pointer to a struct or if a field
 // cannot be set due to visibility or type incompatibility.
@@ -207,8 +271,76 @@ func (r *Record) Fill(container any) error {
 	return nil
 }
 
-// Render prints a single Record as a table
+// RenderLimits restricts size of Render output so huge records don't end up in logs as megabyte lines.
+// Zero value of any field disables corresponding limit.
+type RenderLimits struct {
+	MaxValueSize int // Max size (in bytes) of single rendered value. Longer values are truncated.
+	MaxRows      int // Max number of rows in rendered table of single Record.
+	MaxTotalSize int // Max size (in bytes) of the whole rendered output.
+	MaxCellWidth int // Max width (in characters) of single cell in compact RecordSet table. Longer values are truncated.
+}
+
+// DefaultRenderLimits are limits applied by Render unless changed with SetRenderLimits.
+var DefaultRenderLimits = RenderLimits{
+	MaxValueSize: 4 * 1024,
+	MaxRows:      100,
+	MaxTotalSize: 64 * 1024,
+	MaxCellWidth: 40,
+}
+
+var renderLimits atomic.Pointer[RenderLimits]
+
+// SetRenderLimits sets limits used by Render for all Renderable types.
+func SetRenderLimits(limits RenderLimits) {
+	renderLimits.Store(&limits)
+}
+
+// getRenderLimits returns current render limits.
+func getRenderLimits() RenderLimits {
+	if limits := renderLimits.Load(); limits != nil {
+		return *limits
+	}
+	return DefaultRenderLimits
+}
+
+// truncateString cuts s to max bytes (keeping utf-8 runes intact) and appends truncation marker.
+// Zero or negative max means no limit.
+func truncateString(s string, max int) string {
+	if max <= 0 || len(s) <= max {
+		return s
+	}
+	cut := max
+	for cut > 0 && !utf8.RuneStart(s[cut]) {
+		cut--
+	}
+	return fmt.Sprintf("%s... (truncated, %d bytes)", s[:cut], len(s)-cut)
+}
+
+// Render prints a single Record as a table (or in format set with SetDefaultRenderFormat)
+// Values of sensitive fields (see SetSensitiveKeys) are redacted. Use RenderUnsafe to display raw values.
 func (r Record) Render() string {
+	return Record(redactMap(r)).renderFormat()
+}
+
+// RenderUnsafe prints Record like Render but without redaction of sensitive fields.
+// Avoid using it for output which ends up in logs.
+func (r Record) RenderUnsafe() string {
+	return r.renderFormat()
+}
+
+func (r Record) renderFormat() string {
+	switch getRenderFormat() {
+	case RenderFormatJSON:
+		return marshalRenderJSON(machineReadable(r), true)
+	case RenderFormatYAML:
+		return marshalRenderYAML(machineReadable(r))
+	default:
+		return r.render()
+	}
+}
+
+func (r Record) render() string {
+	limits := getRenderLimits()
 	headers := []string{"attr", "value"}
 	var rows [][]any
 	var name string
@@ -223,7 +355,7 @@ func (r Record) Render() string {
 	// Iterate over printable attributes and add them to rows
 	for _, key := range getPrintableAttrs(r) {
 		if val, ok := r[key]; ok && val != nil {
-			rows = append(rows, []any{key, fmt.Sprintf("%v", val)})
+			rows = append(rows, []any{key, truncateString(fmt.Sprintf("%v", val), limits.MaxValueSize)})
 		}
 	}
 
@@ -240,60 +372,311 @@ func (r Record) Render() string {
 	if len(remainingAttrs) > 0 {
 		// Marshal remainingAttrs into compact JSON
 		remainingJSON, _ := json.Marshal(remainingAttrs)
-		remainingJSONStr := string(remainingJSON)
+		remainingJSONStr := truncateString(string(remainingJSON), limits.MaxValueSize)
 		rows = append(rows, []any{"<<remaining attrs>>", remainingJSONStr})
 	}
+	if limits.MaxRows > 0 && len(rows) > limits.MaxRows {
+		omitted := len(rows) - limits.MaxRows
+		rows = append(rows[:limits.MaxRows], []any{"<<omitted rows>>", fmt.Sprintf("%d", omitted)})
+	}
 	t := gotabulate.Create(rows)
 	t.SetHeaders(headers)
 	t.SetAlign("left")
 	t.SetWrapStrings(true)
 	t.SetMaxCellSize(85)
-	return fmt.Sprintf("%s:\n%s", name, t.Render("grid"))
+	return truncateString(fmt.Sprintf("%s:\n%s", name, t.Render("grid")), limits.MaxTotalSize)
+}
+
+// RenderSummary prints only printable attributes of Record and number of omitted keys.
+// Unlike Render output size doesn't depend on size of non-printable attributes.
+func (r Record) RenderSummary() string {
+	if len(r) == 0 {
+		return "<>"
+	}
+	r = redactMap(r)
+	limits := getRenderLimits()
+	name := "<Unknown>"
+	if resourceTyp, ok := r[resourceTypeKey].(string); ok {
+		name = resourceTyp
+	}
+	var attrs []string
+	for _, key := range getPrintableAttrs(r) {
+		if val := r[key]; val != nil {
+			attrs = append(attrs, fmt.Sprintf("%s=%s", key, truncateString(fmt.Sprintf("%v", val), limits.MaxValueSize)))
+		}
+	}
+	omitted := len(r) - len(attrs)
+	if _, ok := r[resourceTypeKey]; ok {
+		omitted--
+	}
+	return fmt.Sprintf("%s{%s} (%d more keys omitted)", name, strings.Join(attrs, ", "), omitted)
 }
 
-// Render prints the full RecordSet by rendering each individual Record
+// Render prints the full RecordSet as single table with one row per Record
+// (see SetRecordSetLayout to render each individual Record separately)
 func (rs RecordSet) Render() string {
+	return rs.renderFormat(func(r Record) Record { return redactMap(r) })
+}
+
+// RenderUnsafe prints RecordSet like Render but without redaction of sensitive fields.
+func (rs RecordSet) RenderUnsafe() string {
+	return rs.renderFormat(func(r Record) Record { return r })
+}
+
+func (rs RecordSet) renderFormat(prepare func(Record) Record) string {
+	switch format := getRenderFormat(); format {
+	case RenderFormatJSON, RenderFormatYAML:
+		records := make([]map[string]any, len(rs))
+		for i, r := range rs {
+			records[i] = machineReadable(prepare(r))
+		}
+		if format == RenderFormatJSON {
+			return marshalRenderJSON(records, true)
+		}
+		return marshalRenderYAML(records)
+	default:
+		if getRecordSetLayout() == RecordSetLayoutVerbose {
+			return rs.render(func(r Record) string { return prepare(r).render() })
+		}
+		prepared := make(RecordSet, len(rs))
+		for i, r := range rs {
+			prepared[i] = prepare(r)
+		}
+		return prepared.renderCompact()
+	}
+}
+
+// RenderVerbose prints RecordSet as separate table per Record regardless of layout set with SetRecordSetLayout.
+func (rs RecordSet) RenderVerbose() string {
+	return rs.render(Record.Render)
+}
+
+// RecordSetLayout defines how RecordSet is rendered in table format.
+type RecordSetLayout string
+
+const (
+	RecordSetLayoutCompact RecordSetLayout = "compact" // Single table with one row per Record (default)
+	RecordSetLayoutVerbose RecordSetLayout = "verbose" // Separate attr/value table per Record
+)
+
+var recordSetLayout atomic.Pointer[RecordSetLayout]
+
+// SetRecordSetLayout sets layout used by RecordSet.Render in table format.
+func SetRecordSetLayout(layout RecordSetLayout) {
+	recordSetLayout.Store(&layout)
+}
+
+// getRecordSetLayout returns current RecordSet layout.
+func getRecordSetLayout() RecordSetLayout {
+	if layout := recordSetLayout.Load(); layout != nil {
+		return *layout
+	}
+	return RecordSetLayoutCompact
+}
+
+// compactColumns returns columns of compact RecordSet table.
+// Printable attributes present in any Record are used ("id" and "name" first),
+// if there are none union of all keys is used.
+func (rs RecordSet) compactColumns() []string {
+	printable := make(map[string]struct{})
+	all := make(map[string]struct{})
+	for _, r := range rs {
+		for key := range r {
+			if key == resourceTypeKey {
+				continue
+			}
+			all[key] = empty
+			if _, ok := printableAttrs[key]; ok {
+				printable[key] = empty
+			}
+		}
+	}
+	keys := printable
+	if len(keys) == 0 {
+		keys = all
+	}
+	columns := make([]string, 0, len(keys))
+	for key := range keys {
+		columns = append(columns, key)
+	}
+	rank := func(key string) int {
+		switch key {
+		case "id":
+			return 0
+		case "name":
+			return 1
+		default:
+			return 2
+		}
+	}
+	sort.Slice(columns, func(i, j int) bool {
+		if ri, rj := rank(columns[i]), rank(columns[j]); ri != rj {
+			return ri < rj
+		}
+		return columns[i] < columns[j]
+	})
+	return columns
+}
+
+// renderCompact prints RecordSet as single table with one row per Record.
+// Records missing a column (or having null value) show "-".
+func (rs RecordSet) renderCompact() string {
+	if len(rs) == 0 {
+		return "[]"
+	}
+	limits := getRenderLimits()
+	name := "<Unknown>"
+	if resourceTyp, ok := rs[0][resourceTypeKey].(string); ok {
+		name = resourceTyp
+	}
+	columns := rs.compactColumns()
+	if len(columns) == 0 {
+		return fmt.Sprintf("%s: %d empty records", name, len(rs))
+	}
+	records := rs
+	if limits.MaxRows > 0 && len(records) > limits.MaxRows {
+		records = records[:limits.MaxRows]
+	}
+	rows := make([][]any, 0, len(records))
+	for _, r := range records {
+		row := make([]any, len(columns))
+		for i, column := range columns {
+			val, ok := r[column]
+			if !ok || val == nil {
+				row[i] = "-"
+				continue
+			}
+			row[i] = truncateCell(fmt.Sprintf("%v", val), limits.MaxCellWidth)
+		}
+		rows = append(rows, row)
+	}
+	t := gotabulate.Create(rows)
+	t.SetHeaders(columns)
+	t.SetAlign("left")
+	t.SetWrapStrings(false)
+	out := fmt.Sprintf("%s:\n%s", name, t.Render("grid"))
+	if omitted := len(rs) - len(records); omitted > 0 {
+		out += fmt.Sprintf("<<%d omitted rows>>\n", omitted)
+	}
+	return truncateString(out, limits.MaxTotalSize)
+}
+
+// truncateCell cuts s to max characters replacing the tail with "...".
+// Zero or negative max means no limit.
+func truncateCell(s string, max int) string {
+	runes := []rune(s)
+	if max <= 0 || len(runes) <= max {
+		return s
+	}
+	if max <= 3 {
+		return string(runes[:max])
+	}
+	return string(runes[:max-3]) + "..."
+}
+
+func (rs RecordSet) render(renderRecord func(Record) string) string {
 	if len(rs) == 0 {
 		return "[]"
 	}
 	var out strings.Builder
 	out.WriteString("[\n")
 	for i, record := range rs {
-		out.WriteString(record.Render())
+		out.WriteString(renderRecord(record))
 		if i < len(rs)-1 {
 			out.WriteString("\n\n") // separate entries with a blank line
 		}
 	}
 	out.WriteString("\n]")
-	return out.String()
+	return truncateString(out.String(), getRenderLimits().MaxTotalSize)
+}
+
+// RenderSummary prints summary of each Record in RecordSet (see Record.RenderSummary)
+func (rs RecordSet) RenderSummary() string {
+	if len(rs) == 0 {
+		return "[]"
+	}
+	var out strings.Builder
+	out.WriteString("[\n")
+	for _, record := range rs {
+		out.WriteString("  " + record.RenderSummary() + "\n")
+	}
+	out.WriteString("]")
+	return truncateString(out.String(), getRenderLimits().MaxTotalSize)
 }
 
 // Render EmptyRecord
 func (er EmptyRecord) Render() string {
+	if getRenderFormat() != RenderFormatTable {
+		return "{}"
+	}
+	return "<>"
+}
+
+// RenderSummary EmptyRecord
+func (er EmptyRecord) RenderSummary() string {
 	return "<>"
 }
 
+// pageInfo holds metadata of paginated list response.
+// Paginated responses are wrapped in envelope: {"count": N, "next": "<url>", "previous": "<url>", "results": [...]}
+type pageInfo struct {
+	Count   *int64    `json:"count"`
+	Next    *string   `json:"next"`
+	Results RecordSet `json:"results"`
+}
+
+// hasNext reports whether there are more pages after current one.
+func (p *pageInfo) hasNext() bool {
+	return p != nil && p.Next != nil && *p.Next != ""
+}
+
 // unmarshalToRecordUnion unmarshall the response body into a generic Record/RecordSet structure.
 func unmarshalToRecordUnion[T RecordUnion](
 	response *http.Response,
 ) (T, error) {
+	result, _, err := decodeResponse[T](response)
+	return result, err
+}
+
+// decodeResponse unmarshall the response body into a generic Record/RecordSet structure.
+// Paginated envelope is unwrapped for RecordSet and its metadata is returned as pageInfo (nil if response is not paginated).
+func decodeResponse[T RecordUnion](response *http.Response) (T, *pageInfo, error) {
 	var result T
+	defer response.Body.Close()
 
 	switch any(result).(type) {
 	case EmptyRecord:
-		return result, nil
+		_, _ = io.Copy(io.Discard, response.Body)
+		return result, nil, nil
 	}
 	body, err := io.ReadAll(response.Body)
 	if err != nil {
-		return nil, err
+		return nil, nil, err
+	}
+	body = bytes.TrimSpace(body)
+	if len(body) == 0 {
+		// Some endpoints (e.g. DELETE) respond with empty body.
+		switch any(result).(type) {
+		case Record:
+			return any(Record{}).(T), nil, nil
+		case RecordSet:
+			return any(RecordSet{}).(T), nil, nil
+		}
+	}
+	if _, ok := any(result).(RecordSet); ok && body[0] == '{' {
+		var page pageInfo
+		if err = json.Unmarshal(body, &page); err != nil {
+			return nil, nil, err
+		}
+		if page.Results != nil {
+			return any(page.Results).(T), &page, nil
+		}
 	}
-	defer response.Body.Close()
-
 	err = json.Unmarshal(body, &result)
 	if err != nil {
-		return nil, err
+		return nil, nil, err
 	}
-	return result, nil
+	return result, nil, nil
 }
 
 // applyCallbackForRecordUnion applies the provided callback function to a response if
@@ -0,0 +1,129 @@
+package vast_client
+
+import (
+	"flag"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var updateGolden = flag.Bool("update", false, "update golden files in testdata")
+
+// assertGolden compares actual with content of testdata/<name>.golden (rewritten when -update flag is set).
+func assertGolden(t *testing.T, name, actual string) {
+	t.Helper()
+	path := filepath.Join("testdata", name+".golden")
+	if *updateGolden {
+		require.NoError(t, os.MkdirAll(filepath.Dir(path), 0o755))
+		require.NoError(t, os.WriteFile(path, []byte(actual), 0o644))
+	}
+	expected, err := os.ReadFile(path)
+	require.NoError(t, err)
+	assert.Equal(t, string(expected), actual)
+}
+
+// withRenderLimits sets render limits for the duration of test.
+func withRenderLimits(t *testing.T, limits RenderLimits) {
+	SetRenderLimits(limits)
+	t.Cleanup(func() { SetRenderLimits(DefaultRenderLimits) })
+}
+
+func TestTruncateString(t *testing.T) {
+	tests := []struct {
+		s    string
+		max  int
+		want string
+	}{
+		{s: "short", max: 10, want: "short"},
+		{s: "exactly10!", max: 10, want: "exactly10!"},
+		{s: "0123456789abcdef", max: 10, want: "0123456789... (truncated, 6 bytes)"},
+		{s: "no limit", max: 0, want: "no limit"},
+		{s: "ééééé", max: 3, want: "é... (truncated, 8 bytes)"}, // Multi-byte runes are kept intact
+	}
+	for _, tt := range tests {
+		t.Run(tt.s, func(t *testing.T) {
+			assert.Equal(t, tt.want, truncateString(tt.s, tt.max))
+		})
+	}
+}
+
+func TestRenderLimitsGolden(t *testing.T) {
+	snapshot := Record{
+		resourceTypeKey: "Snapshot",
+		"id":            1,
+		"name":          "snap",
+		"path":          "/data/" + strings.Repeat("x", 50),
+		"files":         strings.Split(strings.Repeat("file,", 20), ","),
+	}
+	views := RecordSet{}
+	for i := range 5 {
+		views = append(views, Record{resourceTypeKey: "View", "id": i + 1, "name": strings.Repeat("v", 30), "path": "/v"})
+	}
+	tests := []struct {
+		name   string
+		limits RenderLimits
+		render func() string
+	}{
+		{name: "record_value_size", limits: RenderLimits{MaxValueSize: 20}, render: snapshot.Render},
+		{name: "record_rows", limits: RenderLimits{MaxRows: 2}, render: snapshot.Render},
+		{name: "record_total_size", limits: RenderLimits{MaxTotalSize: 100}, render: snapshot.Render},
+		{name: "record_summary", limits: RenderLimits{MaxValueSize: 20}, render: snapshot.RenderSummary},
+		{name: "recordset_rows_and_cells", limits: RenderLimits{MaxRows: 2, MaxCellWidth: 10}, render: views.Render},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			withRenderLimits(t, tt.limits)
+			assertGolden(t, filepath.Join("render", tt.name), tt.render())
+		})
+	}
+}
+
+// withRecordSetLayout sets RecordSet layout for the duration of test.
+func withRecordSetLayout(t *testing.T, layout RecordSetLayout) {
+	SetRecordSetLayout(layout)
+	t.Cleanup(func() { SetRecordSetLayout(RecordSetLayoutCompact) })
+}
+
+func TestRecordSetLayoutGolden(t *testing.T) {
+	views := RecordSet{
+		{resourceTypeKey: "View", "id": 1, "name": "home", "path": "/home", "tenant_id": 1},
+		{resourceTypeKey: "View", "id": 2, "name": "a-very-long-view-name-which-is-truncated", "path": "/data"},
+		{resourceTypeKey: "View", "id": 3, "path": "/scratch", "tenant_id": 2},
+	}
+	unprintable := RecordSet{{"alpha": 1, "beta": "x"}, {"gamma": true}}
+	tests := []struct {
+		name   string
+		layout RecordSetLayout
+		render func() string
+	}{
+		{name: "recordset_compact", layout: RecordSetLayoutCompact, render: views.Render},
+		{name: "recordset_compact_all_keys", layout: RecordSetLayoutCompact, render: unprintable.Render},
+		{name: "recordset_verbose", layout: RecordSetLayoutVerbose, render: views.Render},
+		{name: "recordset_verbose_explicit", layout: RecordSetLayoutCompact, render: views.RenderVerbose},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			withRenderLimits(t, RenderLimits{MaxCellWidth: 12})
+			withRecordSetLayout(t, tt.layout)
+			assertGolden(t, filepath.Join("render", tt.name), tt.render())
+		})
+	}
+}
+
+func TestRenderCompactEmpty(t *testing.T) {
+	assert.Equal(t, "[]", RecordSet{}.renderCompact())
+	assert.Equal(t, "View: 2 empty records", RecordSet{{resourceTypeKey: "View"}, {resourceTypeKey: "View"}}.renderCompact())
+}
+
+func TestRenderSummaryIsBounded(t *testing.T) {
+	withRenderLimits(t, DefaultRenderLimits)
+	huge := Record{resourceTypeKey: "Snapshot", "id": 1, "name": "snap", "blob": strings.Repeat("x", 4<<20)}
+
+	summary := huge.RenderSummary()
+	assert.Equal(t, "Snapshot{id=1, name=snap} (1 more keys omitted)", summary)
+	assert.LessOrEqual(t, len(huge.Render()), DefaultRenderLimits.MaxTotalSize+len("... (truncated, 0000000 bytes)"))
+}
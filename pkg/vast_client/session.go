@@ -2,16 +2,46 @@ package vast_client
 
 import (
 	"bytes"
+	"compress/gzip"
 	"context"
 	"crypto/tls"
+	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
 	"net/http"
+	"net/url"
 	"runtime"
 	"strings"
 	"sync"
+	"sync/atomic"
+	"time"
 )
 
+// ClientTimeoutError is returned when request is aborted by timeout imposed by client
+// (VMSConfig.RequestTimeout or VMSConfig.Timeouts) as opposed to caller deadline or server-side timeout (e.g. 504).
+// It wraps context.DeadlineExceeded.
+type ClientTimeoutError struct {
+	Verb    string
+	URL     string
+	Timeout time.Duration
+	Err     error
+}
+
+func (e *ClientTimeoutError) Error() string {
+	return fmt.Sprintf("%s request to %s exceeded client timeout %s: %v", e.Verb, e.URL, e.Timeout, e.Err)
+}
+
+func (e *ClientTimeoutError) Unwrap() error {
+	return e.Err
+}
+
+// ErrClientClosed is returned for requests made after client was closed (see VMSRest.Close).
+var ErrClientClosed = errors.New("client closed")
+
+// ErrReadOnly is returned for mutating requests when VMSConfig.ReadOnly is set.
+var ErrReadOnly = errors.New("client is read-only, mutating request rejected")
+
 type RESTSession interface {
 	Get(context.Context, string, io.Reader) (*http.Response, error)
 	Post(context.Context, string, io.Reader) (*http.Response, error)
@@ -23,26 +53,85 @@ type RESTSession interface {
 }
 
 type VMSSession struct {
-	config *VMSConfig
-	client *http.Client
-	mu     sync.Mutex
-	auth   Authenticator
+	config  *VMSConfig
+	client  *http.Client
+	mu      sync.Mutex
+	auth    Authenticator
+	limiter *rateLimiter  // Client-side rate limiter (nil if VMSConfig.RequestsPerSecond is not set)
+	slots   chan struct{} // Semaphore of in-flight requests (nil if VMSConfig.MaxConcurrentRequests is not set)
+	closed  atomic.Bool   // Set by Close
+
+	apiVerMu   sync.Mutex
+	apiVersion string // API version negotiated with cluster (see ApiVersionAuto)
 }
 
-type VMSSessionMethod func(context.Context, string, io.Reader) (*http.Response, error)
+// concurrencyLimiter is implemented by sessions which bound number of in-flight requests.
+type concurrencyLimiter interface {
+	acquireSlot(ctx context.Context) (release func(), err error)
+}
 
-func NewVMSSession(config *VMSConfig) *VMSSession {
-	//Create a new session object
+// acquireSlot blocks until number of in-flight requests is below VMSConfig.MaxConcurrentRequests
+// or context is done. Returned release func must be called when request is completed.
+func (s *VMSSession) acquireSlot(ctx context.Context) (func(), error) {
+	if s.slots == nil {
+		return func() {}, nil
+	}
+	metrics := metricsRecorder(s.config)
+	select {
+	case s.slots <- struct{}{}:
+	case <-ctx.Done():
+		return nil, fmt.Errorf("waiting for free request slot aborted: %w", ctx.Err())
+	}
+	metrics.ObserveInFlight(len(s.slots), cap(s.slots))
+	return func() {
+		<-s.slots
+		metrics.ObserveInFlight(len(s.slots), cap(s.slots))
+	}, nil
+}
+
+// newTransport creates transport of session used by both data and token requests.
+// Proxy is taken from VMSConfig.ProxyURL or from environment (HTTP_PROXY, HTTPS_PROXY, NO_PROXY).
+func newTransport(config *VMSConfig) *http.Transport {
 	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.Proxy = http.ProxyFromEnvironment
+	if config.ProxyURL != "" {
+		// ProxyURL is validated by withProxyURL
+		if proxyUrl, err := url.Parse(config.ProxyURL); err == nil {
+			transport.Proxy = http.ProxyURL(proxyUrl)
+		}
+	}
 	transport.TLSClientConfig = &tls.Config{InsecureSkipVerify: !config.SslVerify}
 	transport.MaxConnsPerHost = config.MaxConnections
-	transport.IdleConnTimeout = *config.Timeout
-	client := &http.Client{Transport: transport}
-	return &VMSSession{
+	transport.IdleConnTimeout = config.IdleConnTimeout
+	// Accept-Encoding is set explicitly in setupHeaders (and response is decompressed in doRequest)
+	// so transport must not negotiate compression on its own.
+	transport.DisableCompression = true
+	return transport
+}
+
+// tokenClient returns client for token requests. It shares transport (proxy, TLS settings, connections) with session
+// but token requests don't go through session so they are not affected by rate limiting and concurrency limits.
+func (s *VMSSession) tokenClient() *http.Client {
+	return &http.Client{Transport: s.client.Transport, Timeout: tokenRequestTimeout}
+}
+
+type VMSSessionMethod func(context.Context, string, io.Reader) (*http.Response, error)
+
+func NewVMSSession(config *VMSConfig) *VMSSession {
+	//Create a new session object
+	client := &http.Client{Transport: newTransport(config)}
+	session := &VMSSession{
 		config: config,
 		client: client,
 		auth:   CreateAuthenticator(config),
 	}
+	if config.RequestsPerSecond > 0 {
+		session.limiter = newRateLimiter(config.RequestsPerSecond, config.Burst)
+	}
+	if config.MaxConcurrentRequests > 0 {
+		session.slots = make(chan struct{}, config.MaxConcurrentRequests)
+	}
+	return session
 }
 
 func request[T RecordUnion](
@@ -52,14 +141,29 @@ func request[T RecordUnion](
 	params, body Params,
 ) (T, error) {
 	var (
-		vmsMethod           VMSSessionMethod
-		query               string
-		data                io.Reader
-		beforeRequestCbData io.Reader
-		err                 error
+		vmsMethod VMSSessionMethod
+		err       error
 	)
 	verb = strings.ToUpper(verb)
 	session := r.Session()
+	config := session.GetConfig()
+	if config.ReadOnly && verb != http.MethodGet {
+		return nil, fmt.Errorf("%w: %s %s", ErrReadOnly, verb, path)
+	}
+	ctx = withTelemetry(ctx, config, r.GetResourceType())
+	ctx, cancel, clientTimeout := withVerbTimeout(ctx, config, verb)
+	defer cancel()
+	ctx, span := startSpan(ctx, config, r.GetResourceType(), verb)
+	defer span.End()
+	requestId, ok := requestIdFromContext(ctx)
+	if !ok {
+		requestId = newRequestId()
+		ctx = WithRequestID(ctx, requestId)
+	}
+
+	if scoped, ok := r.(tenantScopedResource); ok {
+		params, body = scoped.withTenantScope(verb, params, body)
+	}
 
 	switch verb {
 	case "GET":
@@ -75,37 +179,111 @@ func request[T RecordUnion](
 	default:
 		return nil, fmt.Errorf("unknown verb: %s", verb)
 	}
-	if params != nil {
-		query = params.ToQuery()
-	}
-	if body != nil {
-		data, err = body.ToBody()
-		if err != nil {
+	var bodyBytes []byte
+	switch {
+	case body != nil && isMultipart(ctx):
+		var contentType string
+		if bodyBytes, contentType, err = body.multipartBytes(); err != nil {
 			return nil, err
 		}
-		// Need to copy of dta for BeforeRequest Interceptor
-		beforeRequestCbData, err = body.ToBody()
-		if err != nil {
+		ctx = withContentType(ctx, contentType)
+	case body != nil:
+		if bodyBytes, err = json.Marshal(body); err != nil {
 			return nil, err
 		}
-	} else {
-		data = bytes.NewReader(nil)
 	}
-	url, err := buildUrl(session, path, query, apiVer)
-	if err != nil {
+	if ctxApiVer, ok := apiVersionFromContext(ctx); ok {
+		apiVer = ctxApiVer
+	}
+	if apiVer == "" {
+		if apiVer, err = sessionApiVersion(ctx, session); err != nil {
+			return nil, err
+		}
+	}
+	info := newRequestInfo(verb, requestId, params, bodyBytes)
+	if info.URL, err = buildUrl(session, path, info.Params.ToQuery(), apiVer); err != nil {
 		return nil, err
 	}
 	// before request interceptor
-	if err = r.doBeforeRequest(ctx, verb, url, beforeRequestCbData); err != nil {
+	if err = r.doBeforeRequest(ctx, info); err != nil {
+		return nil, err
+	}
+	// Params may be rewritten by interceptor so url is built again
+	url, err := buildUrl(session, path, info.Params.ToQuery(), apiVer)
+	if err != nil {
 		return nil, err
 	}
-	response, err := vmsMethod(ctx, url, data)
+	if len(info.Header) > 0 {
+		ctx = withInterceptorHeaders(ctx, info.Header)
+	}
+	if config.DryRun && verb != http.MethodGet {
+		return dryRunRequest[T](ctx, r, info, url, bodyBytes, body)
+	}
+	if limited, ok := session.(concurrencyLimiter); ok {
+		release, err := limited.acquireSlot(ctx)
+		if err != nil {
+			return nil, err
+		}
+		defer release()
+	}
+	// Requests without body (e.g. GET) are sent with nil body so no Content-Type is set (see setupHeaders)
+	newBody := func() io.Reader {
+		if bodyBytes == nil {
+			return nil
+		}
+		return bytes.NewReader(bodyBytes)
+	}
+	retryPolicy := resolveRetryPolicy(ctx, r, config)
+	started := time.Now()
+	response, err := vmsMethod(ctx, url, newBody())
+	for attempt := 1; err != nil && retryPolicy.shouldRetry(ctx, verb, attempt, err); attempt++ {
+		metricsRecorder(config).ObserveRetry(r.GetResourceType(), verb, attempt)
+		span.SetAttribute(SpanAttrRetryCount, attempt)
+		if !waitRetry(ctx, retryPolicy.delay(attempt)) {
+			break
+		}
+		response, err = vmsMethod(ctx, url, newBody())
+	}
+	responseInfo := ResponseInfo{Verb: verb, URL: url, Duration: time.Since(started), RequestID: requestId}
+	if response != nil {
+		responseInfo.StatusCode = response.StatusCode
+		responseInfo.Header = response.Header
+		if echoed := response.Header.Get(RequestIdHeader); echoed != "" {
+			responseInfo.RequestID = echoed
+		}
+	}
+	if meta := responseMetaFromContext(ctx); meta != nil {
+		*meta = responseInfo
+	}
+	metricsRecorder(config).ObserveRequest(r.GetResourceType(), verb, responseInfo.StatusCode, responseInfo.Duration)
+	setSpanUrl(span, url)
+	span.SetAttribute(SpanAttrStatusCode, responseInfo.StatusCode)
 	if err != nil {
+		if config.RedactErrorBodies {
+			redactApiError(err)
+		}
+		if clientTimeout > 0 && errors.Is(err, context.DeadlineExceeded) {
+			err = &ClientTimeoutError{Verb: verb, URL: url, Timeout: clientTimeout, Err: err}
+		}
+		span.RecordError(err)
+		logRequestFailed(ctx, config, r.GetResourceType(), responseInfo, bodyBytes, err)
+		_ = recordAudit(ctx, config, r.GetResourceType(), responseInfo, bodyBytes, nil, err)
 		return nil, err
 	}
-	result, err := unmarshalToRecordUnion[T](response)
+	result, page, err := decodeResponse[T](response)
 	if err != nil {
-		fmt.Println(err)
+		err = fmt.Errorf("failed to decode response: %w", err)
+		span.RecordError(err)
+		logRequestFailed(ctx, config, r.GetResourceType(), responseInfo, bodyBytes, err)
+		_ = recordAudit(ctx, config, r.GetResourceType(), responseInfo, bodyBytes, nil, err)
+		return nil, err
+	}
+	logRequestDone(ctx, config, r.GetResourceType(), responseInfo, bodyBytes, Renderable(result))
+	if err = recordAudit(ctx, config, r.GetResourceType(), responseInfo, bodyBytes, result, nil); err != nil {
+		return nil, err
+	}
+	if capture := pageCaptureFromContext(ctx); capture != nil && page != nil {
+		*capture = *page
 	}
 	// Set resource type key so .Render can recognize resource type
 	result, err = setResourceKey[T](result, err, r.GetResourceType())
@@ -113,7 +291,41 @@ func request[T RecordUnion](
 		return nil, err
 	}
 	// after request interceptor
-	interceptedResult, err := r.doAfterRequest(Renderable(result))
+	interceptedResult, err := r.doAfterRequest(ctx, responseInfo, Renderable(result))
+	if err != nil {
+		return nil, err
+	}
+	return interceptedResult.(T), nil
+}
+
+// DryRunKey marks synthetic responses returned for mutating requests when VMSConfig.DryRun is set.
+const DryRunKey = "dry_run"
+
+// dryRunRequest logs mutating request instead of sending it and returns synthetic response:
+// Record echoing request body with DryRunKey marker, empty RecordSet or EmptyRecord.
+func dryRunRequest[T RecordUnion](ctx context.Context, r InterceptableVastResource, info *RequestInfo, url string, bodyBytes []byte, body Params) (T, error) {
+	config := r.Session().GetConfig()
+	responseInfo := ResponseInfo{Verb: info.Verb, URL: url, RequestID: info.RequestID}
+	logDryRun(ctx, config, r.GetResourceType(), responseInfo, bodyBytes)
+	var result T
+	switch any(result).(type) {
+	case Record:
+		record := Record{}
+		for key, value := range body {
+			record[key] = value
+		}
+		record[DryRunKey] = true
+		result = any(record).(T)
+	case RecordSet:
+		result = any(RecordSet{}).(T)
+	case EmptyRecord:
+		result = any(EmptyRecord{}).(T)
+	}
+	result, err := setResourceKey[T](result, nil, r.GetResourceType())
+	if err != nil {
+		return nil, err
+	}
+	interceptedResult, err := r.doAfterRequest(ctx, responseInfo, Renderable(result))
 	if err != nil {
 		return nil, err
 	}
@@ -140,27 +352,131 @@ func (s *VMSSession) Delete(ctx context.Context, url string, body io.Reader) (*h
 	return doRequest(ctx, s, http.MethodDelete, url, body)
 }
 
+// Close stops background activity of the session (e.g. token pre-refresh goroutine) and closes idle connections.
+// If VMSConfig.RevokeTokenOnClose is set JWT refresh token is revoked.
+// Further requests made through the session fail with ErrClientClosed.
+func (s *VMSSession) Close() error {
+	if !s.closed.CompareAndSwap(false, true) {
+		return nil
+	}
+	var err error
+	if jwtAuth, ok := resolvedAuthenticator(s.auth).(*JWTAuthenticator); ok {
+		jwtAuth.stopRefresher()
+		if s.config.RevokeTokenOnClose {
+			err = jwtAuth.revokeToken(s)
+		}
+	}
+	s.client.CloseIdleConnections()
+	return err
+}
+
 func (s *VMSSession) GetConfig() *VMSConfig {
 	return s.config
 }
 func (s *VMSSession) Lock()   { s.mu.Lock() }
 func (s *VMSSession) Unlock() { s.mu.Unlock() }
 
+// setupHeaders sets headers of request. Headers are set (not added) so setup is idempotent.
+// Content-Type is set only for requests with body.
 func setupHeaders(s *VMSSession, r *http.Request) error {
 	if err := s.auth.SetAuthHeader(s, &r.Header); err != nil {
 		return err
 	}
-	r.Header.Add("Accept", ApplicationJson)
-	r.Header.Add("Content-type", ApplicationJson)
+	r.Header.Set("Accept", ApplicationJson)
+	if !s.config.DisableCompression {
+		r.Header.Set("Accept-Encoding", "gzip")
+	}
+	if r.Body != nil && r.Body != http.NoBody {
+		r.Header.Set("Content-Type", contentTypeFromContext(r.Context()))
+	}
 	userAgent := fmt.Sprintf("%s, OS:%s, Arch:%s", s.config.UserAgent, runtime.GOOS, runtime.GOARCH)
 	r.Header.Set("User-Agent", userAgent)
+	if token, ok := r.Context().Value(telemetryCtxKey{}).(string); ok {
+		r.Header.Set(TelemetryHeader, token)
+	}
+	if requestId, ok := requestIdFromContext(r.Context()); ok {
+		r.Header.Set(RequestIdHeader, requestId)
+	}
+	if err := setExtraHeaders(r, s.config.DefaultHeaders, headersFromContext(r.Context())); err != nil {
+		return err
+	}
+	// Headers added by before request interceptors (see RequestInfo) are applied last
+	for key, values := range interceptorHeadersFromContext(r.Context()) {
+		if err := checkReservedHeaders(map[string]string{key: ""}); err != nil {
+			return err
+		}
+		r.Header[http.CanonicalHeaderKey(key)] = values
+	}
+	return nil
+}
+
+// reservedHeaders are headers managed by client which cannot be overridden by extra headers.
+var reservedHeaders = []string{"Authorization", "Content-Type"}
+
+// checkReservedHeaders returns error if any of headers is reserved.
+func checkReservedHeaders(headers map[string]string) error {
+	for key := range headers {
+		for _, reserved := range reservedHeaders {
+			if strings.EqualFold(key, reserved) {
+				return fmt.Errorf("header %q is reserved and cannot be overridden", reserved)
+			}
+		}
+	}
+	return nil
+}
+
+// setExtraHeaders sets default headers and then headers from context (so context headers win).
+func setExtraHeaders(r *http.Request, defaultHeaders, ctxHeaders map[string]string) error {
+	for _, headers := range []map[string]string{defaultHeaders, ctxHeaders} {
+		if err := checkReservedHeaders(headers); err != nil {
+			return err
+		}
+		for key, value := range headers {
+			r.Header.Set(key, value)
+		}
+	}
 	return nil
 }
 
+// decompressResponse replaces gzip-compressed response body with decompressing reader.
+func decompressResponse(response *http.Response) error {
+	if !strings.EqualFold(response.Header.Get("Content-Encoding"), "gzip") {
+		return nil
+	}
+	reader, err := gzip.NewReader(response.Body)
+	if err != nil {
+		response.Body.Close()
+		return err
+	}
+	response.Body = &gzipBody{Reader: reader, raw: response.Body}
+	response.Header.Del("Content-Encoding")
+	response.Header.Del("Content-Length")
+	response.ContentLength = -1
+	response.Uncompressed = true
+	return nil
+}
+
+// gzipBody closes both decompressing reader and underlying response body.
+type gzipBody struct {
+	*gzip.Reader
+	raw io.ReadCloser
+}
+
+func (b *gzipBody) Close() error {
+	b.Reader.Close()
+	return b.raw.Close()
+}
+
 func doRequest(ctx context.Context, s *VMSSession, verb, url string, body io.Reader) (*http.Response, error) {
 	// Create the new HTTP request using the context
-	if body == nil {
-		body = bytes.NewReader(nil)
+	if s.closed.Load() {
+		return nil, fmt.Errorf("%w: %s %s", ErrClientClosed, verb, url)
+	}
+	// Token requests don't go through session so auth can't be starved by rate limiter.
+	if s.limiter != nil {
+		if err := s.limiter.Wait(ctx); err != nil {
+			return nil, fmt.Errorf("rate limiter wait for %s request to %s aborted: %w", verb, url, err)
+		}
 	}
 	req, err := http.NewRequestWithContext(ctx, verb, url, body)
 	if err != nil {
@@ -171,7 +487,10 @@ func doRequest(ctx context.Context, s *VMSSession, verb, url string, body io.Rea
 	}
 	response, responseErr := s.client.Do(req)
 	if responseErr != nil {
-		return nil, fmt.Errorf("failed to perform %s request to %s, error %v", verb, url, responseErr)
+		return nil, fmt.Errorf("failed to perform %s request to %s, error %w", verb, url, responseErr)
+	}
+	if err = decompressResponse(response); err != nil {
+		return nil, fmt.Errorf("failed to decompress response of %s request to %s: %w", verb, url, err)
 	}
 	return validateResponse(response)
 }
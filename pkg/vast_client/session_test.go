@@ -0,0 +1,177 @@
+package vast_client
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"runtime"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRequestHeaders(t *testing.T) {
+	userAgent := "test-agent, OS:" + runtime.GOOS + ", Arch:" + runtime.GOARCH
+	common := http.Header{
+		"Accept":          {ApplicationJson},
+		"Accept-Encoding": {"gzip"},
+		"Authorization":   {"Api-Token " + testApiToken},
+		"User-Agent":      {userAgent},
+	}
+	withBody := func(contentLength string) http.Header {
+		header := common.Clone()
+		header.Set("Content-Type", ApplicationJson)
+		header.Set("Content-Length", contentLength)
+		return header
+	}
+	tests := []struct {
+		name       string
+		call       func(ctx context.Context, rest *VMSRest) error
+		wantHeader http.Header
+		wantBody   string
+	}{
+		{
+			name: "GET",
+			call: func(ctx context.Context, rest *VMSRest) error {
+				_, err := rest.Views.List(ctx, Params{"name": "v"})
+				return err
+			},
+			wantHeader: common,
+		},
+		{
+			name: "DELETE",
+			call: func(ctx context.Context, rest *VMSRest) error {
+				_, err := rest.Views.DeleteById(ctx, 1)
+				return err
+			},
+			wantHeader: common,
+		},
+		{
+			name: "POST",
+			call: func(ctx context.Context, rest *VMSRest) error {
+				_, err := rest.Views.Create(ctx, Params{"path": "/v", "policy_id": 1})
+				return err
+			},
+			wantHeader: withBody("27"),
+			wantBody:   `{"path":"/v","policy_id":1}`,
+		},
+		{
+			name: "PATCH",
+			call: func(ctx context.Context, rest *VMSRest) error {
+				_, err := rest.Views.Update(ctx, 1, Params{"name": "v"})
+				return err
+			},
+			wantHeader: withBody("12"),
+			wantBody:   `{"name":"v"}`,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			srv := newTestServer(t, recordsHandler())
+			config := srv.config()
+			config.UserAgent = "test-agent"
+			rest := newTestRest(t, config)
+
+			require.NoError(t, tt.call(context.Background(), rest))
+
+			requests := srv.Requests()
+			require.Len(t, requests, 1)
+			header := requests[0].Header.Clone()
+			assert.NotEmpty(t, header.Get("X-Request-Id"))
+			header.Del("X-Request-Id")
+			assert.Equal(t, tt.wantHeader, header)
+			assert.Equal(t, tt.wantBody, string(requests[0].Body))
+		})
+	}
+}
+
+func TestRequestHeadersAreNotDuplicatedOnRetry(t *testing.T) {
+	var attempts atomic.Int32
+	records := recordsHandler()
+	srv := newTestServer(t, func(w http.ResponseWriter, r *http.Request) {
+		if attempts.Add(1) < 3 {
+			unavailableHandler(w, r)
+			return
+		}
+		records(w, r)
+	})
+	rest := newTestRest(t, srv.config())
+	ctx := WithRetryPolicy(context.Background(), RetryPolicy{MaxAttempts: 3, Backoff: time.Millisecond})
+
+	_, err := rest.Views.List(ctx, nil)
+	require.NoError(t, err)
+
+	requests := srv.Requests()
+	require.Len(t, requests, 3)
+	for _, r := range requests {
+		for key, values := range r.Header {
+			assert.Len(t, values, 1, key)
+		}
+		assert.NotContains(t, r.Header, "Content-Type")
+		assert.Equal(t, requests[0].Header.Get("X-Request-Id"), r.Header.Get("X-Request-Id"), "retries keep request id")
+	}
+}
+
+// recordingProxy is forward HTTP proxy stub which records paths of proxied requests.
+type recordingProxy struct {
+	*httptest.Server
+
+	mu    sync.Mutex
+	paths []string
+}
+
+func newRecordingProxy(t *testing.T) *recordingProxy {
+	t.Helper()
+	proxy := &recordingProxy{}
+	proxy.Server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		proxy.mu.Lock()
+		proxy.paths = append(proxy.paths, r.Method+" "+resourceFromPath(r.URL.Path))
+		proxy.mu.Unlock()
+		outgoing := r.Clone(r.Context())
+		outgoing.RequestURI = ""
+		response, err := http.DefaultTransport.RoundTrip(outgoing)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadGateway)
+			return
+		}
+		defer response.Body.Close()
+		for key, values := range response.Header {
+			w.Header()[key] = values
+		}
+		w.WriteHeader(response.StatusCode)
+		_, _ = io.Copy(w, response.Body)
+	}))
+	t.Cleanup(proxy.Close)
+	return proxy
+}
+
+// Paths returns "<method> <resource path>" of requests proxied so far.
+func (p *recordingProxy) Paths() []string {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return append([]string(nil), p.paths...)
+}
+
+func TestProxyURL(t *testing.T) {
+	srv := newTestServer(t, recordsHandler())
+	proxy := newRecordingProxy(t)
+	rest, err := newVMSRest(&VMSConfig{BaseURL: srv.URL, Username: "admin", Password: "123456", ProxyURL: proxy.URL, RevokeTokenOnClose: true})
+	require.NoError(t, err)
+
+	_, err = rest.Views.List(context.Background(), nil)
+	require.NoError(t, err)
+	require.NoError(t, rest.Close())
+
+	assert.Equal(t, []string{"POST token", "GET views", "POST token/blacklist"}, proxy.Paths())
+	assert.Equal(t, []string{"GET views"}, methodsAndPaths(srv.Requests()))
+}
+
+func TestInvalidProxyURL(t *testing.T) {
+	_, err := newVMSRest(&VMSConfig{BaseURL: "https://vms.local", ApiToken: testApiToken, ProxyURL: "ftp://proxy.local"})
+	assert.ErrorContains(t, err, "invalid proxy url")
+}
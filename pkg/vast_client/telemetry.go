@@ -0,0 +1,47 @@
+package vast_client
+
+import (
+	"context"
+	"fmt"
+)
+
+// ClientVersion is the version of this client. It is reported in the telemetry header when telemetry is enabled.
+const ClientVersion = "0.1.0"
+
+// TelemetryHeader is the header used to describe client code path to VMS (see VMSConfig.EnableTelemetry)
+const TelemetryHeader = "X-Vast-Client-Feature"
+
+// defaultFeature is reported for plain CRUD calls made outside any helper.
+const defaultFeature = "crud"
+
+type featureCtxKey struct{}
+
+type telemetryCtxKey struct{}
+
+// withFeature marks context with the name of the helper (ensure, bulk, wait etc.) that issues requests.
+// The outermost helper wins, so nested helper calls report the code path user actually invoked.
+func withFeature(ctx context.Context, feature string) context.Context {
+	if _, ok := ctx.Value(featureCtxKey{}).(string); ok {
+		return ctx
+	}
+	return context.WithValue(ctx, featureCtxKey{}, feature)
+}
+
+// telemetryToken builds compact telemetry token for request.
+// Format: "go-vast-client/<version>; resource=<resource type>; feature=<helper name>"
+// NOTE: Token never contains any payload data.
+func telemetryToken(ctx context.Context, resourceType string) string {
+	feature, ok := ctx.Value(featureCtxKey{}).(string)
+	if !ok {
+		feature = defaultFeature
+	}
+	return fmt.Sprintf("go-vast-client/%s; resource=%s; feature=%s", ClientVersion, resourceType, feature)
+}
+
+// withTelemetry stores telemetry token in context so session can set TelemetryHeader on outgoing request.
+func withTelemetry(ctx context.Context, config *VMSConfig, resourceType string) context.Context {
+	if !config.EnableTelemetry {
+		return ctx
+	}
+	return context.WithValue(ctx, telemetryCtxKey{}, telemetryToken(ctx, resourceType))
+}
@@ -0,0 +1,69 @@
+package vast_client
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTelemetryHeader(t *testing.T) {
+	tests := []struct {
+		name    string
+		enabled bool
+		call    func(ctx context.Context, rest *VMSRest) error
+		want    string
+	}{
+		{
+			name: "disabled by default",
+			call: func(ctx context.Context, rest *VMSRest) error {
+				_, err := rest.Views.List(ctx, nil)
+				return err
+			},
+		},
+		{
+			name:    "plain crud",
+			enabled: true,
+			call: func(ctx context.Context, rest *VMSRest) error {
+				_, err := rest.Views.List(ctx, nil)
+				return err
+			},
+			want: "go-vast-client/" + ClientVersion + "; resource=View; feature=crud",
+		},
+		{
+			name:    "helper",
+			enabled: true,
+			call: func(ctx context.Context, rest *VMSRest) error {
+				_, err := rest.Quotas.Ensure(ctx, "q", Params{"path": "/q"})
+				return err
+			},
+			want: "go-vast-client/" + ClientVersion + "; resource=Quota; feature=ensure",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			srv := newTestServer(t, recordsHandler())
+			config := srv.config()
+			config.EnableTelemetry = tt.enabled
+			rest := newTestRest(t, config)
+
+			require.NoError(t, tt.call(context.Background(), rest))
+
+			requests := srv.Requests()
+			require.NotEmpty(t, requests)
+			for _, r := range requests {
+				if tt.want == "" {
+					assert.NotContains(t, r.Header, TelemetryHeader)
+				} else {
+					assert.Equal(t, tt.want, r.Header.Get(TelemetryHeader))
+				}
+			}
+		})
+	}
+}
+
+func TestWithFeatureOutermostWins(t *testing.T) {
+	ctx := withFeature(withFeature(context.Background(), "bulk"), "ensure")
+	assert.Equal(t, "go-vast-client/"+ClientVersion+"; resource=View; feature=bulk", telemetryToken(ctx, "View"))
+}
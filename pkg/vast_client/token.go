@@ -0,0 +1,135 @@
+package vast_client
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"strings"
+	"time"
+)
+
+// SessionToken is JWT token pair of session exported with VMSRest.ExportToken.
+// It can be passed (as JSON) to other processes which pre-seed their clients with ImportToken
+// so they don't need username/password until refresh token expires.
+// Tokens are redacted when SessionToken is formatted or logged.
+type SessionToken struct {
+	Access  string    `json:"access_token"`
+	Refresh string    `json:"refresh_token"`
+	Expiry  time.Time `json:"expiry"` // Time after which access token is refreshed
+}
+
+func (t SessionToken) String() string {
+	return fmt.Sprintf("SessionToken{access: %s, refresh: %s, expiry: %s}", RedactedValue, RedactedValue, t.Expiry.Format(time.RFC3339))
+}
+
+func (t SessionToken) GoString() string {
+	return t.String()
+}
+
+func (t SessionToken) LogValue() slog.Value {
+	return slog.StringValue(t.String())
+}
+
+// AuthToken authorizes session (if it is not authorized yet) and returns its current JWT tokens.
+// Returns error if session doesn't use username/password authentication.
+func (rest *VMSRest) AuthToken(ctx context.Context) (access, refresh string, expiry time.Time, err error) {
+	token, err := rest.sessionToken(ctx)
+	if err != nil {
+		return "", "", time.Time{}, err
+	}
+	return token.Access, token.Refresh, token.Expiry, nil
+}
+
+// ExportToken returns JSON serialized SessionToken of authorized session (see AuthToken).
+func (rest *VMSRest) ExportToken(ctx context.Context) ([]byte, error) {
+	token, err := rest.sessionToken(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return json.Marshal(token)
+}
+
+// ImportToken pre-seeds config with JSON serialized SessionToken made by ExportToken
+// (see VMSConfig.InitialAccessToken).
+func ImportToken(config *VMSConfig, data []byte) error {
+	var token SessionToken
+	if err := json.Unmarshal(data, &token); err != nil {
+		return fmt.Errorf("invalid session token: %w", err)
+	}
+	if token.Access == "" || token.Refresh == "" {
+		return errors.New("invalid session token: access and refresh tokens are required")
+	}
+	config.InitialAccessToken = token.Access
+	config.InitialRefreshToken = token.Refresh
+	return nil
+}
+
+func (rest *VMSRest) sessionToken(ctx context.Context) (SessionToken, error) {
+	if err := ctx.Err(); err != nil {
+		return SessionToken{}, err
+	}
+	session, ok := rest.Session.(*VMSSession)
+	if !ok {
+		return SessionToken{}, fmt.Errorf("token export is supported only for *VMSSession, got %T", rest.Session)
+	}
+	if creds, ok := session.auth.(*credentialsAuthenticator); ok {
+		// Authentication method of credentials provider is resolved on first use
+		if _, err := creds.authenticator(session); err != nil {
+			return SessionToken{}, err
+		}
+	}
+	auth, ok := resolvedAuthenticator(session.auth).(*JWTAuthenticator)
+	if !ok {
+		return SessionToken{}, errors.New("token export requires username/password authentication")
+	}
+	if err := auth.Authorize(session); err != nil {
+		return SessionToken{}, err
+	}
+	session.Lock()
+	defer session.Unlock()
+	if auth.Token == nil {
+		// Token was revoked concurrently (see VMSRest.Close)
+		return SessionToken{}, ErrClientClosed
+	}
+	return SessionToken{
+		Access:  auth.Token.Access,
+		Refresh: auth.Token.Refresh,
+		Expiry:  auth.Token.CreatedAt.Add(TokenRefreshTime),
+	}, nil
+}
+
+// seededToken returns token pre-seeded with VMSConfig.InitialAccessToken and VMSConfig.InitialRefreshToken.
+// Creation time is taken from "iat" claim of access token (if it can be decoded).
+func seededToken(config *VMSConfig) *jwtToken {
+	token := &jwtToken{
+		Access:    config.InitialAccessToken,
+		Refresh:   config.InitialRefreshToken,
+		CreatedAt: time.Now(),
+	}
+	if issuedAt, ok := jwtIssuedAt(config.InitialAccessToken); ok {
+		token.CreatedAt = issuedAt
+	}
+	return token
+}
+
+// jwtIssuedAt decodes "iat" claim of JWT without verifying signature.
+func jwtIssuedAt(token string) (time.Time, bool) {
+	parts := strings.Split(token, ".")
+	if len(parts) != 3 {
+		return time.Time{}, false
+	}
+	payload, err := base64.RawURLEncoding.DecodeString(parts[1])
+	if err != nil {
+		return time.Time{}, false
+	}
+	var claims struct {
+		IssuedAt int64 `json:"iat"`
+	}
+	if err = json.Unmarshal(payload, &claims); err != nil || claims.IssuedAt == 0 {
+		return time.Time{}, false
+	}
+	return time.Unix(claims.IssuedAt, 0), true
+}
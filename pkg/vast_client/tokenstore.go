@@ -0,0 +1,164 @@
+package vast_client
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+)
+
+// StoredToken is JWT token pair persisted by TokenStore.
+type StoredToken struct {
+	Access    string    `json:"access_token"`
+	Refresh   string    `json:"refresh_token"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// TokenStore persists JWT tokens between process restarts (see VMSConfig.TokenStore).
+// Tokens are stored by key identifying cluster and user ("<username>@<host>:<port>").
+type TokenStore interface {
+	// Load returns stored token or nil if there is no token for the key.
+	Load(ctx context.Context, key string) (*StoredToken, error)
+	Save(ctx context.Context, key string, token StoredToken) error
+}
+
+// FileTokenStore is TokenStore which keeps tokens in JSON file readable only by owner (0600).
+// Tokens of several clusters/users can be kept in the same file.
+type FileTokenStore struct {
+	Path string
+
+	mu sync.Mutex
+}
+
+// NewFileTokenStore creates FileTokenStore keeping tokens in file at path.
+func NewFileTokenStore(path string) *FileTokenStore {
+	return &FileTokenStore{Path: path}
+}
+
+func (s *FileTokenStore) Load(_ context.Context, key string) (*StoredToken, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	tokens, err := s.read()
+	if err != nil {
+		return nil, err
+	}
+	token, ok := tokens[key]
+	if !ok {
+		return nil, nil
+	}
+	return &token, nil
+}
+
+func (s *FileTokenStore) Save(_ context.Context, key string, token StoredToken) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	tokens, err := s.read()
+	if err != nil {
+		// Corrupted file is overwritten
+		tokens = map[string]StoredToken{}
+	}
+	tokens[key] = token
+	raw, err := json.Marshal(tokens)
+	if err != nil {
+		return err
+	}
+	// Write to temporary file and rename so concurrent readers never see partially written file
+	tmp, err := os.CreateTemp(filepath.Dir(s.Path), filepath.Base(s.Path)+".*")
+	if err != nil {
+		return fmt.Errorf("failed to save token: %w", err)
+	}
+	defer os.Remove(tmp.Name())
+	if err = tmp.Chmod(0o600); err == nil {
+		_, err = tmp.Write(raw)
+	}
+	if closeErr := tmp.Close(); err == nil {
+		err = closeErr
+	}
+	if err == nil {
+		err = os.Rename(tmp.Name(), s.Path)
+	}
+	if err != nil {
+		return fmt.Errorf("failed to save token: %w", err)
+	}
+	return nil
+}
+
+// read returns all tokens kept in file. Missing file means there are no tokens.
+func (s *FileTokenStore) read() (map[string]StoredToken, error) {
+	raw, err := os.ReadFile(s.Path)
+	if errors.Is(err, os.ErrNotExist) {
+		return map[string]StoredToken{}, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read token store: %w", err)
+	}
+	tokens := map[string]StoredToken{}
+	if err = json.Unmarshal(raw, &tokens); err != nil {
+		return nil, fmt.Errorf("failed to parse token store %s: %w", s.Path, err)
+	}
+	return tokens, nil
+}
+
+// tokenStoreKey returns key of tokens of user in TokenStore.
+func tokenStoreKey(username string, config *VMSConfig) string {
+	return username + "@" + hostPort(config)
+}
+
+// tokenStore returns VMSConfig.TokenStore unless token cache is disabled.
+func tokenStore(config *VMSConfig) TokenStore {
+	if config.DisableTokenCache {
+		return nil
+	}
+	return config.TokenStore
+}
+
+// loadStoredToken loads token from VMSConfig.TokenStore (if configured). Returns false if there is no usable token.
+// Username of the key is taken from credentials provider if it is set. Store is skipped if username is unknown.
+// Loaded token is not trusted: it is validated by refresh before use (see renewToken).
+func (auth *JWTAuthenticator) loadStoredToken(config *VMSConfig) bool {
+	store := tokenStore(config)
+	if store == nil {
+		return false
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), tokenRequestTimeout)
+	defer cancel()
+	username, _, _, err := credentials(ctx, config)
+	if err != nil || username == "" {
+		return false
+	}
+	stored, err := store.Load(ctx, tokenStoreKey(username, config))
+	if err != nil {
+		logWarn(config, "failed to load vast token from store", slog.String("error", err.Error()))
+		return false
+	}
+	if stored == nil || stored.Refresh == "" {
+		return false
+	}
+	auth.Token = &jwtToken{Access: stored.Access, Refresh: stored.Refresh, CreatedAt: stored.CreatedAt}
+	auth.tokenUsername = username
+	return true
+}
+
+// saveStoredToken saves current token to VMSConfig.TokenStore (if configured) under username token is issued for.
+// Token of unknown user (e.g. pre-seeded token without username) is not saved. Failures are only logged.
+func (auth *JWTAuthenticator) saveStoredToken(config *VMSConfig) {
+	store := tokenStore(config)
+	username := auth.tokenUsername
+	if username == "" {
+		username = auth.Username
+	}
+	if store == nil || auth.Token == nil || username == "" {
+		return
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), tokenRequestTimeout)
+	defer cancel()
+	token := StoredToken{Access: auth.Token.Access, Refresh: auth.Token.Refresh, CreatedAt: auth.Token.CreatedAt}
+	if err := store.Save(ctx, tokenStoreKey(username, config), token); err != nil {
+		logWarn(config, "failed to save vast token to store", slog.String("error", err.Error()))
+	}
+}
@@ -0,0 +1,208 @@
+package vast_client
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// tokenServer emulates VMS which counts logins and refreshes and accepts only refresh tokens it issued.
+type tokenServer struct {
+	*httptest.Server
+
+	mu        sync.Mutex
+	issued    map[string]string // Refresh token -> username
+	logins    []string          // Usernames of logins
+	refreshes int
+}
+
+func newTokenServer(t *testing.T) *tokenServer {
+	t.Helper()
+	srv := &tokenServer{issued: map[string]string{}}
+	srv.Server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var body map[string]string
+		_ = json.NewDecoder(r.Body).Decode(&body)
+		srv.mu.Lock()
+		defer srv.mu.Unlock()
+		var username string
+		switch resourceFromPath(r.URL.Path) {
+		case "token":
+			username = body["username"]
+			srv.logins = append(srv.logins, username)
+		case "token/refresh":
+			var ok bool
+			if username, ok = srv.issued[body["refresh"]]; !ok {
+				writeTestJSON(w, http.StatusUnauthorized, map[string]string{"detail": "token is invalid or expired"})
+				return
+			}
+			srv.refreshes++
+		default:
+			writeTestJSON(w, http.StatusOK, []map[string]any{})
+			return
+		}
+		n := len(srv.issued) + 1
+		refresh := fmt.Sprintf("refresh-%s-%d", username, n)
+		srv.issued[refresh] = username
+		writeTestJSON(w, http.StatusOK, map[string]string{"access": fmt.Sprintf("access-%d", n), "refresh": refresh})
+	}))
+	t.Cleanup(srv.Close)
+	return srv
+}
+
+// counts returns logins and number of refreshes received so far.
+func (s *tokenServer) counts() ([]string, int) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]string(nil), s.logins...), s.refreshes
+}
+
+// hostPort returns "<host>:<port>" of server as used in TokenStore keys.
+func (s *tokenServer) hostPort() string {
+	parsed, _ := url.Parse(s.URL)
+	return parsed.Host
+}
+
+func TestFileTokenStore(t *testing.T) {
+	ctx := context.Background()
+	path := filepath.Join(t.TempDir(), "tokens.json")
+	store := NewFileTokenStore(path)
+
+	token, err := store.Load(ctx, "admin@vms:443")
+	require.NoError(t, err)
+	assert.Nil(t, token, "missing file means there are no tokens")
+
+	createdAt := time.Date(2026, 1, 2, 3, 4, 5, 0, time.UTC)
+	require.NoError(t, store.Save(ctx, "admin@vms:443", StoredToken{Access: "a1", Refresh: "r1", CreatedAt: createdAt}))
+	require.NoError(t, store.Save(ctx, "svc@vms:443", StoredToken{Access: "a2", Refresh: "r2", CreatedAt: createdAt}))
+
+	info, err := os.Stat(path)
+	require.NoError(t, err)
+	assert.Equal(t, os.FileMode(0o600), info.Mode().Perm())
+	token, err = store.Load(ctx, "admin@vms:443")
+	require.NoError(t, err)
+	assert.Equal(t, &StoredToken{Access: "a1", Refresh: "r1", CreatedAt: createdAt}, token)
+	token, err = NewFileTokenStore(path).Load(ctx, "svc@vms:443")
+	require.NoError(t, err)
+	assert.Equal(t, "a2", token.Access)
+
+	require.NoError(t, os.WriteFile(path, []byte("{corrupted"), 0o600))
+	_, err = store.Load(ctx, "admin@vms:443")
+	assert.ErrorContains(t, err, "failed to parse token store")
+	require.NoError(t, store.Save(ctx, "admin@vms:443", StoredToken{Access: "a3", Refresh: "r3"}), "corrupted file is overwritten")
+	token, err = store.Load(ctx, "admin@vms:443")
+	require.NoError(t, err)
+	assert.Equal(t, "a3", token.Access)
+}
+
+func TestTokenStoreAcrossRestarts(t *testing.T) {
+	tests := []struct {
+		name          string
+		config        func(config *VMSConfig)
+		seedStore     map[string]StoredToken // Keyed by username
+		wantLogins    []string
+		wantRefreshes int
+		wantKeys      []string // Usernames of stored tokens
+	}{
+		{
+			name:          "username",
+			config:        func(config *VMSConfig) { config.Username, config.Password = "admin", "123456" },
+			wantLogins:    []string{"admin"},
+			wantRefreshes: 1,
+			wantKeys:      []string{"admin"},
+		},
+		{
+			name: "credentials provider",
+			config: func(config *VMSConfig) {
+				config.Credentials = StaticCredentials{Username: "svc", Password: "123456"}
+			},
+			wantLogins:    []string{"svc"},
+			wantRefreshes: 1,
+			wantKeys:      []string{"svc"},
+		},
+		{
+			name:          "stale stored token",
+			config:        func(config *VMSConfig) { config.Username, config.Password = "admin", "123456" },
+			seedStore:     map[string]StoredToken{"admin": {Access: "stale", Refresh: "revoked"}},
+			wantLogins:    []string{"admin"},
+			wantRefreshes: 1,
+			wantKeys:      []string{"admin"},
+		},
+		{
+			name: "token cache is disabled",
+			config: func(config *VMSConfig) {
+				config.Username, config.Password = "admin", "123456"
+				config.DisableTokenCache = true
+			},
+			wantLogins: []string{"admin", "admin"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := context.Background()
+			srv := newTokenServer(t)
+			store := NewFileTokenStore(filepath.Join(t.TempDir(), "tokens.json"))
+			for username, token := range tt.seedStore {
+				require.NoError(t, store.Save(ctx, username+"@"+srv.hostPort(), token))
+			}
+
+			// Two short-lived processes sharing token store
+			for range 2 {
+				config := &VMSConfig{BaseURL: srv.URL, TokenStore: store}
+				tt.config(config)
+				rest, err := newVMSRest(config)
+				require.NoError(t, err)
+				_, err = rest.Views.List(ctx, nil)
+				require.NoError(t, err)
+				require.NoError(t, rest.Close())
+			}
+
+			logins, refreshes := srv.counts()
+			assert.Equal(t, tt.wantLogins, logins)
+			assert.Equal(t, tt.wantRefreshes, refreshes)
+			stored, err := store.read()
+			require.NoError(t, err)
+			for _, username := range tt.wantKeys {
+				assert.Contains(t, stored, username+"@"+srv.hostPort())
+			}
+			assert.Len(t, stored, len(tt.wantKeys))
+			assert.NotContains(t, stored, "@"+srv.hostPort(), "token is never stored without username")
+		})
+	}
+}
+
+func TestTokenStoreIsSkippedForUnknownUser(t *testing.T) {
+	ctx := context.Background()
+	srv := newTokenServer(t)
+	srv.issued["seeded-refresh"] = ""
+	path := filepath.Join(t.TempDir(), "tokens.json")
+	config := &VMSConfig{
+		BaseURL:             srv.URL,
+		TokenStore:          NewFileTokenStore(path),
+		InitialAccessToken:  "seeded",
+		InitialRefreshToken: "seeded-refresh",
+	}
+	rest := newTestRest(t, config)
+
+	// Pre-seeded token is refreshed on first use but its owner is unknown
+	session := rest.Session.(*VMSSession)
+	session.Lock()
+	session.auth.(*JWTAuthenticator).Token.CreatedAt = time.Now().Add(-TokenRefreshTime)
+	session.Unlock()
+	_, err := rest.Views.List(ctx, nil)
+	require.NoError(t, err)
+
+	_, refreshes := srv.counts()
+	assert.Equal(t, 1, refreshes)
+	assert.NoFileExists(t, path)
+}
@@ -0,0 +1,71 @@
+package vast_client
+
+import (
+	"context"
+	"net/url"
+)
+
+// Tracer starts spans around requests (see VMSConfig.Tracer). It is a small subset of OpenTelemetry API
+// so client has no dependency on tracing library. OpenTelemetry adapter example:
+//
+//	type otelTracer struct{ tracer trace.Tracer }
+//
+//	func (t otelTracer) Start(ctx context.Context, name string) (context.Context, client.Span) {
+//		ctx, span := t.tracer.Start(ctx, name, trace.WithSpanKind(trace.SpanKindClient))
+//		return ctx, otelSpan{span}
+//	}
+//
+//	type otelSpan struct{ span trace.Span }
+//
+//	func (s otelSpan) SetAttribute(key string, value any) {
+//		s.span.SetAttributes(attribute.String(key, fmt.Sprint(value)))
+//	}
+//	func (s otelSpan) RecordError(err error) {
+//		s.span.RecordError(err)
+//		s.span.SetStatus(codes.Error, err.Error())
+//	}
+//	func (s otelSpan) End() { s.span.End() }
+type Tracer interface {
+	// Start starts span as child of span stored in ctx (if any) and returns context carrying new span.
+	Start(ctx context.Context, name string) (context.Context, Span)
+}
+
+// Span is a single traced operation.
+type Span interface {
+	SetAttribute(key string, value any)
+	RecordError(err error)
+	End()
+}
+
+// Span attribute keys
+const (
+	SpanAttrResource   = "vast.resource"
+	SpanAttrVerb       = "http.request.method"
+	SpanAttrUrlPath    = "url.path"
+	SpanAttrStatusCode = "http.response.status_code"
+	SpanAttrRetryCount = "http.request.resend_count"
+)
+
+type nopSpan struct{}
+
+func (nopSpan) SetAttribute(string, any) {}
+func (nopSpan) RecordError(error)        {}
+func (nopSpan) End()                     {}
+
+// startSpan starts span named "<resource> <verb>" if VMSConfig.Tracer is set. Otherwise no-op span is returned.
+func startSpan(ctx context.Context, config *VMSConfig, resourceType, verb string) (context.Context, Span) {
+	if config.Tracer == nil {
+		return ctx, nopSpan{}
+	}
+	ctx, span := config.Tracer.Start(ctx, resourceType+" "+verb)
+	span.SetAttribute(SpanAttrResource, resourceType)
+	span.SetAttribute(SpanAttrVerb, verb)
+	return ctx, span
+}
+
+// setSpanUrl records path of request url on span (query is omitted as it may contain user data).
+func setSpanUrl(span Span, rawUrl string) {
+	if parsed, err := url.Parse(rawUrl); err == nil {
+		span.SetAttribute(SpanAttrUrlPath, parsed.Path)
+	}
+}
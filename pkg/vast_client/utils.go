@@ -2,28 +2,63 @@ package vast_client
 
 import (
 	"bytes"
+	"context"
+	"crypto/rand"
 	"encoding/json"
 	"errors"
 	"fmt"
 	"io"
+	"net"
 	"net/http"
 	"net/url"
+	"sort"
 	"strconv"
 	"strings"
+	"time"
 )
 
 const ApplicationJson = "application/json"
 
 // convertMapToQuery converts a map[string]any to a URL query string.
-// Values are stringified using fmt.Sprint.
+// Values are stringified using fmt.Sprint. []string values are sent as repeated params (k=a&k=b).
 func convertMapToQuery(params Params) string {
 	values := url.Values{}
 	for k, v := range params {
+		if list, ok := v.([]string); ok {
+			values[k] = list
+			continue
+		}
 		values.Set(k, fmt.Sprint(v))
 	}
 	return values.Encode()
 }
 
+// hostPort joins host and port from config. IPv6 addresses are enclosed in brackets.
+func hostPort(config *VMSConfig) string {
+	return net.JoinHostPort(config.Host, strconv.FormatUint(config.Port, 10))
+}
+
+// endpointUrl returns url of VMS endpoint. Scheme and path prefix are taken from VMSConfig.BaseURL if set.
+func endpointUrl(config *VMSConfig, elem ...string) (*url.URL, error) {
+	scheme, prefix := "https", ""
+	if config.BaseURL != "" {
+		base, err := url.Parse(config.BaseURL)
+		if err != nil {
+			return nil, err
+		}
+		scheme, prefix = base.Scheme, base.Path
+	}
+	path, err := url.JoinPath(strings.Trim(prefix, "/"), elem...)
+	if err != nil {
+		return nil, err
+	}
+	return &url.URL{
+		Scheme: scheme,
+		Host:   hostPort(config),
+		Path:   path,
+	}, nil
+}
+
 // getResponseBodyAsStr reads and returns the HTTP response body as a string.
 // If the response body contains valid JSON, it returns a pretty-printed version.
 // If the JSON indentation fails or the body is not JSON, it returns the raw body as a string.
@@ -47,6 +82,38 @@ func getResponseBodyAsStr(r *http.Response) string {
 	return string(body)
 }
 
+// normalizePath removes trailing slashes so "/a/b/" and "/a/b" are treated as the same path.
+func normalizePath(path string) string {
+	if trimmed := strings.TrimRight(path, "/"); trimmed != "" {
+		return trimmed
+	}
+	return path
+}
+
+// normalizeStringSet returns trimmed, deduplicated and sorted copy of values. Empty values are dropped.
+func normalizeStringSet(values []string) []string {
+	set := make(map[string]struct{}, len(values))
+	for _, value := range values {
+		if value = strings.TrimSpace(value); value != "" {
+			set[value] = struct{}{}
+		}
+	}
+	normalized := make([]string, 0, len(set))
+	for value := range set {
+		normalized = append(normalized, value)
+	}
+	sort.Strings(normalized)
+	return normalized
+}
+
+// percentOf returns value in percents of total (0 if total is not positive).
+func percentOf(value, total int64) float64 {
+	if total <= 0 {
+		return 0
+	}
+	return float64(value) * 100 / float64(total)
+}
+
 // sanitizeVersion truncates all segments of Cluster Version above core (x.y.z)
 func sanitizeVersion(version string) (string, bool) {
 	segments := strings.Split(version, ".")
@@ -69,6 +136,15 @@ func toInt(val any) (int64, error) {
 	return idInt, nil
 }
 
+// nestedId returns id of nested object stored under key (e.g. {"volume": {"id": 1}})
+// or falls back to "<key>_id" field (e.g. {"volume_id": 1}).
+func nestedId(r Record, key string) (int64, error) {
+	if nested, err := r.GetRecord(key); err == nil {
+		return nested.ID()
+	}
+	return r.GetInt64(key + "_id")
+}
+
 func toRecord(m map[string]interface{}) (Record, error) {
 	converted := Record{}
 	for k, v := range m {
@@ -119,6 +195,44 @@ func toIntIfString[T int | float64](val any) (T, error) {
 	}
 }
 
+// newRequestId generates random (version 4) UUID used as request identifier.
+func newRequestId() string {
+	var b [16]byte
+	if _, err := rand.Read(b[:]); err != nil {
+		return fmt.Sprintf("%x", time.Now().UnixNano())
+	}
+	b[6] = (b[6] & 0x0f) | 0x40
+	b[8] = (b[8] & 0x3f) | 0x80
+	return fmt.Sprintf("%x-%x-%x-%x-%x", b[0:4], b[4:6], b[6:8], b[8:10], b[10:])
+}
+
+// isContextErr reports whether err is caused by context cancellation or deadline.
+func isContextErr(err error) bool {
+	return errors.Is(err, context.DeadlineExceeded) || errors.Is(err, context.Canceled)
+}
+
+// ApiError is returned when VMS responds with status code outside of 2xx range.
+type ApiError struct {
+	StatusCode int
+	Method     string
+	URL        string
+	Body       string // Response body (pretty-printed if it is JSON)
+	RequestID  string // Request identifier (echoed by server or sent by client, see WithRequestID)
+}
+
+func (e *ApiError) Error() string {
+	if e.RequestID != "" {
+		return fmt.Sprintf("invalid status code %d (request id %s), err: %s", e.StatusCode, e.RequestID, e.Body)
+	}
+	return fmt.Sprintf("invalid status code %d, err: %s", e.StatusCode, e.Body)
+}
+
+// isApiErrWithStatus reports whether err is ApiError with given status code.
+func isApiErrWithStatus(err error, statusCode int) bool {
+	var apiErr *ApiError
+	return errors.As(err, &apiErr) && apiErr.StatusCode == statusCode
+}
+
 // validateResponse checks the response for valid HTTP status codes (specifically for 2xx codes).
 // It returns an error if the status code is not a valid 2xx code or if the response is nil.
 //
@@ -128,7 +242,7 @@ func toIntIfString[T int | float64](val any) (T, error) {
 //
 // Returns:
 // - response: the original HTTP response
-// - error: an error if validation fails
+// - error: ApiError if validation fails
 func validateResponse(response *http.Response) (*http.Response, error) {
 	// Check if the response status code is within the 2xx range (successful responses)
 	if response == nil {
@@ -138,6 +252,17 @@ func validateResponse(response *http.Response) (*http.Response, error) {
 		return response, nil
 	}
 	// If not, return an error indicating the invalid status code
-	errStr := getResponseBodyAsStr(response)
-	return response, fmt.Errorf("invalid status code %d, err: %s", response.StatusCode, errStr)
+	apiErr := &ApiError{
+		StatusCode: response.StatusCode,
+		Body:       getResponseBodyAsStr(response),
+	}
+	if response.Request != nil {
+		apiErr.Method = response.Request.Method
+		apiErr.URL = response.Request.URL.String()
+		apiErr.RequestID = response.Request.Header.Get(RequestIdHeader)
+	}
+	if echoed := response.Header.Get(RequestIdHeader); echoed != "" {
+		apiErr.RequestID = echoed
+	}
+	return response, apiErr
 }
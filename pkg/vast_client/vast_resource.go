@@ -1,11 +1,21 @@
 package vast_client
 
 import (
+	"bytes"
 	"context"
+	"encoding/pem"
+	"errors"
 	"fmt"
 	version "github.com/hashicorp/go-version"
+	"maps"
+	"math"
+	"net"
 	"net/http"
+	"slices"
+	"sort"
+	"strconv"
 	"strings"
+	"sync"
 	"time"
 )
 
@@ -40,9 +50,25 @@ type VastResourceType interface {
 	GlobalSnapshotStream |
 	ReplicationPeers |
 	ProtectionPolicy |
-	S3replicationPeers |
+	S3ReplicationPeers |
 	Realm |
-	Role
+	Role |
+	Alarm |
+	Event |
+	EventDefinition |
+	Cluster |
+	Dnode |
+	Nic |
+	Subnet |
+	Manager |
+	ApiToken |
+	Certificate |
+	Folder |
+	Monitor |
+	Bucket |
+	NonLocalUser |
+	NonLocalGroup |
+	Vms
 }
 
 // ------------------------------------------------------
@@ -51,24 +77,41 @@ type Version struct {
 	*VastResourceEntry
 }
 
-var sysVersion *version.Version
-
+// GetVersion returns core version (x.y.z) of VAST cluster.
+// Version is requested once and then cached per VMSRest instance.
 func (v *Version) GetVersion(ctx context.Context) (*version.Version, error) {
-	if sysVersion != nil {
-		return sysVersion, nil
+	v.rest.versionMu.Lock()
+	defer v.rest.versionMu.Unlock()
+	if v.rest.clusterVersion != nil {
+		return v.rest.clusterVersion, nil
 	}
 	result, err := v.List(ctx, Params{"status": "success"})
 	if err != nil {
 		return nil, err
 	}
-	truncatedVersion, _ := sanitizeVersion(result[0]["sys_version"].(string))
+	if len(result) == 0 {
+		return nil, fmt.Errorf("no successful cluster version found")
+	}
+	sysVersion, err := result[0].GetString("sys_version")
+	if err != nil {
+		return nil, err
+	}
+	truncatedVersion, _ := sanitizeVersion(sysVersion)
 	clusterVersion, err := version.NewVersion(truncatedVersion)
 	if err != nil {
 		return nil, err
 	}
 	//We only work with core version
-	sysVersion = clusterVersion.Core()
-	return sysVersion, nil
+	v.rest.clusterVersion = clusterVersion.Core()
+	return v.rest.clusterVersion, nil
+}
+
+// InvalidateCache drops cached cluster version so next GetVersion call requests it from cluster again.
+// Useful for long-running processes which need to pick up cluster upgrade.
+func (v *Version) InvalidateCache() {
+	v.rest.versionMu.Lock()
+	defer v.rest.versionMu.Unlock()
+	v.rest.clusterVersion = nil
 }
 
 func (v *Version) CompareWith(ctx context.Context, other *version.Version) (int, error) {
@@ -85,33 +128,210 @@ type Quota struct {
 	*VastResourceEntry
 }
 
+// QuotaUsage describes quota limits and current usage (see Quota.Usage).
+type QuotaUsage struct {
+	HardLimit        int64   `json:"hard_limit"`
+	SoftLimit        int64   `json:"soft_limit"`
+	HardLimitInodes  int64   `json:"hard_limit_inodes"`
+	UsedCapacity     int64   `json:"used_capacity"`
+	UsedInodes       int64   `json:"used_inodes"`
+	UsedCapacityPct  float64 `json:"-"` // Used capacity in percents of hard limit (0 if there is no hard limit)
+	UsedSoftLimitPct float64 `json:"-"` // Used capacity in percents of soft limit (0 if there is no soft limit)
+	UsedInodesPct    float64 `json:"-"` // Used inodes in percents of inodes hard limit (0 if there is no limit)
+}
+
+// GetByPath returns quota of given path in tenant.
+func (q *Quota) GetByPath(ctx context.Context, path string, tenantId int) (Record, error) {
+	return q.Get(ctx, Params{"path": path, "tenant_id": tenantId})
+}
+
+// EnsureQuota returns quota of given path in tenant or creates it if missing.
+// If existing quota has different hard/soft limits they are updated.
+func (q *Quota) EnsureQuota(ctx context.Context, name, path string, tenantId int, hard, soft int64) (Record, error) {
+	ctx = withFeature(ctx, "ensure")
+	limits := Params{"hard_limit": hard, "soft_limit": soft}
+	quota, err := q.GetByPath(ctx, path, tenantId)
+	if isNotFoundErr(err) {
+		limits.Update(Params{"name": name, "path": path, "tenant_id": tenantId}, false)
+		return q.Create(ctx, limits)
+	} else if err != nil {
+		return nil, err
+	}
+	if valuesEqual(quota["hard_limit"], hard, false) && valuesEqual(quota["soft_limit"], soft, false) {
+		return quota, nil
+	}
+	id, err := quota.ID()
+	if err != nil {
+		return nil, err
+	}
+	return q.Update(ctx, id, limits)
+}
+
+// Usage extracts limits and usage from quota Record and calculates usage percentages.
+func (q *Quota) Usage(quota Record) (QuotaUsage, error) {
+	var usage QuotaUsage
+	if err := quota.Fill(&usage); err != nil {
+		return usage, err
+	}
+	usage.UsedCapacityPct = percentOf(usage.UsedCapacity, usage.HardLimit)
+	usage.UsedSoftLimitPct = percentOf(usage.UsedCapacity, usage.SoftLimit)
+	usage.UsedInodesPct = percentOf(usage.UsedInodes, usage.HardLimitInodes)
+	return usage, nil
+}
+
 // ------------------------------------------------------
 
 type View struct {
 	*VastResourceEntry
 }
 
+// EnsureByPath returns view of given path in tenant or creates it (along with directory) if missing.
+// For existing view fields from desired params are compared with actual ones (protocols are compared order-insensitively)
+// and only drifted fields are updated. Returned flag reports whether anything was changed.
+func (v *View) EnsureByPath(ctx context.Context, path string, tenantId int, desired Params) (Record, bool, error) {
+	ctx = withFeature(ctx, "ensure")
+	view, err := v.Get(ctx, Params{"path": path, "tenant_id": tenantId})
+	if isNotFoundErr(err) {
+		body := Params{"create_dir": true}
+		body.Update(desired, false)
+		body.Update(Params{"path": path, "tenant_id": tenantId}, false)
+		view, err = v.Create(ctx, body)
+		return view, err == nil, err
+	} else if err != nil {
+		return nil, false, err
+	}
+	changed, equal := view.Diff(desired)
+	if equal {
+		return view, false, nil
+	}
+	id, err := view.ID()
+	if err != nil {
+		return nil, false, err
+	}
+	view, err = v.Update(ctx, id, changed)
+	return view, err == nil, err
+}
+
 // ------------------------------------------------------
 
 type VipPool struct {
 	*VastResourceEntry
 }
 
+// EnsureVipPool returns VIP pool with given name or creates it if not exists.
+// Ranges are pairs of start/end addresses. They are validated (reversed ranges are rejected) and compared
+// with existing ranges regardless of order and address formatting. Existing pool is updated only with
+// fields which differ (ip_ranges and extra fields such as "subnet_cidr" or "role").
+func (v *VipPool) EnsureVipPool(ctx context.Context, name string, ranges [][2]string, extra Params) (Record, error) {
+	ctx = withFeature(ctx, "ensure")
+	normalized, err := normalizeIpRanges(ranges)
+	if err != nil {
+		return nil, &ValidationError{Resource: v.resourcePath, Fields: []string{"ip_ranges"}, Problems: []string{err.Error()}}
+	}
+	existing, err := v.Get(ctx, Params{"name": name})
+	if isNotFoundErr(err) {
+		body := Params{"name": name, "ip_ranges": normalized}
+		body.Update(extra, false)
+		return v.Create(ctx, body)
+	} else if err != nil {
+		return nil, err
+	}
+	changed, _ := existing.Diff(extra)
+	actual, err := ipRangesOf(existing, "ip_ranges")
+	if err != nil || !ipRangesEqual(actual, normalized) {
+		changed["ip_ranges"] = normalized
+	}
+	if len(changed) == 0 {
+		return existing, nil
+	}
+	id, err := existing.ID()
+	if err != nil {
+		return nil, err
+	}
+	return v.Update(ctx, id, changed)
+}
+
+// IPs returns all addresses of VIP pool ranges. nameOrId is either name (string) or id (integer) of VIP pool.
+// Returns error if pool has more than 65536 addresses.
+func (v *VipPool) IPs(ctx context.Context, nameOrId any) ([]net.IP, error) {
+	var (
+		pool Record
+		err  error
+	)
+	switch ident := nameOrId.(type) {
+	case string:
+		pool, err = v.Get(ctx, Params{"name": ident})
+	case int, int64, float64:
+		id, _ := toInt(ident)
+		pool, err = v.GetById(ctx, id)
+	default:
+		return nil, fmt.Errorf("VIP pool must be identified by name or id, got %T", nameOrId)
+	}
+	if err != nil {
+		return nil, err
+	}
+	ranges, err := ipRangesOf(pool, "ip_ranges")
+	if err != nil {
+		return nil, err
+	}
+	return expandIpRanges(ranges)
+}
+
 // ------------------------------------------------------
 
 type User struct {
 	*VastResourceEntry
 }
 
+// GetByUid looks up user by uid in tenant. Identity information from external providers (AD, LDAP, NIS)
+// is included. Returns NotFoundError if there is no such user.
+func (u *User) GetByUid(ctx context.Context, uid int, tenantId int) (Record, error) {
+	return u.queryRequest(ctx, "query", Params{"uid": uid, "tenant_id": tenantId})
+}
+
+// GetBySid looks up user by Windows security identifier (SID). Returns NotFoundError if there is no such user.
+func (u *User) GetBySid(ctx context.Context, sid string) (Record, error) {
+	return u.queryRequest(ctx, "query", Params{"sid": sid})
+}
+
 // ------------------------------------------------------
 
 type UserKey struct {
 	*VastResourceEntry
 }
 
-func (uk *UserKey) CreateKey(ctx context.Context, userId int64) (Record, error) {
+// CreateKey creates S3 access key pair for user. Optional params are sent in request body (e.g. Params{"pgp_public_key": key}).
+func (uk *UserKey) CreateKey(ctx context.Context, userId int64, params ...Params) (Record, error) {
+	path := fmt.Sprintf(uk.resourcePath, userId)
+	var body Params
+	for _, p := range params {
+		if body == nil {
+			body = Params{}
+		}
+		body.Update(p, false)
+	}
+	return request[Record](ctx, uk, http.MethodPost, path, uk.apiVersion, nil, body)
+}
+
+// ListKeys returns access keys of user.
+func (uk *UserKey) ListKeys(ctx context.Context, userId int64) (RecordSet, error) {
+	path := fmt.Sprintf(uk.resourcePath, userId)
+	return request[RecordSet](ctx, uk, http.MethodGet, path, uk.apiVersion, nil, nil)
+}
+
+// EnableKey enables access key of user.
+func (uk *UserKey) EnableKey(ctx context.Context, userId int64, accessKey string) (EmptyRecord, error) {
+	return uk.setKeyEnabled(ctx, userId, accessKey, true)
+}
+
+// DisableKey disables access key of user without deleting it.
+func (uk *UserKey) DisableKey(ctx context.Context, userId int64, accessKey string) (EmptyRecord, error) {
+	return uk.setKeyEnabled(ctx, userId, accessKey, false)
+}
+
+func (uk *UserKey) setKeyEnabled(ctx context.Context, userId int64, accessKey string, enabled bool) (EmptyRecord, error) {
 	path := fmt.Sprintf(uk.resourcePath, userId)
-	return request[Record](ctx, uk, http.MethodPost, path, uk.apiVersion, nil, nil)
+	return request[EmptyRecord](ctx, uk, http.MethodPatch, path, uk.apiVersion, nil, Params{"access_key": accessKey, "enabled": enabled})
 }
 
 func (uk *UserKey) DeleteKey(ctx context.Context, userId int64, accessKey string) (EmptyRecord, error) {
@@ -125,263 +345,1943 @@ type Cnode struct {
 	*VastResourceEntry
 }
 
+// ListByState returns all cnodes in given state (e.g. "ACTIVE", "INACTIVE", "FAILED").
+func (cn *Cnode) ListByState(ctx context.Context, state string) (RecordSet, error) {
+	return cn.List(ctx, Params{"state": state})
+}
+
 // ------------------------------------------------------
 
-type QosPolicy struct {
+type Dnode struct {
 	*VastResourceEntry
 }
 
+// ListByState returns all dnodes in given state (e.g. "ACTIVE", "INACTIVE", "FAILED").
+func (dn *Dnode) ListByState(ctx context.Context, state string) (RecordSet, error) {
+	return dn.List(ctx, Params{"state": state})
+}
+
 // ------------------------------------------------------
 
-type Dns struct {
+type Nic struct {
 	*VastResourceEntry
 }
 
 // ------------------------------------------------------
 
-type ViewPolicy struct {
+type Subnet struct {
 	*VastResourceEntry
 }
 
 // ------------------------------------------------------
 
-type Group struct {
+type Manager struct {
 	*VastResourceEntry
 }
 
+// ResetPassword sets new password of VMS manager (administrator).
+func (m *Manager) ResetPassword(ctx context.Context, id int64, newPassword string) (Record, error) {
+	if newPassword == "" {
+		return nil, errors.New("new password must not be empty")
+	}
+	return m.Update(ctx, id, Params{"password": newPassword})
+}
+
 // ------------------------------------------------------
 
-type Nis struct {
+type ApiToken struct {
 	*VastResourceEntry
 }
 
-// ------------------------------------------------------
+// CreateToken creates api token and returns its secret along with created record.
+// Secret is shown by VMS only once so it must be stored by caller. Record keeps secret under "token" key
+// (it is redacted by Render, see SetSensitiveKeys).
+func (at *ApiToken) CreateToken(ctx context.Context, body Params) (string, Record, error) {
+	record, err := at.Create(ctx, body)
+	if err != nil {
+		return "", nil, err
+	}
+	token, err := record.GetString("token")
+	if err != nil {
+		return "", record, fmt.Errorf("api token was created but response has no secret: %w", err)
+	}
+	return token, record, nil
+}
 
-type Tenant struct {
-	*VastResourceEntry
+// Revoke revokes api token so it cannot be used for authentication anymore.
+func (at *ApiToken) Revoke(ctx context.Context, id int64) (Record, error) {
+	return at.actionRequest(ctx, http.MethodPatch, id, "revoke", nil, nil, FeatureApiTokens)
 }
 
 // ------------------------------------------------------
 
-type Ldap struct {
+type Certificate struct {
 	*VastResourceEntry
 }
 
-// ------------------------------------------------------
+// CertificatePEM holds PEM encoded certificate (optionally with intermediate chain), private key and CA bundle.
+type CertificatePEM struct {
+	Certificate string
+	PrivateKey  string
+	CA          string // Optional
+}
 
-type S3LifeCycleRule struct {
-	*VastResourceEntry
+// validate rejects obviously malformed PEM content before it is sent to VMS.
+func (c CertificatePEM) validate() error {
+	if err := validatePEM("certificate", c.Certificate, func(blockType string) bool { return blockType == "CERTIFICATE" }); err != nil {
+		return err
+	}
+	if err := validatePEM("private key", c.PrivateKey, func(blockType string) bool { return strings.HasSuffix(blockType, "PRIVATE KEY") }); err != nil {
+		return err
+	}
+	if c.CA != "" {
+		return validatePEM("CA certificate", c.CA, func(blockType string) bool { return blockType == "CERTIFICATE" })
+	}
+	return nil
 }
 
-// ------------------------------------------------------
+// validatePEM checks that content consists only of PEM blocks of expected type.
+func validatePEM(what, content string, expectedType func(string) bool) error {
+	rest := []byte(strings.TrimSpace(content))
+	if len(rest) == 0 {
+		return fmt.Errorf("%s must not be empty", what)
+	}
+	for len(rest) > 0 {
+		var block *pem.Block
+		block, rest = pem.Decode(rest)
+		if block == nil {
+			return fmt.Errorf("%s is not valid PEM", what)
+		}
+		if !expectedType(block.Type) {
+			return fmt.Errorf("%s has unexpected PEM block %q", what, block.Type)
+		}
+		rest = bytes.TrimSpace(rest)
+	}
+	return nil
+}
 
-type ActiveDirectory struct {
-	*VastResourceEntry
+// CreateFromPEM validates PEM content and creates certificate with given name.
+func (c *Certificate) CreateFromPEM(ctx context.Context, name string, certs CertificatePEM) (Record, error) {
+	if err := certs.validate(); err != nil {
+		return nil, err
+	}
+	body := Params{"name": name, "certificate": certs.Certificate, "private_key": certs.PrivateKey}
+	if certs.CA != "" {
+		body["ca_certificate"] = certs.CA
+	}
+	return c.Create(ctx, body)
 }
 
-// ------------------------------------------------------
+// Upload validates PEM content and uploads it as files of multipart/form-data request
+// (for VMS versions which accept certificates only as file uploads).
+func (c *Certificate) Upload(ctx context.Context, name string, certs CertificatePEM) (Record, error) {
+	if err := certs.validate(); err != nil {
+		return nil, err
+	}
+	if err := checkVastResourceVersionCompat(ctx, c.VastResourceEntry); err != nil {
+		return nil, err
+	}
+	body := Params{
+		"name":        name,
+		"certificate": FormFile{Filename: "certificate.pem", Content: []byte(certs.Certificate)},
+		"private_key": FormFile{Filename: "private_key.pem", Content: []byte(certs.PrivateKey)},
+	}
+	if certs.CA != "" {
+		body["ca_certificate"] = FormFile{Filename: "ca.pem", Content: []byte(certs.CA)}
+	}
+	return request[Record](asMultipart(ctx), c, http.MethodPost, c.resourcePath, c.apiVersion, nil, body)
+}
 
-type S3Policy struct {
-	*VastResourceEntry
+// Activate makes certificate the one used by VMS.
+func (c *Certificate) Activate(ctx context.Context, id int64) (Record, error) {
+	return c.actionRequest(ctx, http.MethodPatch, id, "activate", nil, nil)
 }
 
 // ------------------------------------------------------
 
-type ProtectedPath struct {
+type Folder struct {
 	*VastResourceEntry
 }
 
-// ------------------------------------------------------
+// CreateFolder creates directory at path. Ownership can be set with owner params (e.g. Params{"user": "nobody", "group": "users"}).
+func (f *Folder) CreateFolder(ctx context.Context, path string, owner Params) (Record, error) {
+	if err := checkVastResourceVersionCompat(ctx, f.VastResourceEntry); err != nil {
+		return nil, err
+	}
+	body := Params{}
+	body.Update(owner, false)
+	body["path"] = path
+	return request[Record](ctx, f, http.MethodPost, f.resourcePath+"/create_folder", f.apiVersion, nil, body)
+}
 
-type GlobalSnapshotStream struct {
-	*VastResourceEntry
+// DeleteWithData deletes directory at path along with all its content.
+// VMS deletes data asynchronously so returned VTask is waited (see WaitTask).
+func (f *Folder) DeleteWithData(ctx context.Context, path string, opts ...WaitTaskOptions) (Record, error) {
+	if err := checkVastResourceVersionCompat(ctx, f.VastResourceEntry); err != nil {
+		return nil, err
+	}
+	task, _, err := f.requestAndWaitTask(ctx, http.MethodDelete, f.resourcePath+"/delete_folder", Params{"path": path}, false, opts)
+	return task, err
+}
+
+// Stats returns capacity statistics of directory at path.
+func (f *Folder) Stats(ctx context.Context, path string) (Record, error) {
+	if err := checkVastResourceVersionCompat(ctx, f.VastResourceEntry); err != nil {
+		return nil, err
+	}
+	return request[Record](ctx, f, http.MethodGet, f.resourcePath+"/stats", f.apiVersion, Params{"path": path}, nil)
 }
 
 // ------------------------------------------------------
 
-type ReplicationPeers struct {
+type Bucket struct {
 	*VastResourceEntry
 }
 
+// ListByOwner returns all buckets owned by given user.
+// Returns UnsupportedVersionError if cluster doesn't support FeatureViewBucketOwner.
+func (b *Bucket) ListByOwner(ctx context.Context, owner string) (RecordSet, error) {
+	if err := b.rest.requireFeature(ctx, FeatureViewBucketOwner); err != nil {
+		return nil, err
+	}
+	return b.List(ctx, Params{"bucket_owner": owner})
+}
+
 // ------------------------------------------------------
 
-type ProtectionPolicy struct {
+// NonLocalUser provides lookup and S3 key management of users from external providers (AD, LDAP, NIS).
+// Such users are not listed by Users resource and are looked up by query-style requests.
+type NonLocalUser struct {
 	*VastResourceEntry
 }
 
-// ------------------------------------------------------
+// Query looks up single non-local user by criteria (e.g. Params{"username": "jdoe", "tenant_id": 1}).
+// Returns NotFoundError if there is no such user.
+func (nu *NonLocalUser) Query(ctx context.Context, query Params) (Record, error) {
+	return nu.queryRequest(ctx, "query", query)
+}
 
-type S3replicationPeers struct {
-	*VastResourceEntry
+// GetByUsername looks up non-local user by username in tenant.
+func (nu *NonLocalUser) GetByUsername(ctx context.Context, username string, tenantId int) (Record, error) {
+	return nu.Query(ctx, Params{"username": username, "tenant_id": tenantId})
 }
 
-// ------------------------------------------------------
+// GetByUid looks up non-local user by uid in tenant.
+func (nu *NonLocalUser) GetByUid(ctx context.Context, uid int, tenantId int) (Record, error) {
+	return nu.Query(ctx, Params{"uid": uid, "tenant_id": tenantId})
+}
 
-type Realm struct {
-	*VastResourceEntry
+// CreateNonLocalUserKey creates S3 access key pair for non-local user.
+// Secret key is returned only once.
+func (nu *NonLocalUser) CreateNonLocalUserKey(ctx context.Context, username string, tenantId int) (Record, error) {
+	path := nu.resourcePath + "/non_local_keys"
+	return request[Record](ctx, nu, http.MethodPost, path, nu.apiVersion, nil, Params{"username": username, "tenant_id": tenantId})
+}
+
+// DeleteNonLocalUserKey deletes S3 access key of non-local user.
+func (nu *NonLocalUser) DeleteNonLocalUserKey(ctx context.Context, username string, tenantId int, accessKey string) (EmptyRecord, error) {
+	path := nu.resourcePath + "/non_local_keys"
+	body := Params{"username": username, "tenant_id": tenantId, "access_key": accessKey}
+	return request[EmptyRecord](ctx, nu, http.MethodDelete, path, nu.apiVersion, nil, body)
 }
 
 // ------------------------------------------------------
 
-type Role struct {
+// NonLocalGroup provides lookup of groups from external providers (AD, LDAP, NIS) (see NonLocalUser).
+type NonLocalGroup struct {
 	*VastResourceEntry
 }
 
-// ------------------------------------------------------
+// Query looks up single non-local group by criteria (e.g. Params{"groupname": "devs", "tenant_id": 1}).
+// Returns NotFoundError if there is no such group.
+func (ng *NonLocalGroup) Query(ctx context.Context, query Params) (Record, error) {
+	return ng.queryRequest(ctx, "query", query)
+}
 
-type Snapshot struct {
-	*VastResourceEntry
+// GetByGroupname looks up non-local group by name in tenant.
+func (ng *NonLocalGroup) GetByGroupname(ctx context.Context, groupname string, tenantId int) (Record, error) {
+	return ng.Query(ctx, Params{"groupname": groupname, "tenant_id": tenantId})
 }
 
-func (s *Snapshot) afterRequest(response Renderable) (Renderable, error) {
-	// List of snapshots is returned under "results" key
-	return applyCallbackForRecordUnion[RecordSet](response, func(r Renderable) (Renderable, error) {
-		// This callback is only invoked if response is a RecordSet
-		if rawMap, ok := any(r).(map[string]interface{}); ok {
-			if inner, found := rawMap["results"]; found {
-				if list, ok := inner.([]map[string]any); ok {
-					return toRecordSet(list)
-				}
-			}
-		}
-		return r, nil
-	})
+// GetByGid looks up non-local group by gid in tenant.
+func (ng *NonLocalGroup) GetByGid(ctx context.Context, gid int, tenantId int) (Record, error) {
+	return ng.Query(ctx, Params{"gid": gid, "tenant_id": tenantId})
 }
 
 // ------------------------------------------------------
 
-type BlockHost struct {
+// Vms represents VMS-wide settings. It is singleton resource (usually addressed as vms/1).
+type Vms struct {
 	*VastResourceEntry
 }
 
-func (bh *BlockHost) EnsureBlockHost(ctx context.Context, name string, tenantId int, nqn string) (Record, error) {
-	params := Params{"name": name, "tenant_id": tenantId}
-	blockHost, err := bh.Get(ctx, params)
-	if isNotFoundErr(err) {
-		params.Update(Params{"nqn": nqn, "os_type": "LINUX", "connectivity_type": "tcp"}, false)
-		return bh.Create(ctx, params)
-	} else if err != nil {
+// vmsSettingsFeatures maps VMS settings which are not available in all 5.x releases to corresponding feature (see Features).
+var vmsSettingsFeatures = map[string]string{
+	"login_banner":        FeatureVmsLoginBanner,
+	"cli_session_timeout": FeatureVmsSessionTimeout,
+	"max_api_tokens":      FeatureApiTokens,
+}
+
+// GetSettings returns VMS settings.
+func (v *Vms) GetSettings(ctx context.Context) (Record, error) {
+	return v.GetSingleton(ctx)
+}
+
+// UpdateSettings updates VMS settings with params. Id of VMS object is resolved automatically.
+// Returns error without sending request if some of params is not supported by cluster version.
+func (v *Vms) UpdateSettings(ctx context.Context, params Params) (Record, error) {
+	if err := v.checkSettingsCompat(ctx, params); err != nil {
 		return nil, err
 	}
-	return blockHost, nil
+	settings, err := v.GetSingleton(ctx)
+	if err != nil {
+		return nil, err
+	}
+	id, err := settings.ID()
+	if err != nil {
+		return nil, err
+	}
+	return v.Update(ctx, id, params)
 }
 
-// ------------------------------------------------------
+// SetLoginBanner sets banner shown on VMS login page. Empty banner removes it.
+func (v *Vms) SetLoginBanner(ctx context.Context, banner string) (Record, error) {
+	return v.UpdateSettings(ctx, Params{"login_banner": banner})
+}
 
-type Volume struct {
-	*VastResourceEntry
+// SetSessionTimeout sets idle timeout of VMS sessions. Timeout is rounded to seconds.
+func (v *Vms) SetSessionTimeout(ctx context.Context, timeout time.Duration) (Record, error) {
+	if timeout < time.Second {
+		return nil, fmt.Errorf("session timeout must be at least 1s, got %s", timeout)
+	}
+	return v.UpdateSettings(ctx, Params{"cli_session_timeout": int64(timeout.Round(time.Second) / time.Second)})
+}
+
+// SetMaxApiTokens sets maximal number of api tokens per manager.
+func (v *Vms) SetMaxApiTokens(ctx context.Context, limit int) (Record, error) {
+	if limit <= 0 {
+		return nil, fmt.Errorf("max api tokens must be positive, got %d", limit)
+	}
+	return v.UpdateSettings(ctx, Params{"max_api_tokens": limit})
+}
+
+// checkSettingsCompat verifies that all version-dependent params are supported by cluster version.
+func (v *Vms) checkSettingsCompat(ctx context.Context, params Params) error {
+	for _, field := range sortedKeys(params) {
+		feature, ok := vmsSettingsFeatures[field]
+		if !ok {
+			continue
+		}
+		if err := v.rest.requireVersion(ctx, fmt.Sprintf("vms setting %q", field), knownFeatures[feature]); err != nil {
+			return err
+		}
+	}
+	return nil
 }
 
 // ------------------------------------------------------
 
-type VTask struct {
+type Monitor struct {
 	*VastResourceEntry
 }
 
-// WaitTask waits for the task to complete
-func (t *VTask) WaitTask(ctx context.Context, taskId int64) (Record, error) {
-	// isTaskComplete checks if the task is complete
-	isTaskComplete := func(taskId int64) (Record, error) {
-		task, err := t.GetById(ctx, taskId)
-		if err != nil {
-			return nil, err
-		}
-		// Check the task state
-		taskName := fmt.Sprintf("%v", task["name"])
-		taskState := strings.ToLower(fmt.Sprintf("%v", task["state"]))
-		_taskId, err := toInt(task["id"])
-		if err != nil {
-			return nil, err
-		}
-		switch taskState {
-		case "completed":
-			return task, nil
-		case "running":
-			return nil, fmt.Errorf("task %s with ID %s is still running, timeout occurred", taskName, _taskId)
-		default:
-			rawMessages := task["messages"]
-			messages, ok := rawMessages.([]interface{})
-			if !ok {
-				return nil, fmt.Errorf("unexpected message format: %T", rawMessages)
-			}
-			if len(messages) == 0 {
-				return nil, fmt.Errorf("task %s failed with ID %s: no messages found", taskName, _taskId)
-			}
-			lastMsg := fmt.Sprintf("%v", messages[len(messages)-1])
-			return nil, fmt.Errorf("task %s failed with ID %s: %s", taskName, _taskId, lastMsg)
+// MetricsQuery describes ad-hoc query of time-series metrics (see Monitor.Query).
+type MetricsQuery struct {
+	ObjectType  string    // Type of measured objects (e.g. "cluster", "view", "cnode").
+	ObjectIDs   []int64   // Ids of measured objects. Empty means all objects of ObjectType.
+	PropList    []string  // Metric names (e.g. "ProtoMetrics,proto_name=ProtoCommon,iops").
+	TimeFrame   string    // Relative time frame (e.g. "5m", "1h", "2d"). Ignored if From is set.
+	From        time.Time // Optional absolute start of time range.
+	To          time.Time // Optional absolute end of time range (defaults to now).
+	Granularity string    // Optional granularity of samples ("seconds", "minutes", "hours", "days").
+}
+
+// params converts query to query params of ad-hoc query endpoint.
+func (q MetricsQuery) params() (Params, error) {
+	if q.ObjectType == "" {
+		return nil, errors.New("metrics query requires ObjectType")
+	}
+	if len(q.PropList) == 0 {
+		return nil, errors.New("metrics query requires at least one metric in PropList")
+	}
+	params := Params{"object_type": q.ObjectType, "prop_list": q.PropList}
+	if len(q.ObjectIDs) > 0 {
+		ids := make([]string, len(q.ObjectIDs))
+		for i, id := range q.ObjectIDs {
+			ids[i] = fmt.Sprintf("%d", id)
 		}
+		params["object_ids"] = strings.Join(ids, ",")
 	}
-	// Retry logic to poll the task status
-	retries := 30
-	interval := time.Millisecond * 500
-	backoffRate := 1
-
-	for retries > 0 {
-		task, err := isTaskComplete(taskId)
-		if err == nil {
-			return task, nil
+	if !q.From.IsZero() {
+		params["from_time"] = q.From.UTC().Format(time.RFC3339)
+		if !q.To.IsZero() {
+			params["to_time"] = q.To.UTC().Format(time.RFC3339)
 		}
-		time.Sleep(interval)
-		// Backoff logic
-		interval *= time.Duration(backoffRate)
-		retries--
+	} else if q.TimeFrame != "" {
+		params["time_frame"] = q.TimeFrame
 	}
-	return nil, fmt.Errorf("task did not complete in time")
+	if q.Granularity != "" {
+		params["granularity"] = q.Granularity
+	}
+	return params, nil
 }
 
-// ------------------------------------------------------
+// MetricsRow is a single sample of metrics of one object.
+type MetricsRow struct {
+	Timestamp time.Time
+	ObjectID  int64
+	Values    []float64 // Values aligned with MetricsResult.Columns. Missing values are NaN.
+}
 
-type BlockHostMapping struct {
-	*VastResourceEntry
+// MetricsResult is decoded columnar response of ad-hoc metrics query.
+type MetricsResult struct {
+	Columns []string // Metric names (timestamp and object_id columns are exposed as MetricsRow fields).
+	Rows    []MetricsRow
 }
 
-func (bhm *BlockHostMapping) Map(ctx context.Context, hostId, volumeId int64) (Record, error) {
-	body := Params{
-		"pairs_to_add": []Params{
-			{
-				"host_id":   hostId,
-				"volume_id": volumeId,
-			},
-		},
+// Column returns values of metric for all rows (nil if there is no such metric).
+func (r MetricsResult) Column(name string) []float64 {
+	for i, column := range r.Columns {
+		if column == name {
+			values := make([]float64, len(r.Rows))
+			for j, row := range r.Rows {
+				values[j] = row.Values[i]
+			}
+			return values
+		}
 	}
-	path := fmt.Sprintf("%s/bulk", bhm.resourcePath)
-	// Make request on behalf of VTask (for proper parsing)
-	task, err := request[Record](ctx, bhm, http.MethodPatch, path, bhm.apiVersion, nil, body)
+	return nil
+}
+
+// Query runs ad-hoc query of time-series metrics (IOPS, bandwidth, capacity etc.) and decodes columnar response.
+func (m *Monitor) Query(ctx context.Context, query MetricsQuery) (MetricsResult, error) {
+	params, err := query.params()
 	if err != nil {
-		return nil, err
+		return MetricsResult{}, err
+	}
+	if err = checkVastResourceVersionCompat(ctx, m.VastResourceEntry); err != nil {
+		return MetricsResult{}, err
 	}
-	intVal, err := toInt(task["id"])
+	response, err := request[Record](ctx, m, http.MethodGet, m.resourcePath+"/ad_hoc_query", m.apiVersion, params, nil)
 	if err != nil {
-		return nil, err
+		return MetricsResult{}, err
 	}
-	return bhm.rest.VTasks.WaitTask(ctx, intVal)
+	return decodeMetrics(response)
 }
 
-func (bhm *BlockHostMapping) UnMap(ctx context.Context, hostId, volumeId int64) (Record, error) {
-	body := Params{
-		"pairs_to_remove": []Params{
-			{
-				"host_id":   hostId,
-				"volume_id": volumeId,
-			},
-		},
-	}
-	path := fmt.Sprintf("%s/bulk", bhm.resourcePath)
-	task, err := request[Record](ctx, bhm, http.MethodPatch, path, bhm.apiVersion, nil, body)
+// decodeMetrics converts columnar response ({"prop_list": [...], "data": [[...], ...]}) to MetricsResult.
+func decodeMetrics(response Record) (MetricsResult, error) {
+	var result MetricsResult
+	props, err := response.GetSlice("prop_list")
 	if err != nil {
-		return nil, err
+		return result, err
 	}
-	intVal, err := toInt(task["id"])
+	data, err := response.GetSlice("data")
 	if err != nil {
-		return nil, err
+		return result, err
+	}
+	timestampIdx, objectIdx := -1, -1
+	var valueIdx []int
+	for i, prop := range props {
+		name := fmt.Sprintf("%v", prop)
+		switch name {
+		case "timestamp":
+			timestampIdx = i
+		case "object_id":
+			objectIdx = i
+		default:
+			result.Columns = append(result.Columns, name)
+			valueIdx = append(valueIdx, i)
+		}
+	}
+	for n, raw := range data {
+		cells, ok := raw.([]any)
+		if !ok || len(cells) != len(props) {
+			return result, fmt.Errorf("metrics row %d doesn't match prop_list of %d columns", n, len(props))
+		}
+		row := MetricsRow{Values: make([]float64, len(valueIdx))}
+		if timestampIdx >= 0 {
+			if ts, ok := cells[timestampIdx].(string); ok {
+				if row.Timestamp, err = time.Parse(time.RFC3339, ts); err != nil {
+					return result, fmt.Errorf("metrics row %d has invalid timestamp: %w", n, err)
+				}
+			}
+		}
+		if objectIdx >= 0 {
+			row.ObjectID, _ = toInt(cells[objectIdx])
+		}
+		for i, idx := range valueIdx {
+			if value, ok := toFloat(cells[idx]); ok {
+				row.Values[i] = value
+			} else {
+				row.Values[i] = math.NaN()
+			}
+		}
+		result.Rows = append(result.Rows, row)
 	}
-	return bhm.rest.VTasks.WaitTask(ctx, intVal)
+	return result, nil
 }
 
-func (bhm *BlockHostMapping) EnsureMap(ctx context.Context, hostId, volumeId int64) (Record, error) {
-	result, err := bhm.Get(ctx, Params{"volume__id": volumeId, "block_host__id": hostId})
-	if isNotFoundErr(err) {
-		return bhm.Map(ctx, hostId, volumeId)
+// ------------------------------------------------------
+
+type QosPolicy struct {
+	*VastResourceEntry
+}
+
+// QoS policy modes
+const (
+	QosModeStatic              = "STATIC"
+	QosModeUsedCapacity        = "USED_CAPACITY"
+	QosModeProvisionedCapacity = "PROVISIONED_CAPACITY"
+)
+
+// QosBurst describes burst settings of static QoS policy. Zero values mean burst is not allowed.
+// Burst of reads/writes requires corresponding max limit to be set.
+type QosBurst struct {
+	ReadsBwMb   int64 // Burst of read bandwidth in MB
+	WritesBwMb  int64 // Burst of write bandwidth in MB
+	ReadsIops   int64 // Burst of read IOPS
+	WritesIops  int64 // Burst of write IOPS
+	LoanMb      int64 // Amount of burst credits (MB) which can be loaned
+	LoanIops    int64 // Amount of burst credits (IOPS) which can be loaned
+	DurationSec int64 // Maximal duration of burst
+}
+
+// QosStaticLimits describes limits of QosModeStatic policy. Zero values mean no limit.
+type QosStaticLimits struct {
+	MaxReadsBwMbps  int64 // Bandwidth in MB/s
+	MaxWritesBwMbps int64 // Bandwidth in MB/s
+	MaxReadsIops    int64
+	MaxWritesIops   int64
+	MinReadsBwMbps  int64 // Guaranteed bandwidth in MB/s
+	MinWritesBwMbps int64 // Guaranteed bandwidth in MB/s
+	MinReadsIops    int64 // Guaranteed IOPS
+	MinWritesIops   int64 // Guaranteed IOPS
+	Burst           QosBurst
+}
+
+// QosCapacityLimits describes limits of capacity based policy (QosModeUsedCapacity, QosModeProvisionedCapacity)
+// per GB of capacity. Zero values mean no limit.
+type QosCapacityLimits struct {
+	MaxReadsBwMbpsPerGb  float64
+	MaxWritesBwMbpsPerGb float64
+	MaxReadsIopsPerGb    float64
+	MaxWritesIopsPerGb   float64
+}
+
+// qosLimitsProblems returns problems of limits: negative values and absence of any limit.
+func qosLimitsProblems[V int64 | float64](limits map[string]V) []string {
+	var problems []string
+	limited := false
+	for key, value := range limits {
+		if value < 0 {
+			problems = append(problems, fmt.Sprintf("%s must not be negative, got %v", key, value))
+		}
+		limited = limited || value != 0
 	}
-	return result, err
+	if !limited {
+		problems = append(problems, "at least one limit must be set")
+	}
+	sort.Strings(problems)
+	return problems
+}
+
+// params validates limits and converts them to "static_limits" field of QoS policy.
+func (l QosStaticLimits) params() (Params, []string) {
+	limits := map[string]int64{
+		"max_reads_bw_mbps":  l.MaxReadsBwMbps,
+		"max_writes_bw_mbps": l.MaxWritesBwMbps,
+		"max_reads_iops":     l.MaxReadsIops,
+		"max_writes_iops":    l.MaxWritesIops,
+		"min_reads_bw_mbps":  l.MinReadsBwMbps,
+		"min_writes_bw_mbps": l.MinWritesBwMbps,
+		"min_reads_iops":     l.MinReadsIops,
+		"min_writes_iops":    l.MinWritesIops,
+	}
+	problems := qosLimitsProblems(limits)
+	burst := map[string]int64{
+		"burst_reads_bw_mb":   l.Burst.ReadsBwMb,
+		"burst_writes_bw_mb":  l.Burst.WritesBwMb,
+		"burst_reads_iops":    l.Burst.ReadsIops,
+		"burst_writes_iops":   l.Burst.WritesIops,
+		"burst_reads_loan_mb": l.Burst.LoanMb,
+		"burst_loan_iops":     l.Burst.LoanIops,
+		"burst_duration_sec":  l.Burst.DurationSec,
+	}
+	for key, value := range burst {
+		if value < 0 {
+			problems = append(problems, fmt.Sprintf("%s must not be negative, got %d", key, value))
+		}
+	}
+	// Burst makes sense only above max limit
+	for burstKey, maxKey := range map[string]string{
+		"burst_reads_bw_mb":  "max_reads_bw_mbps",
+		"burst_writes_bw_mb": "max_writes_bw_mbps",
+		"burst_reads_iops":   "max_reads_iops",
+		"burst_writes_iops":  "max_writes_iops",
+	} {
+		if burst[burstKey] > 0 && limits[maxKey] == 0 {
+			problems = append(problems, fmt.Sprintf("%s requires %s to be set", burstKey, maxKey))
+		}
+	}
+	for _, kind := range []string{"reads_bw_mbps", "writes_bw_mbps", "reads_iops", "writes_iops"} {
+		minValue, maxValue := limits["min_"+kind], limits["max_"+kind]
+		if minValue > 0 && maxValue > 0 && minValue > maxValue {
+			problems = append(problems, fmt.Sprintf("min_%s (%d) is greater than max_%s (%d)", kind, minValue, kind, maxValue))
+		}
+	}
+	sort.Strings(problems)
+	result := Params{}
+	for key, value := range limits {
+		if value > 0 {
+			result[key] = value
+		}
+	}
+	for key, value := range burst {
+		if value > 0 {
+			result[key] = value
+		}
+	}
+	return result, problems
+}
+
+// params validates limits and converts them to "capacity_limits" field of QoS policy.
+func (l QosCapacityLimits) params() (Params, []string) {
+	limits := map[string]float64{
+		"max_reads_bw_mbps_per_gb_capacity":  l.MaxReadsBwMbpsPerGb,
+		"max_writes_bw_mbps_per_gb_capacity": l.MaxWritesBwMbpsPerGb,
+		"max_reads_iops_per_gb_capacity":     l.MaxReadsIopsPerGb,
+		"max_writes_iops_per_gb_capacity":    l.MaxWritesIopsPerGb,
+	}
+	result := Params{}
+	for key, value := range limits {
+		if value > 0 {
+			result[key] = value
+		}
+	}
+	return result, qosLimitsProblems(limits)
+}
+
+// createWithLimits verifies that extra doesn't contradict mode and creates QoS policy.
+func (q *QosPolicy) createWithLimits(ctx context.Context, name, mode, limitsKey string, limits Params, problems []string, extra Params) (Record, error) {
+	fields := []string{limitsKey}
+	if value, ok := extra["mode"]; ok && fmt.Sprintf("%v", value) != mode {
+		fields = append(fields, "mode")
+		problems = append(problems, fmt.Sprintf("mode %v in extra params contradicts %s limits", value, mode))
+	}
+	for _, key := range []string{"static_limits", "capacity_limits"} {
+		if _, ok := extra[key]; ok && key != limitsKey {
+			fields = append(fields, key)
+			problems = append(problems, fmt.Sprintf("%s cannot be used with %s mode", key, mode))
+		}
+	}
+	if len(problems) > 0 {
+		return nil, &ValidationError{Resource: q.resourcePath, Fields: fields, Problems: problems}
+	}
+	body := Params{"name": name, "mode": mode, limitsKey: limits}
+	body.Update(extra, false)
+	return q.Create(ctx, body)
+}
+
+// CreateStatic creates QoS policy with static limits (QosModeStatic).
+// Limits are validated before request is sent: negative values, absent limits, burst without corresponding
+// max limit and min limits above max limits are rejected. Extra fields (e.g. "policy_type") are added to request body.
+func (q *QosPolicy) CreateStatic(ctx context.Context, name string, limits QosStaticLimits, extra Params) (Record, error) {
+	params, problems := limits.params()
+	return q.createWithLimits(ctx, name, QosModeStatic, "static_limits", params, problems, extra)
+}
+
+// CreateCapacity creates QoS policy with limits per GB of capacity.
+// mode must be QosModeUsedCapacity or QosModeProvisionedCapacity.
+func (q *QosPolicy) CreateCapacity(ctx context.Context, name string, mode string, limits QosCapacityLimits, extra Params) (Record, error) {
+	if err := q.rest.requireFeature(ctx, FeatureQosCapacityModes); err != nil {
+		return nil, err
+	}
+	params, problems := limits.params()
+	if mode != QosModeUsedCapacity && mode != QosModeProvisionedCapacity {
+		problems = append(problems, fmt.Sprintf("mode must be %s or %s for capacity limits, got %q", QosModeUsedCapacity, QosModeProvisionedCapacity, mode))
+	}
+	return q.createWithLimits(ctx, name, mode, "capacity_limits", params, problems, extra)
+}
+
+// AttachToView sets QoS policy of view.
+func (q *QosPolicy) AttachToView(ctx context.Context, qosId, viewId int64) (Record, error) {
+	return q.rest.Views.Update(ctx, viewId, Params{"qos_policy_id": qosId})
+}
+
+// ------------------------------------------------------
+
+type Dns struct {
+	*VastResourceEntry
+}
+
+// defaultDnsName is name of DNS object created by EnsureEnabled when params have no "name".
+const defaultDnsName = "vast-dns"
+
+// EnsureEnabled creates or updates the single DNS object of the cluster so that it is enabled
+// and served on given vip with given domain suffix. Additional fields (e.g. "net_mask", "gateway")
+// can be passed in params. Existing DNS object is updated only if some of the fields differ.
+func (d *Dns) EnsureEnabled(ctx context.Context, vip string, domainSuffix string, params Params) (Record, error) {
+	body := Params{"vip": vip, "domain_suffix": domainSuffix, "enabled": true}
+	body.Update(params, true)
+	existing, err := d.GetSingleton(ctx)
+	if isNotFoundErr(err) {
+		if _, ok := body["name"]; !ok {
+			body["name"] = defaultDnsName
+		}
+		return d.Create(ctx, body)
+	} else if err != nil {
+		return nil, err
+	}
+	changed, equal := existing.Diff(body)
+	if equal {
+		return existing, nil
+	}
+	id, err := existing.ID()
+	if err != nil {
+		return nil, err
+	}
+	return d.Update(ctx, id, changed)
+}
+
+// Disable disables the single DNS object of the cluster. Returns NotFoundError if DNS is not configured.
+func (d *Dns) Disable(ctx context.Context) (Record, error) {
+	existing, err := d.GetSingleton(ctx)
+	if err != nil {
+		return nil, err
+	}
+	id, err := existing.ID()
+	if err != nil {
+		return nil, err
+	}
+	return d.Update(ctx, id, Params{"enabled": false})
+}
+
+// ------------------------------------------------------
+
+type ViewPolicy struct {
+	*VastResourceEntry
+}
+
+// ------------------------------------------------------
+
+type Group struct {
+	*VastResourceEntry
+}
+
+// ------------------------------------------------------
+
+type Nis struct {
+	*VastResourceEntry
+}
+
+// ------------------------------------------------------
+
+type Tenant struct {
+	*VastResourceEntry
+}
+
+// TenantNotEmptyError is returned by Tenant.DeleteSafely when tenant still holds resources.
+type TenantNotEmptyError struct {
+	TenantId int64
+	Blocking map[string]int64 // Number of blocking resources by resource path (e.g. "views": 2)
+}
+
+func (e *TenantNotEmptyError) Error() string {
+	blocking := make([]string, 0, len(e.Blocking))
+	for _, resource := range slices.Sorted(maps.Keys(e.Blocking)) {
+		blocking = append(blocking, fmt.Sprintf("%d %s", e.Blocking[resource], resource))
+	}
+	return fmt.Sprintf("tenant with id %d is not empty (%s): pass force to delete it anyway", e.TenantId, strings.Join(blocking, ", "))
+}
+
+// EnsureTenant returns tenant with given name or creates it if not exists.
+// Client ip ranges are pairs of start/end addresses compared with existing ranges regardless of order
+// and address formatting. Existing tenant is updated only with fields which differ.
+func (t *Tenant) EnsureTenant(ctx context.Context, name string, clientIpRanges [][2]string, extra Params) (Record, error) {
+	ctx = withFeature(ctx, "ensure")
+	normalized, err := normalizeIpRanges(clientIpRanges)
+	if err != nil {
+		return nil, &ValidationError{Resource: t.resourcePath, Fields: []string{"client_ip_ranges"}, Problems: []string{err.Error()}}
+	}
+	existing, err := t.Get(ctx, Params{"name": name})
+	if isNotFoundErr(err) {
+		body := Params{"name": name, "client_ip_ranges": normalized}
+		body.Update(extra, false)
+		return t.Create(ctx, body)
+	} else if err != nil {
+		return nil, err
+	}
+	changed, _ := existing.Diff(extra)
+	actual, err := ipRangesOf(existing, "client_ip_ranges")
+	if err != nil || !ipRangesEqual(actual, normalized) {
+		changed["client_ip_ranges"] = normalized
+	}
+	if len(changed) == 0 {
+		return existing, nil
+	}
+	id, err := existing.ID()
+	if err != nil {
+		return nil, err
+	}
+	return t.Update(ctx, id, changed)
+}
+
+// DeleteSafely deletes tenant only if it holds no views and quotas.
+// Otherwise TenantNotEmptyError listing blocking resources is returned unless force is true.
+func (t *Tenant) DeleteSafely(ctx context.Context, id int64, force bool) (EmptyRecord, error) {
+	if !force {
+		blocking := map[string]int64{}
+		for _, resource := range []*VastResourceEntry{t.rest.Views.VastResourceEntry, t.rest.Quotas.VastResourceEntry} {
+			count, err := resource.Count(ctx, Params{"tenant_id": id})
+			if err != nil {
+				return nil, err
+			}
+			if count > 0 {
+				blocking[resource.resourcePath] = count
+			}
+		}
+		if len(blocking) > 0 {
+			return nil, &TenantNotEmptyError{TenantId: id, Blocking: blocking}
+		}
+	}
+	return t.DeleteById(ctx, id)
+}
+
+// ------------------------------------------------------
+
+type Ldap struct {
+	*VastResourceEntry
+}
+
+// TestConnection validates LDAP configuration (urls, binddn, bindpw, searchbase etc.) without saving it.
+// Returns result of the test reported by VMS.
+func (l *Ldap) TestConnection(ctx context.Context, params Params) (Record, error) {
+	if err := checkVastResourceVersionCompat(ctx, l.VastResourceEntry); err != nil {
+		return nil, err
+	}
+	path := fmt.Sprintf("%s/test", l.resourcePath)
+	return request[Record](ctx, l, http.MethodPost, path, l.apiVersion, nil, params)
+}
+
+// UpdateBindCredentials replaces bind DN and bind password of LDAP configuration. Other fields are left untouched.
+// NOTE: "bindpw" is one of DefaultSensitiveKeys so password is redacted in logs and Render output.
+func (l *Ldap) UpdateBindCredentials(ctx context.Context, id int64, binddn, bindpw string) (Record, error) {
+	if binddn == "" || bindpw == "" {
+		return nil, errors.New("binddn and bindpw must not be empty")
+	}
+	return l.Update(ctx, id, Params{"binddn": binddn, "bindpw": bindpw})
+}
+
+// ------------------------------------------------------
+
+type S3LifeCycleRule struct {
+	*VastResourceEntry
+}
+
+// lifecycleExclusiveFields are pairs of lifecycle rule fields which express the same setting
+// either as number of days or as date. Only one field of pair can be set.
+var lifecycleExclusiveFields = [][2]string{
+	{"expiration_days", "expiration_date"},
+	{"transition_days", "transition_date"},
+}
+
+// ListForView returns all lifecycle rules of view.
+func (l *S3LifeCycleRule) ListForView(ctx context.Context, viewId int64) (RecordSet, error) {
+	return l.List(ctx, Params{"view_id": viewId})
+}
+
+// EnsureRule returns lifecycle rule of view with given prefix or creates it if not exists.
+// Lifecycle rules are unique per view and prefix. Fields of existing rule which differ from rule params
+// (e.g. expiration and transition settings) are updated. Day count and date fields of the same setting
+// (e.g. "expiration_days" and "expiration_date") must not be set together; when existing rule uses the other
+// field of the pair it is cleared in the same update.
+func (l *S3LifeCycleRule) EnsureRule(ctx context.Context, viewId int64, prefix string, rule Params) (Record, error) {
+	ctx = withFeature(ctx, "ensure")
+	var problems, fields []string
+	for _, pair := range lifecycleExclusiveFields {
+		if rule[pair[0]] != nil && rule[pair[1]] != nil {
+			fields = append(fields, pair[0], pair[1])
+			problems = append(problems, fmt.Sprintf("only one of %s and %s can be set", pair[0], pair[1]))
+		}
+	}
+	if len(problems) > 0 {
+		return nil, &ValidationError{Resource: l.resourcePath, Fields: fields, Problems: problems}
+	}
+	searchParams := Params{"view_id": viewId, "prefix": prefix}
+	existing, err := l.Get(ctx, searchParams)
+	if isNotFoundErr(err) {
+		body := Params{}
+		body.Update(rule, false)
+		body.Update(searchParams, true)
+		return l.Create(ctx, body)
+	} else if err != nil {
+		return nil, err
+	}
+	changed, equal := existing.Diff(rule)
+	if equal {
+		return existing, nil
+	}
+	for _, pair := range lifecycleExclusiveFields {
+		for i, field := range pair {
+			other := pair[1-i]
+			if _, ok := changed[field]; ok && rule[field] != nil && existing[other] != nil {
+				changed[other] = nil
+			}
+		}
+	}
+	id, err := existing.ID()
+	if err != nil {
+		return nil, err
+	}
+	return l.Update(ctx, id, changed)
+}
+
+// ------------------------------------------------------
+
+type ActiveDirectory struct {
+	*VastResourceEntry
+}
+
+// ActiveDirectory machine account states (see Join)
+var (
+	adJoinedStates = []string{"joined", "connected"}
+	adFailedStates = []string{"failed", "error"}
+)
+
+// Join creates ActiveDirectory configuration and waits until machine account is joined to the domain.
+// params must contain domain credentials required by VMS (e.g. "domain_name", "admin_username", "admin_password").
+// Optional PollOptions control polling interval, backoff and timeout.
+// If joining fails, returned error contains failure message from "last_error" field of the record.
+func (a *ActiveDirectory) Join(ctx context.Context, params Params, opts ...PollOptions) (Record, error) {
+	record, err := a.Create(ctx, params)
+	if err != nil {
+		return nil, err
+	}
+	id, err := record.ID()
+	if err != nil {
+		return nil, err
+	}
+	record, err = a.WaitForState(ctx, id, "state", adJoinedStates, adFailedStates, firstOrDefault(opts))
+	if err != nil {
+		return record, withLastError(record, fmt.Sprintf("failed to join ActiveDirectory with id %d", id), err)
+	}
+	return record, nil
+}
+
+// withLastError wraps err with failure message reported by VMS in "last_error" field of record (if any).
+func withLastError(record Record, msg string, err error) error {
+	if lastError, _ := record["last_error"].(string); lastError != "" {
+		return fmt.Errorf("%s: %s: %w", msg, lastError, err)
+	}
+	return err
+}
+
+// Leave removes machine account from the domain and deletes ActiveDirectory configuration.
+// Domain admin credentials are sent in DELETE request body.
+func (a *ActiveDirectory) Leave(ctx context.Context, id int64, adminUser, adminPass string) (EmptyRecord, error) {
+	return a.DeleteByIdWithBody(ctx, id, Params{"admin_username": adminUser, "admin_password": adminPass})
+}
+
+// ------------------------------------------------------
+
+type S3Policy struct {
+	*VastResourceEntry
+}
+
+// AttachToUser adds S3 policy to user's "s3_policies_ids". Returns false if policy is already attached.
+func (s *S3Policy) AttachToUser(ctx context.Context, policyId, userId int64) (bool, error) {
+	return s.setAttached(ctx, s.rest.Users.VastResourceEntry, userId, policyId, true)
+}
+
+// DetachFromUser removes S3 policy from user's "s3_policies_ids". Returns false if policy is not attached.
+func (s *S3Policy) DetachFromUser(ctx context.Context, policyId, userId int64) (bool, error) {
+	return s.setAttached(ctx, s.rest.Users.VastResourceEntry, userId, policyId, false)
+}
+
+// AttachToGroup adds S3 policy to group's "s3_policies_ids". Returns false if policy is already attached.
+func (s *S3Policy) AttachToGroup(ctx context.Context, policyId, groupId int64) (bool, error) {
+	return s.setAttached(ctx, s.rest.Groups.VastResourceEntry, groupId, policyId, true)
+}
+
+// DetachFromGroup removes S3 policy from group's "s3_policies_ids". Returns false if policy is not attached.
+func (s *S3Policy) DetachFromGroup(ctx context.Context, policyId, groupId int64) (bool, error) {
+	return s.setAttached(ctx, s.rest.Groups.VastResourceEntry, groupId, policyId, false)
+}
+
+// setAttached reads "s3_policies_ids" of user or group, adds or removes policy id and writes list back if it changed.
+// Absent list is treated as empty, ids are deduplicated. Returns whether change was made.
+func (s *S3Policy) setAttached(ctx context.Context, target *VastResourceEntry, targetId, policyId int64, attach bool) (bool, error) {
+	record, err := target.GetById(ctx, targetId)
+	if err != nil {
+		return false, err
+	}
+	var raw []any
+	if value, ok := record["s3_policies_ids"]; ok && value != nil {
+		if raw, ok = value.([]any); !ok {
+			return false, &WrongTypeError{Key: "s3_policies_ids", Expected: "list of ids", Value: value}
+		}
+	}
+	seen := make(map[int64]struct{}, len(raw))
+	ids := make([]int64, 0, len(raw)+1)
+	attached := false
+	for _, item := range raw {
+		id, err := toInt(item)
+		if err != nil {
+			return false, fmt.Errorf("invalid s3_policies_ids of %s %d: %w", target.resourceType, targetId, err)
+		}
+		if _, dup := seen[id]; dup {
+			continue
+		}
+		seen[id] = struct{}{}
+		if id == policyId {
+			attached = true
+			if !attach {
+				continue
+			}
+		}
+		ids = append(ids, id)
+	}
+	if attached == attach {
+		return false, nil
+	}
+	if attach {
+		ids = append(ids, policyId)
+	}
+	if _, err = target.Update(ctx, targetId, Params{"s3_policies_ids": ids}); err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+// ------------------------------------------------------
+
+type ProtectedPath struct {
+	*VastResourceEntry
+}
+
+// EnsureProtectedPath returns protected path of sourceDir in tenant or creates it with given protection policy.
+// Protected paths are identified by source directory and tenant, name is used only on create.
+// If VMS creates protected path asynchronously, creation task is awaited (see CreateAsync).
+func (pp *ProtectedPath) EnsureProtectedPath(ctx context.Context, name, sourceDir string, policyId int64, tenantId int) (Record, error) {
+	ctx = withFeature(ctx, "ensure")
+	searchParams := Params{"source_dir": sourceDir, "tenant_id": tenantId}
+	result, err := pp.Get(ctx, searchParams)
+	if isNotFoundErr(err) {
+		body := Params{"name": name, "protection_policy_id": policyId}
+		body.Update(searchParams, false)
+		return pp.CreateAsync(ctx, body)
+	}
+	return result, err
+}
+
+// DeleteByIdAndWait deletes protected path and waits until it is actually gone
+// (protected paths stay in "removing" state for a while after deletion, see WaitForDeletion).
+// Returns id of asynchronous VTask if VMS reports one (0 otherwise) so callers can correlate deletion with VTasks.
+// NOTE: unlike DeleteAndWait protected path is addressed by id and missing protected path is an error.
+func (pp *ProtectedPath) DeleteByIdAndWait(ctx context.Context, id int64, opts ...PollOptions) (int64, error) {
+	if err := checkVastResourceVersionCompat(ctx, pp.VastResourceEntry); err != nil {
+		return 0, err
+	}
+	path := fmt.Sprintf("%s/%d", pp.resourcePath, id)
+	response, err := request[Record](ctx, pp, http.MethodDelete, path, pp.apiVersion, nil, nil)
+	if err != nil {
+		return 0, err
+	}
+	taskId, _ := asyncTaskId(response)
+	if err = pp.WaitForDeletion(ctx, id, firstOrDefault(opts)); err != nil {
+		return taskId, err
+	}
+	return taskId, nil
+}
+
+// ------------------------------------------------------
+
+type GlobalSnapshotStream struct {
+	*VastResourceEntry
+}
+
+// Global snapshot stream statuses (see WaitUntilFinished)
+var (
+	streamFinishedStates = []string{"finished", "completed"}
+	streamFailedStates   = []string{"failed", "error", "stopped"}
+)
+
+// CreateFromSnapshot creates global snapshot stream which clones snapshot to targetPath.
+// Additional fields (e.g. "name", "loanee_tenant_id", "enabled") can be passed in params.
+// If params have no "name", name is generated from snapshot id.
+func (gs *GlobalSnapshotStream) CreateFromSnapshot(ctx context.Context, snapshotId int64, targetPath string, params Params) (Record, error) {
+	body := Params{"source_snapshot_id": snapshotId, "loanee_root_path": targetPath}
+	body.Update(params, true)
+	if _, ok := body["name"]; !ok {
+		body["name"] = fmt.Sprintf("snapshot-%d-stream", snapshotId)
+	}
+	return gs.Create(ctx, body)
+}
+
+// WaitUntilFinished polls status of global snapshot stream until it is finished (see WaitForState).
+// onProgress (if not nil) is called with progress in percents every time progress changes.
+// Optional PollOptions control polling interval, backoff and timeout.
+// If stream fails or is stopped ResourceStateError is returned.
+func (gs *GlobalSnapshotStream) WaitUntilFinished(ctx context.Context, id int64, onProgress func(percent float64), opts ...PollOptions) (Record, error) {
+	progress := -1.0
+	record, err := gs.waitForState(ctx, id, "status", streamFinishedStates, streamFailedStates, firstOrDefault(opts), func(record Record) {
+		if current, ok := streamProgress(record); ok && current != progress {
+			progress = current
+			if onProgress != nil {
+				onProgress(current)
+			}
+		}
+	})
+	if isContextErr(err) {
+		err = fmt.Errorf("global snapshot stream with id %d is not finished (progress: %.1f%%): %w", id, max(progress, 0), err)
+	}
+	return record, err
+}
+
+// Stop stops running global snapshot stream.
+func (gs *GlobalSnapshotStream) Stop(ctx context.Context, id int64) (Record, error) {
+	return gs.actionRequest(ctx, http.MethodPatch, id, "stop", nil, nil)
+}
+
+// streamProgress returns "progress" of global snapshot stream in percents.
+// VMS reports progress either as number or as string with percent sign (e.g. "42%").
+func streamProgress(record Record) (float64, bool) {
+	switch v := record["progress"].(type) {
+	case float64:
+		return v, true
+	case string:
+		parsed, err := strconv.ParseFloat(strings.TrimSpace(strings.TrimSuffix(v, "%")), 64)
+		return parsed, err == nil
+	default:
+		return 0, false
+	}
+}
+
+// ------------------------------------------------------
+
+type ReplicationPeers struct {
+	*VastResourceEntry
+}
+
+// Replication peer states (see WaitForConnected)
+var (
+	peerConnectedStates = []string{"connected"}
+	peerFailedStates    = []string{"failed", "error"}
+)
+
+// EnsurePeer returns replication peer with given name or creates it if not exists.
+// leadingVip is VIP of remote cluster used for handshake and remoteVipPool is name of VIP pool used for replication.
+// Extra fields (e.g. "secure_mode") are added to request body on create.
+func (rp *ReplicationPeers) EnsurePeer(ctx context.Context, name, leadingVip string, remoteVipPool string, extra Params) (Record, error) {
+	body := Params{"leading_vip": leadingVip, "remote_vip_pool": remoteVipPool}
+	body.Update(extra, false)
+	return rp.Ensure(ctx, name, body)
+}
+
+// WaitForConnected waits until secure handshake with replication peer is completed.
+// If peer reaches failed state, error with message reported by remote side is returned immediately.
+// Zero timeout means VMSConfig.Timeouts.LongRunning.
+func (rp *ReplicationPeers) WaitForConnected(ctx context.Context, id int64, timeout time.Duration) (Record, error) {
+	record, err := rp.WaitForState(ctx, id, "state", peerConnectedStates, peerFailedStates, PollOptions{Timeout: timeout})
+	if err != nil {
+		return record, withLastError(record, fmt.Sprintf("replication peer with id %d is not connected", id), err)
+	}
+	return record, nil
+}
+
+// ------------------------------------------------------
+
+type ProtectionPolicy struct {
+	*VastResourceEntry
+}
+
+// ProtectionPolicyFrame is a single schedule of protection policy: snapshot is taken Every period starting at StartAt
+// and kept locally for KeepLocal and on replication target for KeepRemote.
+// Periods must be whole minutes. Zero KeepLocal/KeepRemote means snapshots are not kept at corresponding side.
+type ProtectionPolicyFrame struct {
+	Every      time.Duration
+	StartAt    time.Time // Zero value means "now"
+	KeepLocal  time.Duration
+	KeepRemote time.Duration
+}
+
+// protectionFrameTimeLayout is layout of "start-at" field of protection policy frame.
+const protectionFrameTimeLayout = "2006-01-02 15:04:05"
+
+// validate returns descriptive problems of impossible schedule.
+func (f ProtectionPolicyFrame) validate() []string {
+	var problems []string
+	for name, period := range map[string]time.Duration{"every": f.Every, "keep-local": f.KeepLocal, "keep-remote": f.KeepRemote} {
+		if period < 0 || period%time.Minute != 0 {
+			problems = append(problems, fmt.Sprintf("%s must be non-negative whole number of minutes, got %s", name, period))
+		}
+	}
+	if f.Every <= 0 {
+		problems = append(problems, "every must be positive")
+	}
+	if f.KeepLocal == 0 && f.KeepRemote == 0 {
+		problems = append(problems, "at least one of keep-local and keep-remote must be set")
+	}
+	if f.KeepLocal > 0 && f.KeepLocal < f.Every {
+		problems = append(problems, fmt.Sprintf("keep-local (%s) is shorter than every (%s)", f.KeepLocal, f.Every))
+	}
+	if f.KeepRemote > 0 && f.KeepRemote < f.Every {
+		problems = append(problems, fmt.Sprintf("keep-remote (%s) is shorter than every (%s)", f.KeepRemote, f.Every))
+	}
+	sort.Strings(problems)
+	return problems
+}
+
+// params converts frame to element of "frames" array of protection policy.
+func (f ProtectionPolicyFrame) params() Params {
+	frame := Params{"every": formatFramePeriod(f.Every)}
+	if !f.StartAt.IsZero() {
+		frame["start-at"] = f.StartAt.UTC().Format(protectionFrameTimeLayout)
+	}
+	if f.KeepLocal > 0 {
+		frame["keep-local"] = formatFramePeriod(f.KeepLocal)
+	}
+	if f.KeepRemote > 0 {
+		frame["keep-remote"] = formatFramePeriod(f.KeepRemote)
+	}
+	return frame
+}
+
+// framePeriodUnits are units of protection policy periods from largest to smallest.
+var framePeriodUnits = []struct {
+	suffix string
+	period time.Duration
+}{
+	{"Y", 365 * 24 * time.Hour},
+	{"M", 30 * 24 * time.Hour},
+	{"W", 7 * 24 * time.Hour},
+	{"D", 24 * time.Hour},
+	{"h", time.Hour},
+	{"m", time.Minute},
+}
+
+// formatFramePeriod formats period with the largest unit which divides it (e.g. 48h -> "2D").
+// Months and years are never used for formatting because they are approximations.
+func formatFramePeriod(period time.Duration) string {
+	for _, unit := range framePeriodUnits[2:] {
+		if period%unit.period == 0 {
+			return fmt.Sprintf("%d%s", period/unit.period, unit.suffix)
+		}
+	}
+	return fmt.Sprintf("%dm", period/time.Minute)
+}
+
+// parseFramePeriod parses protection policy period (e.g. "30m", "1D", "2W", "1Y").
+func parseFramePeriod(raw string) (time.Duration, error) {
+	raw = strings.TrimSpace(raw)
+	for _, unit := range framePeriodUnits {
+		if number, ok := strings.CutSuffix(raw, unit.suffix); ok {
+			n, err := strconv.ParseInt(number, 10, 64)
+			if err != nil {
+				break
+			}
+			return time.Duration(n) * unit.period, nil
+		}
+	}
+	return 0, fmt.Errorf("invalid protection policy period %q", raw)
+}
+
+// decodeFrames parses "frames" field of protection policy record.
+func decodeFrames(record Record) ([]ProtectionPolicyFrame, error) {
+	raw, _ := record["frames"].([]any)
+	frames := make([]ProtectionPolicyFrame, 0, len(raw))
+	for i, item := range raw {
+		fields, ok := item.(map[string]any)
+		if !ok {
+			return nil, fmt.Errorf("invalid frame #%d: %v", i, item)
+		}
+		var (
+			frame ProtectionPolicyFrame
+			err   error
+		)
+		for key, target := range map[string]*time.Duration{"every": &frame.Every, "keep-local": &frame.KeepLocal, "keep-remote": &frame.KeepRemote} {
+			if value, ok := fields[key].(string); ok && value != "" {
+				if *target, err = parseFramePeriod(value); err != nil {
+					return nil, fmt.Errorf("invalid frame #%d: %w", i, err)
+				}
+			}
+		}
+		if value, ok := fields["start-at"].(string); ok && value != "" {
+			if frame.StartAt, err = time.ParseInLocation(protectionFrameTimeLayout, value, time.UTC); err != nil {
+				return nil, fmt.Errorf("invalid frame #%d: %w", i, err)
+			}
+		}
+		frames = append(frames, frame)
+	}
+	return frames, nil
+}
+
+// framesParams validates frames and converts them to "frames" field of protection policy.
+func (p *ProtectionPolicy) framesParams(frames []ProtectionPolicyFrame) ([]Params, error) {
+	var problems []string
+	result := make([]Params, 0, len(frames))
+	for i, frame := range frames {
+		for _, problem := range frame.validate() {
+			problems = append(problems, fmt.Sprintf("frame #%d: %s", i, problem))
+		}
+		result = append(result, frame.params())
+	}
+	if len(problems) > 0 {
+		return nil, &ValidationError{Resource: p.resourcePath, Fields: []string{"frames"}, Problems: problems}
+	}
+	return result, nil
+}
+
+// CreateWithFrames creates protection policy with given clone type (e.g. "LOCAL", "NATIVE_REPLICATION", "CLOUD_REPLICATION")
+// and schedule frames. Frames are validated before request is sent. Extra fields (e.g. "prefix", "target_object_id")
+// are added to request body.
+func (p *ProtectionPolicy) CreateWithFrames(ctx context.Context, name string, cloneType string, frames []ProtectionPolicyFrame, extra Params) (Record, error) {
+	if len(frames) == 0 {
+		return nil, &ValidationError{Resource: p.resourcePath, Fields: []string{"frames"}, Problems: []string{"at least one frame is required"}}
+	}
+	framesParams, err := p.framesParams(frames)
+	if err != nil {
+		return nil, err
+	}
+	body := Params{"name": name, "clone_type": cloneType, "frames": framesParams}
+	body.Update(extra, false)
+	return p.Create(ctx, body)
+}
+
+// AddFrame appends schedule frame to existing protection policy.
+func (p *ProtectionPolicy) AddFrame(ctx context.Context, id int64, frame ProtectionPolicyFrame) (Record, error) {
+	return p.modifyFrames(ctx, id, func(frames []ProtectionPolicyFrame) ([]ProtectionPolicyFrame, error) {
+		return append(frames, frame), nil
+	})
+}
+
+// RemoveFrame removes schedule frame with given Every period from existing protection policy.
+// Returns error if policy has no such frame or if it is the last frame of policy.
+func (p *ProtectionPolicy) RemoveFrame(ctx context.Context, id int64, every time.Duration) (Record, error) {
+	return p.modifyFrames(ctx, id, func(frames []ProtectionPolicyFrame) ([]ProtectionPolicyFrame, error) {
+		kept := make([]ProtectionPolicyFrame, 0, len(frames))
+		for _, frame := range frames {
+			if frame.Every != every {
+				kept = append(kept, frame)
+			}
+		}
+		if len(kept) == len(frames) {
+			return nil, fmt.Errorf("protection policy with id %d has no frame every %s", id, formatFramePeriod(every))
+		}
+		if len(kept) == 0 {
+			return nil, fmt.Errorf("cannot remove the last frame of protection policy with id %d", id)
+		}
+		return kept, nil
+	})
+}
+
+// modifyFrames reads frames of protection policy, modifies them with fn and writes them back.
+func (p *ProtectionPolicy) modifyFrames(ctx context.Context, id int64, fn func([]ProtectionPolicyFrame) ([]ProtectionPolicyFrame, error)) (Record, error) {
+	policy, err := p.GetById(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	frames, err := decodeFrames(policy)
+	if err != nil {
+		return nil, err
+	}
+	if frames, err = fn(frames); err != nil {
+		return nil, err
+	}
+	framesParams, err := p.framesParams(frames)
+	if err != nil {
+		return nil, err
+	}
+	return p.Update(ctx, id, Params{"frames": framesParams})
+}
+
+// ------------------------------------------------------
+
+type S3ReplicationPeers struct {
+	*VastResourceEntry
+}
+
+// Deprecated: use S3ReplicationPeers.
+type S3replicationPeers = S3ReplicationPeers
+
+// ------------------------------------------------------
+
+type Realm struct {
+	*VastResourceEntry
+}
+
+// EnsureRealm returns realm with given name or creates it if not exists.
+// Object types are deduplicated and compared order-insensitively; drifted set of object types is updated.
+func (r *Realm) EnsureRealm(ctx context.Context, name string, objectTypes []string) (Record, error) {
+	normalized := normalizeStringSet(objectTypes)
+	if len(normalized) == 0 {
+		return nil, &ValidationError{Resource: r.resourcePath, Fields: []string{"object_types"}, Problems: []string{"at least one object type is required"}}
+	}
+	return r.EnsureByParams(ctx, Params{"name": name}, Params{"object_types": normalized}, EnsureOptions{UpdateOnDrift: true})
+}
+
+// ------------------------------------------------------
+
+type Role struct {
+	*VastResourceEntry
+}
+
+// Permission actions and realms (domains) which form role permissions (e.g. "create_security", "view_monitoring").
+var (
+	roleActions = []string{"create", "view", "edit", "delete"}
+	roleRealms  = []string{"hardware", "logical", "security", "settings", "monitoring", "support", "events", "database"}
+)
+
+// normalizePermissions validates permissions against known actions and realms and returns them lowercased,
+// deduplicated and sorted.
+func normalizePermissions(permissions []string) ([]string, []string) {
+	var problems []string
+	lowered := make([]string, len(permissions))
+	for i, permission := range permissions {
+		lowered[i] = strings.ToLower(permission)
+	}
+	normalized := normalizeStringSet(lowered)
+	for _, permission := range normalized {
+		action, realm, ok := strings.Cut(permission, "_")
+		if !ok || !slices.Contains(roleActions, action) || !slices.Contains(roleRealms, realm) {
+			problems = append(problems, fmt.Sprintf("unknown permission %q (expected <%s>_<%s>)",
+				permission, strings.Join(roleActions, "|"), strings.Join(roleRealms, "|")))
+		}
+	}
+	if len(normalized) == 0 {
+		problems = append(problems, "at least one permission is required")
+	}
+	return normalized, problems
+}
+
+// EnsureRole returns role with given name in tenant or creates it if not exists.
+// Permissions have form <action>_<realm> (e.g. "create_security", "view_monitoring") and are validated,
+// lowercased and deduplicated before request is sent. Drifted permission set of existing role is updated.
+func (r *Role) EnsureRole(ctx context.Context, name string, permissions []string, tenantId int) (Record, error) {
+	normalized, problems := normalizePermissions(permissions)
+	if len(problems) > 0 {
+		return nil, &ValidationError{Resource: r.resourcePath, Fields: []string{"permissions_list"}, Problems: problems}
+	}
+	return r.EnsureByParams(ctx, Params{"name": name, "tenant_id": tenantId}, Params{"permissions_list": normalized}, EnsureOptions{UpdateOnDrift: true})
+}
+
+// ------------------------------------------------------
+
+type Alarm struct {
+	*VastResourceEntry
+}
+
+// Alarm severities (see ListBySeverity)
+const (
+	AlarmSeverityInfo     = "INFO"
+	AlarmSeverityMinor    = "MINOR"
+	AlarmSeverityMajor    = "MAJOR"
+	AlarmSeverityCritical = "CRITICAL"
+)
+
+// ListBySeverity returns all alarms with given severity (e.g. AlarmSeverityCritical).
+// Additional filters (e.g. "event_definition") can be passed in params.
+func (a *Alarm) ListBySeverity(ctx context.Context, severity string, params Params) (RecordSet, error) {
+	query := Params{"severity": severity}
+	query.Update(params, false)
+	return a.List(ctx, query)
+}
+
+// Acknowledge marks alarm as acknowledged.
+func (a *Alarm) Acknowledge(ctx context.Context, id int64) (Record, error) {
+	return a.actionRequest(ctx, http.MethodPatch, id, "acknowledge", nil, nil)
+}
+
+// ClearAll clears all alarms matching params (e.g. Params{"severity": AlarmSeverityMinor}).
+// Empty params clear all alarms of the cluster.
+func (a *Alarm) ClearAll(ctx context.Context, params Params) (Record, error) {
+	if err := checkVastResourceVersionCompat(ctx, a.VastResourceEntry); err != nil {
+		return nil, err
+	}
+	path := fmt.Sprintf("%s/clear_all", a.resourcePath)
+	return request[Record](ctx, a, http.MethodPatch, path, a.apiVersion, params, nil)
+}
+
+// ------------------------------------------------------
+
+type Event struct {
+	*VastResourceEntry
+}
+
+// eventTimeField is field of event used for time-range filtering
+const eventTimeField = "timestamp"
+
+// ListSince returns all events which happened at or after since.
+// Event log can be large so only selected fields can be requested (see WithFields).
+func (ev *Event) ListSince(ctx context.Context, since time.Time, fields ...string) (RecordSet, error) {
+	params := Params{eventTimeField + "__gte": since.UTC().Format(time.RFC3339)}
+	return ev.List(ctx, WithFields(params, fields...))
+}
+
+// ListBetween returns all events which happened in time range [from, to).
+// Event log can be large so only selected fields can be requested (see WithFields).
+func (ev *Event) ListBetween(ctx context.Context, from, to time.Time, fields ...string) (RecordSet, error) {
+	if !from.Before(to) {
+		return nil, fmt.Errorf("invalid time range: %s is not before %s", from, to)
+	}
+	params := Params{
+		eventTimeField + "__gte": from.UTC().Format(time.RFC3339),
+		eventTimeField + "__lt":  to.UTC().Format(time.RFC3339),
+	}
+	return ev.List(ctx, WithFields(params, fields...))
+}
+
+// ------------------------------------------------------
+
+type EventDefinition struct {
+	*VastResourceEntry
+}
+
+// SetEnabled enables or disables event definition.
+func (ed *EventDefinition) SetEnabled(ctx context.Context, id int64, enabled bool) (Record, error) {
+	return ed.Update(ctx, id, Params{"enabled": enabled})
+}
+
+// SetSeverity changes severity of alarms raised by event definition (e.g. AlarmSeverityMajor).
+func (ed *EventDefinition) SetSeverity(ctx context.Context, id int64, severity string) (Record, error) {
+	return ed.Update(ctx, id, Params{"severity": severity})
+}
+
+// ------------------------------------------------------
+
+type Cluster struct {
+	*VastResourceEntry
+}
+
+// GetState returns state of cluster (e.g. "ONLINE") along with related details returned by VMS.
+func (c *Cluster) GetState(ctx context.Context, id int64) (Record, error) {
+	return c.actionRequest(ctx, http.MethodGet, id, "state", nil, nil)
+}
+
+// SetSSLCertificate installs PEM encoded SSL certificate and private key used by VMS.
+func (c *Cluster) SetSSLCertificate(ctx context.Context, id int64, certPEM, keyPEM string) (Record, error) {
+	if strings.TrimSpace(certPEM) == "" || strings.TrimSpace(keyPEM) == "" {
+		return nil, errors.New("certificate and private key must not be empty")
+	}
+	body := Params{"ssl_certificate": certPEM, "ssl_keyfile": keyPEM}
+	return c.actionRequest(ctx, http.MethodPatch, id, "ssl_certificate", nil, body)
+}
+
+// ------------------------------------------------------
+
+type Snapshot struct {
+	*VastResourceEntry
+}
+
+// SnapshotPathConflictError is returned by EnsureSnapshot when snapshot with the same name exists on a different path.
+type SnapshotPathConflictError struct {
+	Name          string
+	ExistingPath  string
+	RequestedPath string
+}
+
+func (e *SnapshotPathConflictError) Error() string {
+	return fmt.Sprintf("snapshot %q already exists on path %q (requested path %q)", e.Name, e.ExistingPath, e.RequestedPath)
+}
+
+// EnsureSnapshot returns snapshot with given name and path in tenant or creates it if missing.
+// Optional expirationTime is sent in RFC3339 format. If snapshot with the same name exists on a different path
+// SnapshotPathConflictError is returned.
+func (s *Snapshot) EnsureSnapshot(ctx context.Context, name, path string, tenantId int, expirationTime *time.Time) (Record, error) {
+	ctx = withFeature(ctx, "ensure")
+	snapshot, err := s.Get(ctx, Params{"name": name, "tenant_id": tenantId})
+	if isNotFoundErr(err) {
+		body := Params{"name": name, "path": path, "tenant_id": tenantId}
+		if expirationTime != nil {
+			body["expiration_time"] = expirationTime.UTC().Format(time.RFC3339)
+		}
+		return s.Create(ctx, body)
+	} else if err != nil {
+		return nil, err
+	}
+	existingPath := fmt.Sprintf("%v", snapshot["path"])
+	if normalizePath(existingPath) != normalizePath(path) {
+		return nil, &SnapshotPathConflictError{Name: name, ExistingPath: existingPath, RequestedPath: path}
+	}
+	return snapshot, nil
+}
+
+// ListByPath returns all snapshots of given path (all pages of paginated response).
+func (s *Snapshot) ListByPath(ctx context.Context, path string) (RecordSet, error) {
+	return s.List(ctx, Params{"path": path})
+}
+
+// ------------------------------------------------------
+
+type BlockHost struct {
+	*VastResourceEntry
+}
+
+// EnsureBlockHostOptions controls EnsureBlockHost behavior when existing host doesn't match requested one.
+type EnsureBlockHostOptions struct {
+	StrictNqn bool // Return error if existing host has different NQN instead of updating it.
+}
+
+// EnsureBlockHost returns block host with given name in tenant or creates it if missing.
+// If existing host has different NQN (e.g. host was re-imaged) NQN is updated,
+// or error is returned when StrictNqn option is set.
+func (bh *BlockHost) EnsureBlockHost(ctx context.Context, name string, tenantId int, nqn string, opts ...EnsureBlockHostOptions) (Record, error) {
+	ctx = withFeature(ctx, "ensure")
+	params := Params{"name": name, "tenant_id": tenantId}
+	blockHost, err := bh.Get(ctx, params)
+	if isNotFoundErr(err) {
+		params.Update(Params{"nqn": nqn, "os_type": "LINUX", "connectivity_type": "tcp"}, false)
+		return bh.Create(ctx, params)
+	} else if err != nil {
+		return nil, err
+	}
+	currentNqn := fmt.Sprintf("%v", blockHost["nqn"])
+	if currentNqn == nqn {
+		return blockHost, nil
+	}
+	if firstOrDefault(opts).StrictNqn {
+		return nil, fmt.Errorf("block host %q in tenant %d has nqn %q, expected %q", name, tenantId, currentNqn, nqn)
+	}
+	id, err := blockHost.ID()
+	if err != nil {
+		return nil, err
+	}
+	return bh.Update(ctx, id, Params{"nqn": nqn})
+}
+
+// ------------------------------------------------------
+
+type Volume struct {
+	*VastResourceEntry
+}
+
+// volumeCheckedFields are fields validated by EnsureVolume for existing volume (if provided in extra params)
+var volumeCheckedFields = []string{"subsystem_name", "view_id"}
+
+// EnsureVolume returns volume with given name in tenant or creates it if missing.
+// Existing volume is validated against requested size and subsystem fields provided in extra params
+// (mismatch is reported as error, volume is never modified). If VMS creates volume asynchronously task is waited.
+func (v *Volume) EnsureVolume(ctx context.Context, name string, tenantId int, sizeBytes int64, extra Params) (Record, error) {
+	ctx = withFeature(ctx, "ensure")
+	volume, err := v.Get(ctx, Params{"name": name, "tenant_id": tenantId})
+	if isNotFoundErr(err) {
+		body := Params{}
+		body.Update(extra, false)
+		body.Update(Params{"name": name, "tenant_id": tenantId, "size": sizeBytes}, false)
+		return v.CreateAsync(ctx, body)
+	} else if err != nil {
+		return nil, err
+	}
+	var mismatches []string
+	if !valuesEqual(volume["size"], sizeBytes, false) {
+		mismatches = append(mismatches, fmt.Sprintf("size is %v, expected %d", volume["size"], sizeBytes))
+	}
+	for _, field := range volumeCheckedFields {
+		if expected, ok := extra[field]; ok && !valuesEqual(volume[field], expected, false) {
+			mismatches = append(mismatches, fmt.Sprintf("%s is %v, expected %v", field, volume[field], expected))
+		}
+	}
+	if len(mismatches) > 0 {
+		return nil, fmt.Errorf("volume %q in tenant %d doesn't match requested spec: %s", name, tenantId, strings.Join(mismatches, "; "))
+	}
+	return volume, nil
+}
+
+// Extend grows volume to newSize (in bytes). Shrinking is refused.
+// Returns updated volume. If VMS resizes volume asynchronously task is waited.
+func (v *Volume) Extend(ctx context.Context, id int64, newSize int64) (Record, error) {
+	volume, err := v.GetById(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	currentSize, err := volume.GetInt64("size")
+	if err != nil {
+		return nil, err
+	}
+	switch {
+	case newSize < currentSize:
+		return nil, fmt.Errorf("volume with id %d cannot be shrunk from %d to %d bytes", id, currentSize, newSize)
+	case newSize == currentSize:
+		return volume, nil
+	}
+	path := fmt.Sprintf("%s/%d", v.resourcePath, id)
+	updated, isTask, err := v.requestAndWaitTask(ctx, http.MethodPatch, path, Params{"size": newSize}, false, nil)
+	if err != nil || !isTask {
+		return updated, err
+	}
+	return v.GetById(ctx, id)
+}
+
+// ------------------------------------------------------
+
+type VTask struct {
+	*VastResourceEntry
+}
+
+// TaskTimeoutError is returned by WaitTask when task is still not completed after timeout or context cancellation.
+// Callers may keep waiting for the same task.
+type TaskTimeoutError struct {
+	TaskId      int64
+	Name        string
+	State       string
+	LastMessage string
+	Err         error // context error (context.DeadlineExceeded or context.Canceled)
+}
+
+func (e *TaskTimeoutError) Error() string {
+	return fmt.Sprintf("task %s with ID %d is not completed (state: %s, last message: %q): %v", e.Name, e.TaskId, e.State, e.LastMessage, e.Err)
+}
+
+func (e *TaskTimeoutError) Unwrap() error {
+	return e.Err
+}
+
+// TaskFailedError is returned by WaitTask when task finished unsuccessfully.
+type TaskFailedError struct {
+	TaskId      int64
+	Name        string
+	State       string
+	LastMessage string
+}
+
+func (e *TaskFailedError) Error() string {
+	return fmt.Sprintf("task %s with ID %d failed (state: %s): %s", e.Name, e.TaskId, e.State, e.LastMessage)
+}
+
+// describeTask extracts name, state and last message from task Record.
+func describeTask(task Record) (name, state, lastMsg string) {
+	if task == nil {
+		return "<unknown>", "<unknown>", ""
+	}
+	name = fmt.Sprintf("%v", task["name"])
+	state = strings.ToLower(fmt.Sprintf("%v", task["state"]))
+	if messages, err := task.GetSlice("messages"); err == nil && len(messages) > 0 {
+		lastMsg = fmt.Sprintf("%v", messages[len(messages)-1])
+	} else {
+		lastMsg = "no messages found"
+	}
+	return
+}
+
+// VTask states (see WaitTask)
+var (
+	taskCompletedStates = []string{"completed"}
+	taskFailedStates    = []string{"failed", "aborted", "cancelled"}
+)
+
+// WaitTask waits for the task to complete.
+// Optional WaitTaskOptions control polling interval, backoff and timeout.
+// Returns TaskFailedError if task failed and TaskTimeoutError if task is still running when time is over.
+func (t *VTask) WaitTask(ctx context.Context, taskId int64, opts ...WaitTaskOptions) (Record, error) {
+	ctx = withFeature(ctx, "wait")
+	task, err := t.WaitForState(ctx, taskId, "state", taskCompletedStates, taskFailedStates, firstOrDefault(opts))
+	if err == nil {
+		return task, nil
+	}
+	var stateErr *ResourceStateError
+	name, state, lastMsg := describeTask(task)
+	if errors.As(err, &stateErr) {
+		return nil, &TaskFailedError{TaskId: taskId, Name: name, State: state, LastMessage: lastMsg}
+	} else if isContextErr(err) {
+		return nil, &TaskTimeoutError{TaskId: taskId, Name: name, State: state, LastMessage: lastMsg, Err: err}
+	}
+	return nil, err
+}
+
+// taskTerminalStates are states after which task state doesn't change anymore.
+var taskTerminalStates = append(append([]string{}, taskCompletedStates...), taskFailedStates...)
+
+// Cancel aborts running task and waits until task reaches terminal state.
+// Returns final task Record. If task is already finished error is returned.
+func (t *VTask) Cancel(ctx context.Context, taskId int64) (Record, error) {
+	task, err := t.GetById(ctx, taskId)
+	if err != nil {
+		return nil, err
+	}
+	if name, state, _ := describeTask(task); containsFold(taskTerminalStates, state) {
+		return nil, fmt.Errorf("task %s with ID %d cannot be cancelled: it is already finished (state: %s)", name, taskId, state)
+	}
+	path := fmt.Sprintf("%s/%d/cancel", t.resourcePath, taskId)
+	if _, err = request[Record](ctx, t, http.MethodPost, path, t.apiVersion, nil, nil); err != nil {
+		return nil, err
+	}
+	return t.WaitForState(ctx, taskId, "state", taskTerminalStates, nil, PollOptions{})
+}
+
+// ListRunning returns all tasks which are currently running.
+func (t *VTask) ListRunning(ctx context.Context) (RecordSet, error) {
+	return t.List(ctx, Params{"state": "running"})
+}
+
+// WaitForAll waits for several tasks concurrently (see WaitTask).
+// Returns completed tasks in the same order as ids. Failed tasks are represented by nil Records
+// and their errors are aggregated into returned error.
+func (t *VTask) WaitForAll(ctx context.Context, ids []int64, opts ...WaitTaskOptions) (RecordSet, error) {
+	var wg sync.WaitGroup
+	tasks := make(RecordSet, len(ids))
+	errs := make([]error, len(ids))
+	for i, id := range ids {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			tasks[i], errs[i] = t.WaitTask(ctx, id, opts...)
+		}()
+	}
+	wg.Wait()
+	return tasks, errors.Join(errs...)
+}
+
+// ------------------------------------------------------
+
+type BlockHostMapping struct {
+	*VastResourceEntry
+}
+
+// defaultBulkChunkSize is max number of pairs sent in one bulk request by MapMany/UnMapMany
+const defaultBulkChunkSize = 100
+
+// HostVolumePair identifies mapping between block host and volume.
+type HostVolumePair struct {
+	HostId   int64 `json:"host_id"`
+	VolumeId int64 `json:"volume_id"`
+}
+
+// BulkMappingOptions controls MapMany/UnMapMany behavior.
+type BulkMappingOptions struct {
+	ChunkSize   int             // Max number of pairs per bulk request (one VTask per chunk). Default is 100.
+	WaitOptions WaitTaskOptions // Options for waiting each chunk task.
+}
+
+// BulkMappingResult summarizes result of MapMany/UnMapMany.
+type BulkMappingResult struct {
+	Succeeded []HostVolumePair // Pairs processed by successfully completed tasks.
+	Failed    []HostVolumePair // Pairs processed by failed tasks (or requests).
+	Skipped   []HostVolumePair // Pairs skipped because nothing had to be done (see EnsureMapMany).
+}
+
+func (bhm *BlockHostMapping) Map(ctx context.Context, hostId, volumeId int64) (Record, error) {
+	return bhm.bulk(withFeature(ctx, "bulk"), "pairs_to_add", []HostVolumePair{{hostId, volumeId}}, WaitTaskOptions{})
+}
+
+func (bhm *BlockHostMapping) UnMap(ctx context.Context, hostId, volumeId int64) (Record, error) {
+	return bhm.bulk(withFeature(ctx, "bulk"), "pairs_to_remove", []HostVolumePair{{hostId, volumeId}}, WaitTaskOptions{})
+}
+
+// MapMany maps many host-volume pairs. Pairs are sent in chunks (one bulk request and VTask per chunk).
+// Failure of one chunk doesn't stop processing of other chunks. Errors of all chunks are aggregated.
+func (bhm *BlockHostMapping) MapMany(ctx context.Context, pairs []HostVolumePair, opts ...BulkMappingOptions) (BulkMappingResult, error) {
+	return bhm.bulkMany(withFeature(ctx, "bulk"), "pairs_to_add", pairs, firstOrDefault(opts))
+}
+
+// UnMapMany unmaps many host-volume pairs. See MapMany for details.
+func (bhm *BlockHostMapping) UnMapMany(ctx context.Context, pairs []HostVolumePair, opts ...BulkMappingOptions) (BulkMappingResult, error) {
+	return bhm.bulkMany(withFeature(ctx, "bulk"), "pairs_to_remove", pairs, firstOrDefault(opts))
+}
+
+func (bhm *BlockHostMapping) EnsureMap(ctx context.Context, hostId, volumeId int64) (Record, error) {
+	ctx = withFeature(ctx, "ensure")
+	result, err := bhm.findMapping(ctx, hostId, volumeId)
+	if isNotFoundErr(err) {
+		return bhm.Map(ctx, hostId, volumeId)
+	}
+	return result, err
+}
+
+// EnsureUnmap removes mapping between host and volume if it exists.
+// Returns empty Record if mapping doesn't exist or completed UnMap task otherwise.
+func (bhm *BlockHostMapping) EnsureUnmap(ctx context.Context, hostId, volumeId int64) (Record, error) {
+	ctx = withFeature(ctx, "ensure")
+	_, err := bhm.findMapping(ctx, hostId, volumeId)
+	if isNotFoundErr(err) {
+		return Record{}, nil
+	} else if err != nil {
+		return nil, err
+	}
+	return bhm.UnMap(ctx, hostId, volumeId)
+}
+
+// findMapping returns mapping between host and volume. Returns NotFoundError if mapping doesn't exist.
+func (bhm *BlockHostMapping) findMapping(ctx context.Context, hostId, volumeId int64) (Record, error) {
+	return bhm.Get(ctx, Params{"volume__id": volumeId, "block_host__id": hostId})
+}
+
+// EnsureMapMany maps only those pairs which are not mapped yet (see MapMany).
+// Already existing mappings are reported as Skipped.
+func (bhm *BlockHostMapping) EnsureMapMany(ctx context.Context, pairs []HostVolumePair, opts ...BulkMappingOptions) (BulkMappingResult, error) {
+	ctx = withFeature(ctx, "ensure")
+	existing, err := bhm.existingPairs(ctx, pairs)
+	if err != nil {
+		return BulkMappingResult{}, err
+	}
+	var missing, skipped []HostVolumePair
+	for _, pair := range pairs {
+		if _, ok := existing[pair]; ok {
+			skipped = append(skipped, pair)
+		} else {
+			missing = append(missing, pair)
+		}
+	}
+	result, err := bhm.MapMany(ctx, missing, opts...)
+	result.Skipped = skipped
+	return result, err
+}
+
+// existingPairs returns subset of pairs which are already mapped.
+// Mappings are queried once per distinct host.
+func (bhm *BlockHostMapping) existingPairs(ctx context.Context, pairs []HostVolumePair) (map[HostVolumePair]struct{}, error) {
+	existing := make(map[HostVolumePair]struct{})
+	queried := make(map[int64]struct{})
+	for _, pair := range pairs {
+		if _, ok := queried[pair.HostId]; ok {
+			continue
+		}
+		queried[pair.HostId] = struct{}{}
+		mappings, err := bhm.List(ctx, Params{"block_host__id": pair.HostId})
+		if err != nil {
+			return nil, err
+		}
+		for _, mapping := range mappings {
+			if volumeId, err := nestedId(mapping, "volume"); err == nil {
+				existing[HostVolumePair{HostId: pair.HostId, VolumeId: volumeId}] = struct{}{}
+			}
+		}
+	}
+	return existing, nil
+}
+
+// bulk sends one bulk request for pairs and waits for resulting VTask.
+func (bhm *BlockHostMapping) bulk(ctx context.Context, key string, pairs []HostVolumePair, opts WaitTaskOptions) (Record, error) {
+	body := Params{key: pairs}
+	path := fmt.Sprintf("%s/bulk", bhm.resourcePath)
+	// Bulk endpoint always responds with VTask
+	task, _, err := bhm.requestAndWaitTask(ctx, http.MethodPatch, path, body, true, []WaitTaskOptions{opts})
+	return task, err
+}
+
+// bulkMany splits pairs into chunks and sends one bulk request per chunk.
+func (bhm *BlockHostMapping) bulkMany(ctx context.Context, key string, pairs []HostVolumePair, opts BulkMappingOptions) (BulkMappingResult, error) {
+	var (
+		result BulkMappingResult
+		errs   []error
+	)
+	chunkSize := opts.ChunkSize
+	if chunkSize <= 0 {
+		chunkSize = defaultBulkChunkSize
+	}
+	for start := 0; start < len(pairs); start += chunkSize {
+		chunk := pairs[start:min(start+chunkSize, len(pairs))]
+		if _, err := bhm.bulk(ctx, key, chunk, opts.WaitOptions); err != nil {
+			result.Failed = append(result.Failed, chunk...)
+			errs = append(errs, err)
+			if isContextErr(ctx.Err()) {
+				// No reason to continue.
+				result.Failed = append(result.Failed, pairs[start+len(chunk):]...)
+				break
+			}
+			continue
+		}
+		result.Succeeded = append(result.Succeeded, chunk...)
+	}
+	return result, errors.Join(errs...)
 }
@@ -0,0 +1,694 @@
+package vast_client
+
+import (
+	"context"
+	"math"
+	"net/http"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestManagerResetPassword(t *testing.T) {
+	srv := newTestServer(t, recordsHandler())
+	rest := newTestRest(t, srv.config())
+
+	_, err := rest.Managers.ResetPassword(context.Background(), 4, "")
+	require.Error(t, err)
+	assert.Empty(t, srv.Requests())
+
+	_, err = rest.Managers.ResetPassword(context.Background(), 4, "n3w-pass")
+	require.NoError(t, err)
+	requests := srv.Requests()
+	assert.Equal(t, []string{"PATCH managers/4"}, methodsAndPaths(requests))
+	assert.JSONEq(t, `{"password": "n3w-pass"}`, string(requests[0].Body))
+}
+
+func TestApiTokenCreateKeepsSecret(t *testing.T) {
+	const secret = "one-time-secret"
+	srv := newTestServer(t, func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == http.MethodPost {
+			writeTestJSON(w, http.StatusCreated, map[string]any{"id": 3, "name": "ci", "token": secret})
+			return
+		}
+		recordsHandler()(w, r)
+	})
+	config := srv.config()
+	var intercepted Renderable
+	config.AfterRequestFnV2 = func(ctx context.Context, info ResponseInfo, response Renderable) (Renderable, error) {
+		intercepted = response
+		return response, nil
+	}
+	rest := newTestRest(t, config)
+
+	token, record, err := rest.ApiTokens.CreateToken(context.Background(), Params{"name": "ci", "owner": "admin"})
+
+	require.NoError(t, err)
+	assert.Equal(t, secret, token)
+	assert.Equal(t, secret, record["token"], "secret is kept in record")
+	assert.Equal(t, "ApiToken", record[resourceTypeKey])
+	assert.Equal(t, secret, intercepted.(Record)["token"], "secret reaches interceptors")
+	assert.NotContains(t, record.Render(), secret, "secret is redacted when rendered")
+}
+
+func TestApiTokenCreateWithoutSecret(t *testing.T) {
+	srv := newTestServer(t, recordsHandler())
+	rest := newTestRest(t, srv.config())
+
+	_, record, err := rest.ApiTokens.CreateToken(context.Background(), Params{"name": "ci"})
+
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "no secret")
+	assert.NotNil(t, record, "created record is returned so token can be revoked")
+}
+
+func TestApiTokenRevoke(t *testing.T) {
+	srv := newTestServer(t, versionedRecordsHandler("5.2.0"))
+	rest := newTestRest(t, srv.config())
+
+	_, err := rest.ApiTokens.Revoke(context.Background(), 3)
+
+	require.NoError(t, err)
+	assert.Equal(t, []string{"GET versions", "PATCH apitokens/3/revoke"}, methodsAndPaths(srv.Requests()))
+}
+
+func TestFolderDeleteWithDataWaitsTask(t *testing.T) {
+	var polls int
+	srv := newTestServer(t, func(w http.ResponseWriter, r *http.Request) {
+		switch path := resourceFromPath(r.URL.Path); {
+		case r.Method == http.MethodDelete && path == "folders/delete_folder":
+			writeTestJSON(w, http.StatusOK, map[string]any{"async_task": map[string]any{"id": 77, "name": "DeleteFolderTask", "state": "running"}})
+		case r.Method == http.MethodGet && path == "vtasks/77":
+			polls++
+			state := "running"
+			if polls == 3 {
+				state = "completed"
+			}
+			writeTestJSON(w, http.StatusOK, map[string]any{"id": 77, "name": "DeleteFolderTask", "state": state})
+		default:
+			http.NotFound(w, r)
+		}
+	})
+	rest := newTestRest(t, srv.config())
+
+	task, err := rest.Folders.DeleteWithData(context.Background(), "/data/old dir", WaitTaskOptions{Interval: 5 * time.Millisecond})
+
+	require.NoError(t, err)
+	assert.Equal(t, "completed", task["state"])
+	requests := srv.Requests()
+	assert.Equal(t, []string{"DELETE folders/delete_folder", "GET vtasks/77", "GET vtasks/77", "GET vtasks/77"}, methodsAndPaths(requests))
+	assert.JSONEq(t, `{"path": "/data/old dir"}`, string(requests[0].Body))
+}
+
+func TestFolderRequests(t *testing.T) {
+	tests := []struct {
+		name      string
+		call      func(ctx context.Context, f *Folder) error
+		wantCall  string
+		wantQuery string
+		wantBody  string
+	}{
+		{
+			name: "stats of path with spaces and unicode",
+			call: func(ctx context.Context, f *Folder) error {
+				_, err := f.Stats(ctx, "/data/my dir/ünïcødé&x=1")
+				return err
+			},
+			wantCall:  "GET folders/stats",
+			wantQuery: "/data/my dir/ünïcødé&x=1",
+		},
+		{
+			name: "create with owner",
+			call: func(ctx context.Context, f *Folder) error {
+				_, err := f.CreateFolder(ctx, "/data/new", Params{"user": "nobody", "group": "users"})
+				return err
+			},
+			wantCall: "POST folders/create_folder",
+			wantBody: `{"path": "/data/new", "user": "nobody", "group": "users"}`,
+		},
+		{
+			name: "create without owner",
+			call: func(ctx context.Context, f *Folder) error {
+				_, err := f.CreateFolder(ctx, "/data/new", nil)
+				return err
+			},
+			wantCall: "POST folders/create_folder",
+			wantBody: `{"path": "/data/new"}`,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			srv := newTestServer(t, func(w http.ResponseWriter, r *http.Request) {
+				writeTestJSON(w, http.StatusOK, map[string]any{"path": "/data"})
+			})
+			rest := newTestRest(t, srv.config())
+
+			require.NoError(t, tt.call(context.Background(), rest.Folders))
+
+			requests := srv.Requests()
+			require.Len(t, requests, 1)
+			assert.Equal(t, tt.wantCall, methodsAndPaths(requests)[0])
+			if tt.wantQuery != "" {
+				assert.Equal(t, []string{tt.wantQuery}, requests[0].Query["path"])
+				assert.Len(t, requests[0].Query, 1)
+			}
+			if tt.wantBody != "" {
+				assert.JSONEq(t, tt.wantBody, string(requests[0].Body))
+			}
+		})
+	}
+}
+
+func TestMonitorQuery(t *testing.T) {
+	captured, err := os.ReadFile(filepath.Join("testdata", "metrics", "ad_hoc_query.json"))
+	require.NoError(t, err)
+	srv := newTestServer(t, func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", ApplicationJson)
+		_, _ = w.Write(captured)
+	})
+	rest := newTestRest(t, srv.config())
+	iops, bw := "ProtoMetrics,proto_name=ProtoCommon,iops", "ProtoMetrics,proto_name=ProtoCommon,bw"
+
+	result, err := rest.Monitors.Query(context.Background(), MetricsQuery{
+		ObjectType:  "cnode",
+		ObjectIDs:   []int64{1, 2},
+		PropList:    []string{iops, bw, "Capacity,drr"},
+		TimeFrame:   "5m",
+		Granularity: "seconds",
+	})
+
+	require.NoError(t, err)
+	requests := srv.Requests()
+	require.Len(t, requests, 1)
+	assert.Equal(t, "GET monitors/ad_hoc_query", methodsAndPaths(requests)[0])
+	query := requests[0].Query
+	assert.Equal(t, "cnode", query.Get("object_type"))
+	assert.Equal(t, "1,2", query.Get("object_ids"))
+	assert.Equal(t, "5m", query.Get("time_frame"))
+	assert.Equal(t, "seconds", query.Get("granularity"))
+	assert.Equal(t, []string{iops, bw, "Capacity,drr"}, query["prop_list"])
+
+	assert.Equal(t, []string{iops, bw, "Capacity,drr"}, result.Columns)
+	require.Len(t, result.Rows, 4)
+	assert.Equal(t, time.Date(2024, 5, 2, 14, 10, 10, 0, time.UTC), result.Rows[2].Timestamp)
+	assert.Equal(t, int64(2), result.Rows[3].ObjectID)
+	assert.Equal(t, []float64{15234, 14870, 16011, 15002}, result.Column(iops))
+	assert.Equal(t, 812736512.5, result.Column(bw)[0])
+	assert.True(t, math.IsNaN(result.Column("Capacity,drr")[2]), "missing value is NaN")
+	assert.Nil(t, result.Column("unknown"))
+}
+
+func TestMetricsQueryParams(t *testing.T) {
+	from := time.Date(2024, 5, 2, 16, 0, 0, 0, time.FixedZone("CEST", 2*60*60))
+	tests := []struct {
+		name    string
+		query   MetricsQuery
+		want    Params
+		wantErr string
+	}{
+		{name: "missing object type", query: MetricsQuery{PropList: []string{"iops"}}, wantErr: "ObjectType"},
+		{name: "missing metrics", query: MetricsQuery{ObjectType: "view"}, wantErr: "PropList"},
+		{
+			name:  "absolute time range wins over time frame",
+			query: MetricsQuery{ObjectType: "view", PropList: []string{"iops"}, TimeFrame: "1h", From: from, To: from.Add(time.Hour)},
+			want: Params{
+				"object_type": "view", "prop_list": []string{"iops"},
+				"from_time": "2024-05-02T14:00:00Z", "to_time": "2024-05-02T15:00:00Z",
+			},
+		},
+		{
+			name:  "all objects",
+			query: MetricsQuery{ObjectType: "cluster", PropList: []string{"iops"}},
+			want:  Params{"object_type": "cluster", "prop_list": []string{"iops"}},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			params, err := tt.query.params()
+			if tt.wantErr != "" {
+				require.Error(t, err)
+				assert.Contains(t, err.Error(), tt.wantErr)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, params)
+		})
+	}
+}
+
+func TestDecodeMetricsMalformed(t *testing.T) {
+	tests := []struct {
+		name     string
+		response Record
+	}{
+		{name: "no prop_list", response: Record{"data": []any{}}},
+		{name: "no data", response: Record{"prop_list": []any{"iops"}}},
+		{name: "row width mismatch", response: Record{"prop_list": []any{"timestamp", "iops"}, "data": []any{[]any{"2024-05-02T14:10:00Z"}}}},
+		{name: "invalid timestamp", response: Record{"prop_list": []any{"timestamp", "iops"}, "data": []any{[]any{"yesterday", 1.0}}}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := decodeMetrics(tt.response)
+			assert.Error(t, err)
+		})
+	}
+}
+
+func TestProtectionPolicyCreateWithFrames(t *testing.T) {
+	srv := newTestServer(t, recordsHandler())
+	rest := newTestRest(t, srv.config())
+	frames := []ProtectionPolicyFrame{
+		{Every: 30 * time.Minute, KeepLocal: 2 * time.Hour},
+		{
+			Every:      24 * time.Hour,
+			StartAt:    time.Date(2024, 5, 2, 3, 30, 0, 0, time.FixedZone("CEST", 2*60*60)),
+			KeepLocal:  7 * 24 * time.Hour,
+			KeepRemote: 30 * 24 * time.Hour,
+		},
+	}
+
+	_, err := rest.ProtectionPolicies.CreateWithFrames(context.Background(), "hourly", "NATIVE_REPLICATION", frames, Params{"prefix": "pp", "target_object_id": 2})
+
+	require.NoError(t, err)
+	requests := srv.Requests()
+	require.Equal(t, []string{"POST protectionpolicies"}, methodsAndPaths(requests))
+	assert.JSONEq(t, `{
+		"name": "hourly", "clone_type": "NATIVE_REPLICATION", "prefix": "pp", "target_object_id": 2,
+		"frames": [
+			{"every": "30m", "keep-local": "2h"},
+			{"every": "1D", "start-at": "2024-05-02 01:30:00", "keep-local": "1W", "keep-remote": "30D"}
+		]
+	}`, string(requests[0].Body))
+}
+
+func TestProtectionPolicyFrameValidation(t *testing.T) {
+	tests := []struct {
+		name   string
+		frames []ProtectionPolicyFrame
+		want   []string
+	}{
+		{name: "no frames", want: []string{"at least one frame is required"}},
+		{
+			name:   "keep shorter than every",
+			frames: []ProtectionPolicyFrame{{Every: 24 * time.Hour, KeepLocal: time.Hour, KeepRemote: 12 * time.Hour}},
+			want: []string{
+				"frame #0: keep-local (1h0m0s) is shorter than every (24h0m0s)",
+				"frame #0: keep-remote (12h0m0s) is shorter than every (24h0m0s)",
+			},
+		},
+		{
+			name:   "nothing kept",
+			frames: []ProtectionPolicyFrame{{Every: time.Hour}},
+			want:   []string{"frame #0: at least one of keep-local and keep-remote must be set"},
+		},
+		{
+			name: "not whole minutes and missing every",
+			frames: []ProtectionPolicyFrame{
+				{Every: time.Hour, KeepLocal: 2 * time.Hour},
+				{KeepLocal: 90 * time.Second},
+			},
+			want: []string{
+				"frame #1: every must be positive",
+				"frame #1: keep-local must be non-negative whole number of minutes, got 1m30s",
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			srv := newTestServer(t, recordsHandler())
+			rest := newTestRest(t, srv.config())
+
+			_, err := rest.ProtectionPolicies.CreateWithFrames(context.Background(), "p", "LOCAL", tt.frames, nil)
+
+			var validationErr *ValidationError
+			require.ErrorAs(t, err, &validationErr)
+			assert.Equal(t, []string{"frames"}, validationErr.Fields)
+			assert.Equal(t, tt.want, validationErr.Problems)
+			assert.Empty(t, srv.Requests())
+		})
+	}
+}
+
+func TestProtectionPolicyModifyFrames(t *testing.T) {
+	policy := map[string]any{
+		"id": 5, "name": "p",
+		"frames": []map[string]any{
+			{"every": "1h", "keep-local": "1D"},
+			{"every": "1D", "start-at": "2024-05-02 01:30:00", "keep-local": "2W", "keep-remote": "1Y"},
+		},
+	}
+	tests := []struct {
+		name       string
+		modify     func(ctx context.Context, p *ProtectionPolicy) (Record, error)
+		wantFrames string
+		wantErr    string
+	}{
+		{
+			name: "add frame",
+			modify: func(ctx context.Context, p *ProtectionPolicy) (Record, error) {
+				return p.AddFrame(ctx, 5, ProtectionPolicyFrame{Every: 7 * 24 * time.Hour, KeepRemote: 4 * 7 * 24 * time.Hour})
+			},
+			wantFrames: `[
+				{"every": "1h", "keep-local": "1D"},
+				{"every": "1D", "start-at": "2024-05-02 01:30:00", "keep-local": "2W", "keep-remote": "365D"},
+				{"every": "1W", "keep-remote": "4W"}
+			]`,
+		},
+		{
+			name: "remove frame",
+			modify: func(ctx context.Context, p *ProtectionPolicy) (Record, error) {
+				return p.RemoveFrame(ctx, 5, time.Hour)
+			},
+			wantFrames: `[{"every": "1D", "start-at": "2024-05-02 01:30:00", "keep-local": "2W", "keep-remote": "365D"}]`,
+		},
+		{
+			name: "remove missing frame",
+			modify: func(ctx context.Context, p *ProtectionPolicy) (Record, error) {
+				return p.RemoveFrame(ctx, 5, time.Minute)
+			},
+			wantErr: "has no frame every 1m",
+		},
+		{
+			name: "add invalid frame",
+			modify: func(ctx context.Context, p *ProtectionPolicy) (Record, error) {
+				return p.AddFrame(ctx, 5, ProtectionPolicyFrame{Every: time.Hour, KeepLocal: time.Minute})
+			},
+			wantErr: "frame #2: keep-local (1m0s) is shorter than every (1h0m0s)",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			srv := newTestServer(t, func(w http.ResponseWriter, r *http.Request) {
+				writeTestJSON(w, http.StatusOK, policy)
+			})
+			rest := newTestRest(t, srv.config())
+
+			_, err := tt.modify(context.Background(), rest.ProtectionPolicies)
+
+			requests := srv.Requests()
+			if tt.wantErr != "" {
+				require.Error(t, err)
+				assert.Contains(t, err.Error(), tt.wantErr)
+				assert.Equal(t, []string{"GET protectionpolicies/5"}, methodsAndPaths(requests))
+				return
+			}
+			require.NoError(t, err)
+			require.Equal(t, []string{"GET protectionpolicies/5", "PATCH protectionpolicies/5"}, methodsAndPaths(requests))
+			assert.JSONEq(t, `{"frames": `+tt.wantFrames+`}`, string(requests[1].Body))
+		})
+	}
+}
+
+func TestProtectionPolicyRemoveLastFrame(t *testing.T) {
+	srv := newTestServer(t, func(w http.ResponseWriter, r *http.Request) {
+		writeTestJSON(w, http.StatusOK, map[string]any{"id": 5, "frames": []map[string]any{{"every": "1h", "keep-local": "1D"}}})
+	})
+	rest := newTestRest(t, srv.config())
+
+	_, err := rest.ProtectionPolicies.RemoveFrame(context.Background(), 5, time.Hour)
+
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "last frame")
+}
+
+func TestFramePeriod(t *testing.T) {
+	tests := []struct {
+		raw    string
+		period time.Duration
+		format string
+	}{
+		{raw: "30m", period: 30 * time.Minute, format: "30m"},
+		{raw: "90m", period: 90 * time.Minute, format: "90m"},
+		{raw: "2h", period: 2 * time.Hour, format: "2h"},
+		{raw: "2D", period: 48 * time.Hour, format: "2D"},
+		{raw: "3W", period: 21 * 24 * time.Hour, format: "3W"},
+		{raw: "1M", period: 30 * 24 * time.Hour, format: "30D"},
+		{raw: "1Y", period: 365 * 24 * time.Hour, format: "365D"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.raw, func(t *testing.T) {
+			period, err := parseFramePeriod(tt.raw)
+			require.NoError(t, err)
+			assert.Equal(t, tt.period, period)
+			assert.Equal(t, tt.format, formatFramePeriod(period))
+		})
+	}
+	for _, raw := range []string{"", "1", "1x", "abcD"} {
+		_, err := parseFramePeriod(raw)
+		assert.Error(t, err, raw)
+	}
+}
+
+// sequenceHandler responds to GET requests with next record of sequence (last record is repeated when sequence is over).
+func sequenceHandler(records ...map[string]any) http.HandlerFunc {
+	var (
+		mu sync.Mutex
+		n  int
+	)
+	return func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		record := records[min(n, len(records)-1)]
+		n++
+		mu.Unlock()
+		writeTestJSON(w, http.StatusOK, record)
+	}
+}
+
+func TestGlobalSnapshotStreamWaitUntilFinished(t *testing.T) {
+	tests := []struct {
+		name         string
+		statuses     []map[string]any
+		timeout      time.Duration
+		wantProgress []float64
+		wantStateErr string
+		wantTimeout  bool
+	}{
+		{
+			name: "finished",
+			statuses: []map[string]any{
+				{"id": 4, "status": "running", "progress": 0},
+				{"id": 4, "status": "running", "progress": "42.5%"},
+				{"id": 4, "status": "running", "progress": "42.5%"},
+				{"id": 4, "status": "Finished", "progress": 100},
+			},
+			wantProgress: []float64{0, 42.5, 100},
+		},
+		{
+			name: "stopped",
+			statuses: []map[string]any{
+				{"id": 4, "status": "running", "progress": 10},
+				{"id": 4, "status": "stopped", "progress": 10},
+			},
+			wantProgress: []float64{10},
+			wantStateErr: "stopped",
+		},
+		{
+			name:         "not finished in time",
+			statuses:     []map[string]any{{"id": 4, "status": "running", "progress": "7%"}},
+			timeout:      50 * time.Millisecond,
+			wantProgress: []float64{7},
+			wantTimeout:  true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			srv := newTestServer(t, sequenceHandler(tt.statuses...))
+			rest := newTestRest(t, srv.config())
+			var progress []float64
+
+			record, err := rest.GlobalSnapshotStreams.WaitUntilFinished(context.Background(), 4, func(percent float64) {
+				progress = append(progress, percent)
+			}, PollOptions{Interval: 5 * time.Millisecond, Timeout: tt.timeout})
+
+			assert.Equal(t, tt.wantProgress, progress)
+			require.NotNil(t, record, "last fetched record is returned")
+			for _, path := range methodsAndPaths(srv.Requests()) {
+				assert.Equal(t, "GET globalsnapstreams/4", path)
+			}
+			switch {
+			case tt.wantStateErr != "":
+				var stateErr *ResourceStateError
+				require.ErrorAs(t, err, &stateErr)
+				assert.Equal(t, "status", stateErr.Field)
+				assert.Equal(t, tt.wantStateErr, stateErr.State)
+			case tt.wantTimeout:
+				require.ErrorIs(t, err, context.DeadlineExceeded)
+				assert.Contains(t, err.Error(), "progress: 7.0%")
+				assert.Contains(t, err.Error(), `last status: "running"`)
+			default:
+				require.NoError(t, err)
+				assert.Len(t, srv.Requests(), len(tt.statuses))
+			}
+		})
+	}
+}
+
+func TestGlobalSnapshotStreamWaitWithoutCallback(t *testing.T) {
+	srv := newTestServer(t, sequenceHandler(map[string]any{"id": 4, "status": "completed", "progress": 100}))
+	rest := newTestRest(t, srv.config())
+
+	_, err := rest.GlobalSnapshotStreams.WaitUntilFinished(context.Background(), 4, nil)
+
+	require.NoError(t, err)
+}
+
+func TestGlobalSnapshotStreamCreateAndStop(t *testing.T) {
+	srv := newTestServer(t, recordsHandler())
+	rest := newTestRest(t, srv.config())
+	ctx := context.Background()
+
+	_, err := rest.GlobalSnapshotStreams.CreateFromSnapshot(ctx, 9, "/clone", Params{"loanee_tenant_id": 2})
+	require.NoError(t, err)
+	_, err = rest.GlobalSnapshotStreams.Stop(ctx, 4)
+	require.NoError(t, err)
+
+	requests := srv.Requests()
+	assert.Equal(t, []string{"POST globalsnapstreams", "PATCH globalsnapstreams/4/stop"}, methodsAndPaths(requests))
+	assert.JSONEq(t, `{"name": "snapshot-9-stream", "source_snapshot_id": 9, "loanee_root_path": "/clone", "loanee_tenant_id": 2}`, string(requests[0].Body))
+}
+
+func TestNormalizePermissions(t *testing.T) {
+	tests := []struct {
+		name         string
+		permissions  []string
+		want         []string
+		wantProblems []string
+	}{
+		{
+			name:        "lowercased, trimmed, deduplicated and sorted",
+			permissions: []string{"View_Monitoring", " create_security", "view_monitoring", "", "EDIT_LOGICAL"},
+			want:        []string{"create_security", "edit_logical", "view_monitoring"},
+		},
+		{
+			name:         "unknown action and realm",
+			permissions:  []string{"read_security", "view_everything", "viewmonitoring"},
+			want:         []string{"read_security", "view_everything", "viewmonitoring"},
+			wantProblems: []string{`unknown permission "read_security"`, `unknown permission "view_everything"`, `unknown permission "viewmonitoring"`},
+		},
+		{name: "empty", permissions: []string{" "}, want: []string{}, wantProblems: []string{"at least one permission is required"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			normalized, problems := normalizePermissions(tt.permissions)
+
+			assert.Equal(t, tt.want, normalized)
+			require.Len(t, problems, len(tt.wantProblems))
+			for i, want := range tt.wantProblems {
+				assert.Contains(t, problems[i], want)
+			}
+		})
+	}
+}
+
+func TestEnsureRole(t *testing.T) {
+	tests := []struct {
+		name      string
+		existing  []map[string]any
+		wantCalls []string
+		wantBody  string
+	}{
+		{
+			name:      "create",
+			wantCalls: []string{"GET roles", "POST roles"},
+			wantBody:  `{"name": "ops", "tenant_id": 3, "permissions_list": ["create_security", "view_monitoring"]}`,
+		},
+		{
+			name:      "same permissions in different order",
+			existing:  []map[string]any{{"id": 7, "name": "ops", "tenant_id": 3, "permissions_list": []string{"view_monitoring", "create_security"}}},
+			wantCalls: []string{"GET roles"},
+		},
+		{
+			name:      "drifted permissions",
+			existing:  []map[string]any{{"id": 7, "name": "ops", "tenant_id": 3, "permissions_list": []string{"view_monitoring"}}},
+			wantCalls: []string{"GET roles", "PATCH roles/7"},
+			wantBody:  `{"permissions_list": ["create_security", "view_monitoring"]}`,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			srv := newTestServer(t, recordsHandler(tt.existing...))
+			rest := newTestRest(t, srv.config())
+
+			_, err := rest.Roles.EnsureRole(context.Background(), "ops", []string{"VIEW_monitoring", "create_security", "view_monitoring"}, 3)
+
+			require.NoError(t, err)
+			requests := srv.Requests()
+			assert.Equal(t, tt.wantCalls, methodsAndPaths(requests))
+			assert.Equal(t, "ops", requests[0].Query.Get("name"))
+			assert.Equal(t, "3", requests[0].Query.Get("tenant_id"))
+			if tt.wantBody != "" {
+				assert.JSONEq(t, tt.wantBody, string(requests[len(requests)-1].Body))
+			}
+		})
+	}
+}
+
+func TestEnsureRoleValidation(t *testing.T) {
+	srv := newTestServer(t, recordsHandler())
+	rest := newTestRest(t, srv.config())
+
+	_, err := rest.Roles.EnsureRole(context.Background(), "ops", []string{"view_monitoring", "delete_all"}, 3)
+
+	var validationErr *ValidationError
+	require.ErrorAs(t, err, &validationErr)
+	assert.Equal(t, []string{"permissions_list"}, validationErr.Fields)
+	assert.Empty(t, srv.Requests())
+}
+
+func TestEnsureRealm(t *testing.T) {
+	tests := []struct {
+		name        string
+		existing    []map[string]any
+		objectTypes []string
+		wantCalls   []string
+		wantBody    string
+		wantErr     bool
+	}{
+		{
+			name:        "create",
+			objectTypes: []string{"view", "quota", "view"},
+			wantCalls:   []string{"GET realms", "POST realms"},
+			wantBody:    `{"name": "storage", "object_types": ["quota", "view"]}`,
+		},
+		{
+			name:        "unchanged",
+			existing:    []map[string]any{{"id": 2, "name": "storage", "object_types": []string{"view", "quota"}}},
+			objectTypes: []string{"quota", "view"},
+			wantCalls:   []string{"GET realms"},
+		},
+		{
+			name:        "drifted",
+			existing:    []map[string]any{{"id": 2, "name": "storage", "object_types": []string{"view"}}},
+			objectTypes: []string{"quota", "view"},
+			wantCalls:   []string{"GET realms", "PATCH realms/2"},
+			wantBody:    `{"object_types": ["quota", "view"]}`,
+		},
+		{name: "no object types", objectTypes: []string{" "}, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			srv := newTestServer(t, recordsHandler(tt.existing...))
+			rest := newTestRest(t, srv.config())
+
+			_, err := rest.Realms.EnsureRealm(context.Background(), "storage", tt.objectTypes)
+
+			requests := srv.Requests()
+			if tt.wantErr {
+				var validationErr *ValidationError
+				require.ErrorAs(t, err, &validationErr)
+				assert.Empty(t, requests)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.wantCalls, methodsAndPaths(requests))
+			if tt.wantBody != "" {
+				assert.JSONEq(t, tt.wantBody, string(requests[len(requests)-1].Body))
+			}
+		})
+	}
+}
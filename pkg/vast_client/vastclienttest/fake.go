@@ -0,0 +1,391 @@
+// Package vastclienttest provides in-memory fake of VMS API for unit tests of code that uses vast_client.VMSRest.
+//
+// Example:
+//
+//	rest, fake := vastclienttest.NewRest()
+//	fake.Seed("views", client.Record{"name": "existing", "path": "/existing"})
+//
+//	view, err := rest.Views.Ensure(ctx, "myview", client.Params{"path": "/myview", "policy_id": 1})
+//	// view["id"] is assigned by fake, fake.Records("views") contains both views
+package vastclienttest
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+
+	client "github.com/600apples/go-vast-client/pkg/vast_client"
+)
+
+// DefaultClusterVersion is version reported by fake "versions" resource unless changed with SetClusterVersion.
+const DefaultClusterVersion = "5.3.0"
+
+// reservedParams are query params which are not treated as filters.
+var reservedParams = map[string]struct{}{
+	"page":      {},
+	"page_size": {},
+	"fields":    {},
+	"ordering":  {},
+}
+
+var apiVersionSegment = regexp.MustCompile(`^(v\d+|latest)$`)
+
+// Call describes request received by FakeSession.
+type Call struct {
+	Verb  string
+	Path  string // Resource path without api prefix (e.g. "views/1")
+	Query url.Values
+	Body  client.Params
+	// Header of HTTP request. Set only for requests received by Server (requests made through FakeSession have no headers).
+	Header http.Header
+}
+
+// FakeSession is client.RESTSession backed by in-memory map of resources.
+//
+// Supported operations:
+//   - GET <resource> lists records filtered by query params (exact match of string representation,
+//     records without filtered key don't match). "fields" param limits returned keys.
+//     If "page_size" param is set response is paginated envelope ({"count", "next", "previous", "results"})
+//     with page selected by "page" param (1 by default).
+//   - GET <resource>/<id> returns record or 404.
+//   - POST <resource> stores body with auto-assigned id and returns it.
+//   - PATCH <resource>/<id> merges body into record, PUT <resource>/<id> replaces it. 404 if record is missing.
+//   - DELETE <resource>/<id> removes record. 404 if record is missing.
+type FakeSession struct {
+	mu     sync.Mutex // Locker of client.RESTSession
+	dataMu sync.Mutex
+	config *client.VMSConfig
+	data   map[string]map[int64]client.Record
+	nextId map[string]int64
+	calls  []Call
+}
+
+// NewFakeSession creates FakeSession. Nil config is replaced with minimal config suitable for tests.
+func NewFakeSession(config *client.VMSConfig) *FakeSession {
+	if config == nil {
+		config = &client.VMSConfig{Host: "vms.fake", Port: 443, ApiVersion: "v5"}
+	}
+	f := &FakeSession{
+		config: config,
+		data:   make(map[string]map[int64]client.Record),
+		nextId: make(map[string]int64),
+	}
+	f.SetClusterVersion(DefaultClusterVersion)
+	return f
+}
+
+// NewRest creates VMSRest backed by new FakeSession.
+func NewRest() (*client.VMSRest, *FakeSession) {
+	fake := NewFakeSession(nil)
+	return client.NewVMSRestWithSession(fake), fake
+}
+
+// SetClusterVersion replaces content of "versions" resource with single successful version.
+func (f *FakeSession) SetClusterVersion(version string) {
+	f.dataMu.Lock()
+	defer f.dataMu.Unlock()
+	f.data["versions"] = map[int64]client.Record{1: {"id": float64(1), "sys_version": version, "status": "success"}}
+	f.nextId["versions"] = 1
+}
+
+// Seed adds records to resource (e.g. "views" or "users/1/access_keys"). Records without id get auto-assigned one.
+// Returns stored copies of records.
+func (f *FakeSession) Seed(resource string, records ...client.Record) client.RecordSet {
+	f.dataMu.Lock()
+	defer f.dataMu.Unlock()
+	resource = strings.Trim(resource, "/")
+	result := client.RecordSet{}
+	for _, record := range records {
+		result = append(result, f.store(resource, normalize(record)))
+	}
+	return result
+}
+
+// Clear removes all records of resource and resets its id sequence.
+func (f *FakeSession) Clear(resource string) {
+	f.dataMu.Lock()
+	defer f.dataMu.Unlock()
+	resource = strings.Trim(resource, "/")
+	delete(f.data, resource)
+	delete(f.nextId, resource)
+}
+
+// Records returns copies of all records of resource sorted by id.
+func (f *FakeSession) Records(resource string) client.RecordSet {
+	f.dataMu.Lock()
+	defer f.dataMu.Unlock()
+	return f.list(strings.Trim(resource, "/"), nil)
+}
+
+// Calls returns requests received by session so far.
+func (f *FakeSession) Calls() []Call {
+	f.dataMu.Lock()
+	defer f.dataMu.Unlock()
+	return append([]Call(nil), f.calls...)
+}
+
+func (f *FakeSession) Get(ctx context.Context, rawUrl string, body io.Reader) (*http.Response, error) {
+	return f.handle(ctx, http.MethodGet, rawUrl, body, nil)
+}
+
+func (f *FakeSession) Post(ctx context.Context, rawUrl string, body io.Reader) (*http.Response, error) {
+	return f.handle(ctx, http.MethodPost, rawUrl, body, nil)
+}
+
+func (f *FakeSession) Put(ctx context.Context, rawUrl string, body io.Reader) (*http.Response, error) {
+	return f.handle(ctx, http.MethodPut, rawUrl, body, nil)
+}
+
+func (f *FakeSession) Patch(ctx context.Context, rawUrl string, body io.Reader) (*http.Response, error) {
+	return f.handle(ctx, http.MethodPatch, rawUrl, body, nil)
+}
+
+func (f *FakeSession) Delete(ctx context.Context, rawUrl string, body io.Reader) (*http.Response, error) {
+	return f.handle(ctx, http.MethodDelete, rawUrl, body, nil)
+}
+
+func (f *FakeSession) GetConfig() *client.VMSConfig {
+	return f.config
+}
+
+func (f *FakeSession) Lock()   { f.mu.Lock() }
+func (f *FakeSession) Unlock() { f.mu.Unlock() }
+
+// handle dispatches request to in-memory storage.
+func (f *FakeSession) handle(ctx context.Context, verb, rawUrl string, body io.Reader, header http.Header) (*http.Response, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	parsed, err := url.Parse(rawUrl)
+	if err != nil {
+		return nil, err
+	}
+	params := client.Params{}
+	if body != nil {
+		raw, err := io.ReadAll(body)
+		if err != nil {
+			return nil, err
+		}
+		if len(bytes.TrimSpace(raw)) > 0 {
+			if err = json.Unmarshal(raw, &params); err != nil {
+				return nil, err
+			}
+		}
+	}
+	resource, id, hasId := splitPath(parsed.Path)
+	query := parsed.Query()
+
+	f.dataMu.Lock()
+	defer f.dataMu.Unlock()
+	f.calls = append(f.calls, Call{Verb: verb, Path: strings.TrimPrefix(parsed.Path, "/"), Query: query, Body: params, Header: header})
+
+	if !hasId {
+		switch verb {
+		case http.MethodGet:
+			if query.Has("page_size") {
+				return paginate(verb, parsed, f.list(resource, query))
+			}
+			return jsonResponse(http.StatusOK, f.list(resource, query))
+		case http.MethodPost:
+			return jsonResponse(http.StatusCreated, f.store(resource, params))
+		default:
+			return errorResponse(verb, rawUrl, http.StatusMethodNotAllowed, fmt.Sprintf("%s is not supported for %q", verb, resource))
+		}
+	}
+	record, ok := f.data[resource][id]
+	if !ok {
+		return errorResponse(verb, rawUrl, http.StatusNotFound, fmt.Sprintf("%s %d not found", resource, id))
+	}
+	switch verb {
+	case http.MethodGet:
+		return jsonResponse(http.StatusOK, copyRecord(record))
+	case http.MethodPatch:
+		for key, value := range params {
+			record[key] = value
+		}
+		return jsonResponse(http.StatusOK, copyRecord(record))
+	case http.MethodPut:
+		replaced := client.Record(params)
+		replaced["id"] = float64(id)
+		f.data[resource][id] = replaced
+		return jsonResponse(http.StatusOK, copyRecord(replaced))
+	case http.MethodDelete:
+		delete(f.data[resource], id)
+		return &http.Response{
+			StatusCode: http.StatusNoContent,
+			Header:     http.Header{},
+			Body:       io.NopCloser(bytes.NewReader(nil)),
+		}, nil
+	default:
+		return errorResponse(verb, rawUrl, http.StatusMethodNotAllowed, fmt.Sprintf("%s is not supported for %q", verb, resource))
+	}
+}
+
+// store saves record under resource assigning id if record has none. Must be called with dataMu held.
+func (f *FakeSession) store(resource string, params client.Params) client.Record {
+	record := client.Record{}
+	for key, value := range params {
+		record[key] = value
+	}
+	id, err := record.GetInt64("id")
+	if err != nil {
+		f.nextId[resource]++
+		id = f.nextId[resource]
+		record["id"] = float64(id)
+	} else if id > f.nextId[resource] {
+		f.nextId[resource] = id
+	}
+	if f.data[resource] == nil {
+		f.data[resource] = make(map[int64]client.Record)
+	}
+	f.data[resource][id] = record
+	return copyRecord(record)
+}
+
+// list returns copies of resource records matching query sorted by id. Must be called with dataMu held.
+func (f *FakeSession) list(resource string, query url.Values) client.RecordSet {
+	ids := make([]int64, 0, len(f.data[resource]))
+	for id := range f.data[resource] {
+		ids = append(ids, id)
+	}
+	sort.Slice(ids, func(i, j int) bool { return ids[i] < ids[j] })
+	result := client.RecordSet{}
+	for _, id := range ids {
+		record := f.data[resource][id]
+		if !matches(record, query) {
+			continue
+		}
+		if fields := query.Get("fields"); fields != "" {
+			record = project(record, strings.Split(fields, ","))
+		}
+		result = append(result, copyRecord(record))
+	}
+	return result
+}
+
+// paginate returns page of records selected by "page" and "page_size" query params of request url
+// wrapped into paginated envelope.
+func paginate(verb string, requestUrl *url.URL, records client.RecordSet) (*http.Response, error) {
+	query := requestUrl.Query()
+	size, err := strconv.Atoi(query.Get("page_size"))
+	if err != nil || size <= 0 {
+		return errorResponse(verb, requestUrl.String(), http.StatusBadRequest, "invalid page_size")
+	}
+	page := 1
+	if query.Has("page") {
+		if page, err = strconv.Atoi(query.Get("page")); err != nil || page <= 0 {
+			return errorResponse(verb, requestUrl.String(), http.StatusNotFound, "invalid page")
+		}
+	}
+	start, end := min((page-1)*size, len(records)), min(page*size, len(records))
+	pageUrl := func(n int) any {
+		if n < 1 || (n-1)*size >= len(records) {
+			return nil
+		}
+		q := requestUrl.Query()
+		q.Set("page", strconv.Itoa(n))
+		u := *requestUrl
+		u.RawQuery = q.Encode()
+		return u.String()
+	}
+	return jsonResponse(http.StatusOK, map[string]any{
+		"count":    len(records),
+		"next":     pageUrl(page + 1),
+		"previous": pageUrl(page - 1),
+		"results":  records[start:end],
+	})
+}
+
+// splitPath strips "api/<version>" prefix and splits path to resource and trailing numeric id.
+func splitPath(path string) (resource string, id int64, hasId bool) {
+	segments := strings.Split(strings.Trim(path, "/"), "/")
+	if len(segments) > 0 && segments[0] == "api" {
+		segments = segments[1:]
+	}
+	if len(segments) > 0 && apiVersionSegment.MatchString(segments[0]) {
+		segments = segments[1:]
+	}
+	if n := len(segments); n > 1 {
+		if parsed, err := strconv.ParseInt(segments[n-1], 10, 64); err == nil {
+			return strings.Join(segments[:n-1], "/"), parsed, true
+		}
+	}
+	return strings.Join(segments, "/"), 0, false
+}
+
+// matches reports whether record values match all filter params of query.
+func matches(record client.Record, query url.Values) bool {
+	for key, values := range query {
+		if _, ok := reservedParams[key]; ok {
+			continue
+		}
+		actual, ok := record[key]
+		if !ok || len(values) == 0 || fmt.Sprintf("%v", actual) != values[0] {
+			return false
+		}
+	}
+	return true
+}
+
+func project(record client.Record, fields []string) client.Record {
+	projected := client.Record{}
+	for _, field := range fields {
+		if value, ok := record[strings.TrimSpace(field)]; ok {
+			projected[strings.TrimSpace(field)] = value
+		}
+	}
+	return projected
+}
+
+// normalize converts record values to JSON types (as if record was received from VMS).
+func normalize(record client.Record) client.Params {
+	raw, err := json.Marshal(record)
+	if err != nil {
+		return client.Params(record)
+	}
+	var params client.Params
+	if err = json.Unmarshal(raw, &params); err != nil {
+		return client.Params(record)
+	}
+	return params
+}
+
+func copyRecord(record client.Record) client.Record {
+	copied := make(client.Record, len(record))
+	for key, value := range record {
+		copied[key] = value
+	}
+	return copied
+}
+
+func jsonResponse(status int, payload any) (*http.Response, error) {
+	raw, err := json.Marshal(payload)
+	if err != nil {
+		return nil, err
+	}
+	return &http.Response{
+		StatusCode: status,
+		Header:     http.Header{"Content-Type": {client.ApplicationJson}},
+		Body:       io.NopCloser(bytes.NewReader(raw)),
+	}, nil
+}
+
+// errorResponse returns response along with client.ApiError the same way as real session does for non 2xx statuses.
+func errorResponse(verb, rawUrl string, status int, detail string) (*http.Response, error) {
+	body, _ := json.Marshal(map[string]string{"detail": detail})
+	response := &http.Response{
+		StatusCode: status,
+		Header:     http.Header{"Content-Type": {client.ApplicationJson}},
+		Body:       io.NopCloser(bytes.NewReader(body)),
+	}
+	return response, &client.ApiError{StatusCode: status, Method: verb, URL: rawUrl, Body: string(body)}
+}
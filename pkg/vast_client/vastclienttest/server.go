@@ -0,0 +1,222 @@
+package vastclienttest
+
+import (
+	"crypto/rand"
+	"embed"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"path"
+	"strings"
+	"sync"
+
+	client "github.com/600apples/go-vast-client/pkg/vast_client"
+)
+
+// Credentials accepted by Server.
+const (
+	ServerUsername = "admin"
+	ServerPassword = "123456"
+	ServerApiToken = "test-api-token"
+)
+
+//go:embed fixtures/*.json
+var fixtures embed.FS
+
+// Fixture returns records of embedded fixture (e.g. "views", "quotas", "vtasks", "versions").
+// Fixtures are representative VMS API payloads which are loaded into every Server.
+func Fixture(name string) (client.RecordSet, error) {
+	raw, err := fixtures.ReadFile(path.Join("fixtures", name+".json"))
+	if err != nil {
+		return nil, err
+	}
+	var records client.RecordSet
+	if err = json.Unmarshal(raw, &records); err != nil {
+		return nil, fmt.Errorf("invalid fixture %q: %w", name, err)
+	}
+	return records, nil
+}
+
+// Server is httptest.Server emulating VMS API: token endpoints (api/token/, api/token/refresh/,
+// api/token/blacklist/) and resources backed by FakeSession storage preloaded with fixtures.
+// Requests to resources must be authorized either with JWT issued by token endpoint or with ServerApiToken.
+//
+// Example:
+//
+//	srv := vastclienttest.NewServer()
+//	defer srv.Close()
+//	rest := client.NewVMSRest(srv.Config())
+//	views, err := rest.Views.List(ctx, nil)
+type Server struct {
+	*httptest.Server
+	Fake *FakeSession // Storage of resources. Use it to seed records or inspect received calls.
+
+	mu      sync.Mutex
+	access  map[string]struct{} // Issued access tokens
+	refresh map[string]struct{} // Issued (not revoked) refresh tokens
+}
+
+// NewServer starts Server. Caller must Close it.
+func NewServer() *Server {
+	s := &Server{
+		Fake:    NewFakeSession(nil),
+		access:  make(map[string]struct{}),
+		refresh: make(map[string]struct{}),
+	}
+	entries, err := fixtures.ReadDir("fixtures")
+	if err != nil {
+		panic(err)
+	}
+	for _, entry := range entries {
+		name := strings.TrimSuffix(entry.Name(), ".json")
+		records, err := Fixture(name)
+		if err != nil {
+			panic(err)
+		}
+		s.Fake.Clear(name)
+		s.Fake.Seed(name, records...)
+	}
+	s.Server = httptest.NewServer(http.HandlerFunc(s.serveHTTP))
+	return s
+}
+
+// Config returns VMSConfig pointing to the server with username/password authentication.
+func (s *Server) Config() *client.VMSConfig {
+	return &client.VMSConfig{
+		BaseURL:  s.URL,
+		Username: ServerUsername,
+		Password: ServerPassword,
+	}
+}
+
+// ApiTokenConfig returns VMSConfig pointing to the server with api token authentication.
+func (s *Server) ApiTokenConfig() *client.VMSConfig {
+	return &client.VMSConfig{
+		BaseURL:  s.URL,
+		ApiToken: ServerApiToken,
+	}
+}
+
+// Rest creates client for the server with preset for tests (see client.NewForTests).
+func (s *Server) Rest(opts ...client.VMSConfigFunc) (*client.VMSRest, error) {
+	return client.NewForTests(s, opts...)
+}
+
+func (s *Server) serveHTTP(w http.ResponseWriter, r *http.Request) {
+	switch strings.Trim(r.URL.Path, "/") {
+	case "api/token":
+		s.issueToken(w, r)
+		return
+	case "api/token/refresh":
+		s.refreshToken(w, r)
+		return
+	case "api/token/blacklist":
+		s.revokeToken(w, r)
+		return
+	}
+	if !s.authorized(r.Header.Get("Authorization")) {
+		writeJSON(w, http.StatusUnauthorized, map[string]string{"detail": "Authentication credentials were not provided."})
+		return
+	}
+	response, err := s.Fake.handle(r.Context(), r.Method, r.URL.String(), r.Body, r.Header.Clone())
+	if response == nil {
+		writeJSON(w, http.StatusInternalServerError, map[string]string{"detail": fmt.Sprint(err)})
+		return
+	}
+	defer response.Body.Close()
+	for key, values := range response.Header {
+		w.Header()[key] = values
+	}
+	w.WriteHeader(response.StatusCode)
+	_, _ = io.Copy(w, response.Body)
+}
+
+func (s *Server) issueToken(w http.ResponseWriter, r *http.Request) {
+	var credentials struct {
+		Username string `json:"username"`
+		Password string `json:"password"`
+	}
+	if r.Method != http.MethodPost || json.NewDecoder(r.Body).Decode(&credentials) != nil {
+		writeJSON(w, http.StatusBadRequest, map[string]string{"detail": "invalid token request"})
+		return
+	}
+	if credentials.Username != ServerUsername || credentials.Password != ServerPassword {
+		writeJSON(w, http.StatusUnauthorized, map[string]string{"detail": "No active account found with the given credentials"})
+		return
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	access, refresh := randomToken(), randomToken()
+	s.access[access] = struct{}{}
+	s.refresh[refresh] = struct{}{}
+	writeJSON(w, http.StatusOK, map[string]string{"access": access, "refresh": refresh})
+}
+
+func (s *Server) refreshToken(w http.ResponseWriter, r *http.Request) {
+	refresh, ok := s.decodeRefresh(w, r)
+	if !ok {
+		return
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	access := randomToken()
+	s.access[access] = struct{}{}
+	writeJSON(w, http.StatusOK, map[string]string{"access": access, "refresh": refresh})
+}
+
+func (s *Server) revokeToken(w http.ResponseWriter, r *http.Request) {
+	refresh, ok := s.decodeRefresh(w, r)
+	if !ok {
+		return
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	delete(s.refresh, refresh)
+	writeJSON(w, http.StatusOK, map[string]string{})
+}
+
+// decodeRefresh reads refresh token from request body and verifies it was issued and not revoked.
+func (s *Server) decodeRefresh(w http.ResponseWriter, r *http.Request) (string, bool) {
+	var body struct {
+		Refresh string `json:"refresh"`
+	}
+	if r.Method != http.MethodPost || json.NewDecoder(r.Body).Decode(&body) != nil {
+		writeJSON(w, http.StatusBadRequest, map[string]string{"detail": "invalid token request"})
+		return "", false
+	}
+	s.mu.Lock()
+	_, ok := s.refresh[body.Refresh]
+	s.mu.Unlock()
+	if !ok {
+		writeJSON(w, http.StatusUnauthorized, map[string]string{"detail": "Token is invalid or expired"})
+		return "", false
+	}
+	return body.Refresh, true
+}
+
+// authorized reports whether Authorization header carries issued access token or ServerApiToken.
+func (s *Server) authorized(header string) bool {
+	if token, ok := strings.CutPrefix(header, "Bearer "); ok {
+		s.mu.Lock()
+		defer s.mu.Unlock()
+		_, issued := s.access[token]
+		return issued
+	}
+	token, ok := strings.CutPrefix(header, "Api-Token ")
+	return ok && token == ServerApiToken
+}
+
+func randomToken() string {
+	raw := make([]byte, 16)
+	_, _ = rand.Read(raw)
+	return hex.EncodeToString(raw)
+}
+
+func writeJSON(w http.ResponseWriter, status int, payload any) {
+	w.Header().Set("Content-Type", client.ApplicationJson)
+	w.WriteHeader(status)
+	_ = json.NewEncoder(w).Encode(payload)
+}
@@ -0,0 +1,225 @@
+package vastclienttest_test
+
+import (
+	"context"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	client "github.com/600apples/go-vast-client/pkg/vast_client"
+	"github.com/600apples/go-vast-client/pkg/vast_client/vastclienttest"
+)
+
+// configServer is client.FakeServer which returns fixed config.
+type configServer struct {
+	config *client.VMSConfig
+}
+
+func (s configServer) Config() *client.VMSConfig {
+	return s.config
+}
+
+func newServerRest(t *testing.T) (*client.VMSRest, *vastclienttest.Server) {
+	t.Helper()
+	srv := vastclienttest.NewServer()
+	t.Cleanup(srv.Close)
+	rest, err := srv.Rest()
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = rest.Close() })
+	return rest, srv
+}
+
+func TestServerAuthentication(t *testing.T) {
+	srv := vastclienttest.NewServer()
+	defer srv.Close()
+	tests := []struct {
+		name       string
+		config     *client.VMSConfig
+		wantPrefix string
+		wantStatus int
+	}{
+		{name: "username and password", config: srv.Config(), wantPrefix: "Bearer "},
+		{name: "api token", config: srv.ApiTokenConfig(), wantPrefix: "Api-Token " + vastclienttest.ServerApiToken},
+		{
+			name:       "invalid api token",
+			config:     &client.VMSConfig{BaseURL: srv.URL, ApiToken: "wrong"},
+			wantStatus: http.StatusUnauthorized,
+		},
+		{
+			name:       "invalid password",
+			config:     &client.VMSConfig{BaseURL: srv.URL, Username: vastclienttest.ServerUsername, Password: "wrong"},
+			wantStatus: http.StatusUnauthorized,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rest, err := client.NewForTests(configServer{tt.config})
+			require.NoError(t, err)
+			defer rest.Close()
+			callsBefore := len(srv.Fake.Calls())
+
+			_, err = rest.Views.List(context.Background(), nil)
+
+			if tt.wantStatus != 0 {
+				var apiErr *client.ApiError
+				require.ErrorAs(t, err, &apiErr)
+				assert.Equal(t, tt.wantStatus, apiErr.StatusCode)
+				return
+			}
+			require.NoError(t, err)
+			calls := srv.Fake.Calls()[callsBefore:]
+			require.NotEmpty(t, calls)
+			last := calls[len(calls)-1]
+			assert.Equal(t, "api/v5/views", last.Path)
+			assert.True(t, strings.HasPrefix(last.Header.Get("Authorization"), tt.wantPrefix), last.Header.Get("Authorization"))
+		})
+	}
+}
+
+func TestServerGetFixtures(t *testing.T) {
+	rest, _ := newServerRest(t)
+	ctx := context.Background()
+
+	view, err := rest.Views.Get(ctx, client.Params{"name": "data"})
+	require.NoError(t, err)
+	fixture, err := vastclienttest.Fixture("views")
+	require.NoError(t, err)
+	assert.Equal(t, fixture[1]["path"], view["path"])
+	assert.Equal(t, "View", view["@resourceType"])
+
+	byId, err := rest.Views.GetById(ctx, 1)
+	require.NoError(t, err)
+	assert.Equal(t, "default", byId["name"])
+
+	_, err = rest.Views.Get(ctx, client.Params{"name": "missing"})
+	var notFound *client.NotFoundError
+	assert.ErrorAs(t, err, &notFound)
+
+	_, err = rest.Views.GetById(ctx, 999)
+	var apiErr *client.ApiError
+	require.ErrorAs(t, err, &apiErr)
+	assert.Equal(t, http.StatusNotFound, apiErr.StatusCode)
+}
+
+func TestServerCreateUpdateDelete(t *testing.T) {
+	rest, srv := newServerRest(t)
+	ctx := context.Background()
+
+	created, err := rest.Views.Create(ctx, client.Params{"name": "new", "path": "/new", "policy_id": 1})
+	require.NoError(t, err)
+	id, err := created.GetInt64("id")
+	require.NoError(t, err)
+	assert.Equal(t, int64(3), id, "id is assigned after fixture ids")
+
+	updated, err := rest.Views.Update(ctx, id, client.Params{"path": "/renamed"})
+	require.NoError(t, err)
+	assert.Equal(t, "/renamed", updated["path"])
+	assert.Equal(t, "new", updated["name"])
+
+	_, err = rest.Views.Delete(ctx, client.Params{"name": "new"})
+	require.NoError(t, err)
+	_, err = rest.Views.Get(ctx, client.Params{"name": "new"})
+	var notFound *client.NotFoundError
+	assert.ErrorAs(t, err, &notFound)
+	assert.Len(t, srv.Fake.Records("views"), 2)
+
+	var verbs []string
+	for _, call := range srv.Fake.Calls() {
+		if call.Verb != http.MethodGet {
+			verbs = append(verbs, call.Verb+" "+call.Path)
+		}
+	}
+	assert.Equal(t, []string{"POST api/v5/views", "PATCH api/v5/views/3", "DELETE api/v5/views/3"}, verbs)
+}
+
+func TestServerPagination(t *testing.T) {
+	rest, srv := newServerRest(t)
+	ctx := context.Background()
+	srv.Fake.Clear("quotas")
+	for i := 0; i < 25; i++ {
+		srv.Fake.Seed("quotas", client.Record{"name": "q", "path": "/q", "tenant_id": 1})
+	}
+
+	all, err := rest.Quotas.List(ctx, client.Params{"page_size": 10})
+	require.NoError(t, err)
+	assert.Len(t, all, 25)
+	ids := all.Pluck("id")
+	assert.Equal(t, float64(1), ids[0])
+	assert.Equal(t, float64(25), ids[24])
+
+	var pages []string
+	for _, call := range srv.Fake.Calls() {
+		if call.Path == "api/v5/quotas" {
+			pages = append(pages, call.Query.Get("page"))
+		}
+	}
+	assert.Equal(t, []string{"", "2", "3"}, pages)
+
+	count, err := rest.Quotas.Count(ctx, client.Params{"tenant_id": 1})
+	require.NoError(t, err)
+	assert.Equal(t, int64(25), count)
+
+	page, err := rest.Quotas.List(ctx, client.Params{"page_size": 10, "page": 3})
+	require.NoError(t, err)
+	assert.Len(t, page, 5)
+
+	limited, err := rest.Quotas.List(ctx, client.Params{}.Offset(12).Limit(3))
+	require.NoError(t, err)
+	assert.Equal(t, []any{float64(13), float64(14), float64(15)}, limited.Pluck("id"))
+}
+
+func TestServerTaskPolling(t *testing.T) {
+	rest, srv := newServerRest(t)
+	ctx := context.Background()
+	opts := client.WaitTaskOptions{Interval: 10 * time.Millisecond, Timeout: 5 * time.Second}
+
+	task, err := rest.VTasks.WaitTask(ctx, 101, opts)
+	require.NoError(t, err)
+	assert.Equal(t, "completed", task["state"])
+
+	go func() {
+		time.Sleep(50 * time.Millisecond)
+		srv.Fake.Seed("vtasks", client.Record{"id": 102, "name": "ModifyVolumeTask", "state": "completed"})
+	}()
+	task, err = rest.VTasks.WaitTask(ctx, 102, opts)
+	require.NoError(t, err)
+	assert.Equal(t, "completed", task["state"])
+	var polls int
+	for _, call := range srv.Fake.Calls() {
+		if call.Path == "api/v5/vtasks/102" {
+			polls++
+		}
+	}
+	assert.Greater(t, polls, 1, "running task is polled until completion")
+
+	srv.Fake.Seed("vtasks", client.Record{"id": 103, "name": "CreateViewTask", "state": "failed", "messages": []string{"boom"}})
+	_, err = rest.VTasks.WaitTask(ctx, 103, opts)
+	var failed *client.TaskFailedError
+	require.ErrorAs(t, err, &failed)
+	assert.Equal(t, int64(103), failed.TaskId)
+	assert.Equal(t, "CreateViewTask", failed.Name)
+
+	srv.Fake.Seed("vtasks", client.Record{"id": 104, "name": "StuckTask", "state": "running"})
+	_, err = rest.VTasks.WaitTask(ctx, 104, client.WaitTaskOptions{Interval: 10 * time.Millisecond, Timeout: 50 * time.Millisecond})
+	var timeout *client.TaskTimeoutError
+	assert.ErrorAs(t, err, &timeout)
+}
+
+func TestFakeRest(t *testing.T) {
+	rest, fake := vastclienttest.NewRest()
+	ctx := context.Background()
+	fake.Seed("views", client.Record{"name": "existing", "path": "/existing"})
+
+	view, err := rest.Views.Ensure(ctx, "myview", client.Params{"path": "/myview", "policy_id": 1})
+	require.NoError(t, err)
+	assert.Equal(t, float64(2), view["id"])
+	assert.Equal(t, []any{"existing", "myview"}, fake.Records("views").Pluck("name"))
+
+	existing, err := rest.Views.Ensure(ctx, "existing", client.Params{"path": "/other"})
+	require.NoError(t, err)
+	assert.Equal(t, "/existing", existing["path"])
+}
@@ -0,0 +1,93 @@
+package vast_client
+
+import (
+	"context"
+	"net/http"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// versionHandler serves cluster version on "versions" endpoint and empty lists for other resources.
+func versionHandler(sysVersion string) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if resourceFromPath(r.URL.Path) == "versions" {
+			writeTestJSON(w, http.StatusOK, []map[string]any{{"id": 1, "sys_version": sysVersion, "status": "success"}})
+			return
+		}
+		writeTestJSON(w, http.StatusOK, []map[string]any{})
+	}
+}
+
+// countRequests returns number of requests to resource path.
+func countRequests(requests []recordedRequest, path string) int {
+	var n int
+	for _, r := range requests {
+		if r.Path == path {
+			n++
+		}
+	}
+	return n
+}
+
+func TestClusterVersionIsCachedPerClient(t *testing.T) {
+	ctx := context.Background()
+	newSrv := newTestServer(t, versionHandler("5.3.1.2"))
+	oldSrv := newTestServer(t, versionHandler("5.2.0.10"))
+	newRest := newTestRest(t, newSrv.config())
+	oldRest := newTestRest(t, oldSrv.config())
+
+	newVersion, err := newRest.Versions.GetVersion(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, "5.3.1", newVersion.String())
+	oldVersion, err := oldRest.Versions.GetVersion(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, "5.2.0", oldVersion.String())
+
+	// Volumes are available from 5.3.0
+	_, err = newRest.Volumes.List(ctx, nil)
+	require.NoError(t, err)
+	_, err = oldRest.Volumes.List(ctx, nil)
+	var unsupported *UnsupportedVersionError
+	require.ErrorAs(t, err, &unsupported)
+	assert.Equal(t, "5.2.0", unsupported.ClusterVersion)
+
+	assert.Equal(t, 1, countRequests(newSrv.Requests(), "versions"))
+	assert.Equal(t, 1, countRequests(oldSrv.Requests(), "versions"))
+}
+
+func TestClusterVersionConcurrentFirstUse(t *testing.T) {
+	srv := newTestServer(t, versionHandler("5.3.0"))
+	rest := newTestRest(t, srv.config())
+
+	var wg sync.WaitGroup
+	for range 20 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			_, err := rest.Volumes.List(context.Background(), nil)
+			assert.NoError(t, err)
+		}()
+	}
+	wg.Wait()
+
+	assert.Equal(t, 1, countRequests(srv.Requests(), "versions"))
+}
+
+func TestClusterVersionInvalidateCache(t *testing.T) {
+	ctx := context.Background()
+	srv := newTestServer(t, versionHandler("5.3.0"))
+	rest := newTestRest(t, srv.config())
+
+	_, err := rest.Versions.GetVersion(ctx)
+	require.NoError(t, err)
+	_, err = rest.Versions.GetVersion(ctx)
+	require.NoError(t, err)
+	rest.Versions.InvalidateCache()
+	_, err = rest.Versions.GetVersion(ctx)
+	require.NoError(t, err)
+
+	assert.Equal(t, 2, countRequests(srv.Requests(), "versions"))
+}